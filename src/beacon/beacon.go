@@ -0,0 +1,62 @@
+// Package beacon provides a drand-style verifiable-randomness source for
+// stamping L1 commit payloads with an unpredictable-but-checkable ordering
+// nonce, replacing a bare time.Now() timestamp that a proposer could grind
+// or replay undetected.
+package beacon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Entry is one published round: Signature is the group signature over
+// signedMessage(Round, Previous), and Randomness is SHA-256(Signature) - the
+// actual nonce consumers should use, since the signature itself is only
+// meaningful for verification.
+type Entry struct {
+	Round      uint64
+	Signature  []byte
+	Previous   []byte
+	Randomness []byte
+}
+
+// Beacon is a source of verifiable-randomness rounds, chained by each
+// round's signature covering the previous round's signature. Requesting
+// round 0 returns the latest published round, along with its actual round
+// number - needed by callers comparing against a session's last-seen round.
+type Beacon interface {
+	// Entry returns the round actually served (== round, unless round is 0,
+	// in which case it's the latest), its signature, and the previous
+	// round's signature.
+	Entry(round uint64) (actualRound uint64, sig []byte, prev []byte, err error)
+	// Verify checks that entry chains from prev (entry.Previous must equal
+	// prev.Signature, unless entry is the first round) and that its
+	// signature was produced by this beacon's group key.
+	Verify(entry *Entry, prev *Entry) error
+}
+
+// signedMessage is what a beacon's group key signs for round: the round
+// number as 8 bytes big-endian, followed by the previous round's signature.
+// This mirrors drand's own chained-randomness message format.
+func signedMessage(round uint64, previous []byte) []byte {
+	msg := make([]byte, 8+len(previous))
+	binary.BigEndian.PutUint64(msg[:8], round)
+	copy(msg[8:], previous)
+	return msg
+}
+
+// randomnessOf derives an entry's public randomness from its signature.
+func randomnessOf(sig []byte) []byte {
+	sum := sha256.Sum256(sig)
+	return sum[:]
+}
+
+// FetchLatest builds an *Entry from Beacon's latest round (round 0).
+func FetchLatest(b Beacon) (*Entry, error) {
+	round, sig, prev, err := b.Entry(0)
+	if err != nil {
+		return nil, fmt.Errorf("beacon: fetch latest entry: %w", err)
+	}
+	return &Entry{Round: round, Signature: sig, Previous: prev, Randomness: randomnessOf(sig)}, nil
+}
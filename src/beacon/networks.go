@@ -0,0 +1,42 @@
+package beacon
+
+import "fmt"
+
+// NetworkRange binds a Beacon to the L1 height range it's the active source
+// for. ToHeight of 0 means "open-ended" (the current active network).
+type NetworkRange struct {
+	Beacon     Beacon
+	FromHeight int64
+	ToHeight   int64
+}
+
+// contains reports whether height falls within the range.
+func (n NetworkRange) contains(height int64) bool {
+	if height < n.FromHeight {
+		return false
+	}
+	return n.ToHeight == 0 || height <= n.ToHeight
+}
+
+// BeaconNetworks routes a session to the beacon network that was active at
+// its start height, so a long-running session keeps reading rounds from the
+// network it began under even after operators roll over to a newer one.
+type BeaconNetworks struct {
+	ranges []NetworkRange
+}
+
+// NewBeaconNetworks builds a router over ranges. Order doesn't matter; For
+// picks whichever range contains the queried height.
+func NewBeaconNetworks(ranges ...NetworkRange) *BeaconNetworks {
+	return &BeaconNetworks{ranges: ranges}
+}
+
+// For returns the Beacon active at sessionStartHeight.
+func (n *BeaconNetworks) For(sessionStartHeight int64) (Beacon, error) {
+	for _, r := range n.ranges {
+		if r.contains(sessionStartHeight) {
+			return r.Beacon, nil
+		}
+	}
+	return nil, fmt.Errorf("beacon: no network configured for height %d", sessionStartHeight)
+}
@@ -6,75 +6,48 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ahmadzakiakmal/thesis/src/benchmark/client"
 )
 
-type testPackageResponse struct {
-	Body struct {
-		PackageID string `json:"package_id"`
-	} `json:"body"`
-}
-
-type startSessionResponse struct {
-	Body struct {
-		SessionID string `json:"id"`
-	} `json:"body"`
-}
-
-type commitSessionResponse struct {
-	Body struct {
-		L1 struct {
-			BlockHeight int64 `json:"BlockHeight"`
-		} `json:"l1"`
-	} `json:"body"`
-}
-
-const (
-	operatorID  = "OPR-001"
-	destination = "CUSTOMER A"
-	courierID   = "COU-001"
-	priority    = "standard"
-)
-
-type RequestResult struct {
-	Name        string
-	Method      string
-	Endpoint    string
-	Layer       string
-	Latency     time.Duration
-	BlockHeight int64
-}
-
 func main() {
 	l1Nodes := flag.Int("l1", 4, "Number of Layer 1 nodes")
 	l2Nodes := flag.Int("l2", 1, "Number of Layer 2 nodes")
-	iterations := flag.Int("n", 1, "Number of iterations to run")
+	iterations := flag.Int("n", 0, "Number of scenario runs (closed-loop total); ignored if -duration is set")
+	duration := flag.Duration("duration", 0, "How long to generate load, as an alternative to -n")
+	concurrency := flag.Int("concurrency", 1, "Number of worker goroutines pulling scenario runs from the shared job channel")
+	rate := flag.Float64("rate", 0, "Target scenario runs/sec; 0 runs closed-loop (each worker starts its next run as soon as its last one finishes), >0 paces dispatch with a leaky-bucket ticker for open-loop load")
+	warmup := flag.Duration("warmup", 0, "Warmup period excluded from histograms and the summary CSV, but still run")
+	scenario := flag.String("scenario", "full", "Workflow to run: full (packaging happy path), start-only (/session/start only), or mix (random pick of the two per run)")
 	useIPv6 := flag.Bool("ipv6", false, "Use IPv6 localhost (::1) instead of IPv4")
 	flag.Parse()
 
-	l2Url := "http://127.0.0.1:4000"
-	if *useIPv6 {
-		l2Url = "http://[::1]:4000"
+	if *iterations <= 0 && *duration <= 0 {
+		*iterations = 1 // preserve the old single-run default when neither flag is given
 	}
 
-	filename := fmt.Sprintf("benchmark_n_%d_l1_%d_l2_%d.csv", *iterations, *l1Nodes, *l2Nodes)
-	file, err := os.Create(filename)
-	if err != nil {
-		fmt.Printf("Error creating CSV file: %v\n", err)
+	run := scenarioByName(*scenario)
+	if run == nil {
+		fmt.Printf("Unknown -scenario %q (want full, start-only, or mix)\n", *scenario)
 		return
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	l2Url := "http://127.0.0.1:4000"
+	if *useIPv6 {
+		l2Url = "http://[::1]:4000"
+	}
 
-	header := []string{"Iteration", "Step", "Method", "Endpoint", "Layer", "Latency_ms", "BlockHeight"}
-	if err := writer.Write(header); err != nil {
-		fmt.Printf("Error writing CSV header: %v\n", err)
+	requestsPath := fmt.Sprintf("benchmark_requests_%s_c%d_l1_%d_l2_%d.csv", *scenario, *concurrency, *l1Nodes, *l2Nodes)
+	requestsFile, requestsWriter, err := newCSVWriter(requestsPath,
+		[]string{"Iteration", "Step", "Method", "Endpoint", "Layer", "StartTime", "GoroutineID", "Latency_ms", "BlockHeight", "Warmup", "Error"})
+	if err != nil {
+		fmt.Printf("Error creating per-request CSV: %v\n", err)
 		return
 	}
+	defer requestsFile.Close()
+	defer requestsWriter.Flush()
 
 	requestClient := client.NewHTTPClient(l2Url)
 	opts := &client.RequestOptions{
@@ -87,217 +60,177 @@ func main() {
 		Timeout: 10 * time.Second,
 	}
 
-	for i := 0; i < *iterations; i++ {
-		fmt.Printf("\n[Iteration %d/%d]\n", i+1, *iterations)
-		results := runBenchmark(requestClient, opts)
-
-		for _, result := range results {
-			record := []string{
-				strconv.Itoa(i + 1),
-				result.Name,
-				result.Method,
-				result.Endpoint,
-				result.Layer,
-				strconv.FormatInt(result.Latency.Milliseconds(), 10),
-				strconv.FormatInt(result.BlockHeight, 10),
+	hist := newStepHistograms()
+
+	jobs := make(chan struct{}, *concurrency*4)
+	stop := make(chan struct{})
+	go generateJobs(jobs, int64(*iterations), *duration, *rate, stop)
+
+	warmupDeadline := time.Now().Add(*warmup)
+
+	var csvMu sync.Mutex
+	var iteration int64
+	var wg sync.WaitGroup
+
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for range jobs {
+				reqStart := time.Now()
+				results := run(requestClient, opts)
+				inWarmup := reqStart.Before(warmupDeadline)
+
+				csvMu.Lock()
+				iteration++
+				n := iteration
+				for _, r := range results {
+					r.StartTime = reqStart
+					r.GoroutineID = goroutineID
+					writeRequestRecord(requestsWriter, n, r, inWarmup)
+					if !inWarmup {
+						hist.record(r)
+					}
+				}
+				csvMu.Unlock()
 			}
+		}(w)
+	}
 
-			if err := writer.Write(record); err != nil {
-				fmt.Printf("Error writing record to CSV: %v\n", err)
-			}
-		}
+	wg.Wait()
+	close(stop)
+
+	elapsed := time.Since(warmupDeadline)
+	if elapsed < 0 {
+		elapsed = 0
+	}
 
-		time.Sleep(100 * time.Millisecond)
+	summaryPath := fmt.Sprintf("benchmark_summary_%s_c%d_l1_%d_l2_%d.csv", *scenario, *concurrency, *l1Nodes, *l2Nodes)
+	if err := writeSummaryCSV(summaryPath, hist.summaries(elapsed)); err != nil {
+		fmt.Printf("Error writing summary CSV: %v\n", err)
+		return
 	}
 
-	fmt.Printf("\nBenchmark complete. Results saved to %s\n", filename)
+	fmt.Printf("\nBenchmark complete. Per-request results in %s, summary in %s\n", requestsPath, summaryPath)
 }
 
-func runBenchmark(requestClient *client.HTTPClient, opts *client.RequestOptions) []RequestResult {
-	var results []RequestResult
-	totalStart := time.Now()
+// generateJobs is the shared job channel's only producer. In closed-loop
+// mode (rate <= 0) it sends as fast as workers drain the buffered channel,
+// so each worker's own completion time paces its next run. In open-loop
+// mode (rate > 0) it's gated by a ticker instead - a leaky bucket that
+// dispatches on a fixed schedule independent of whether workers have
+// caught up, queuing in jobs' buffer (and blocking once that's full) if
+// they haven't. It stops once duration has elapsed (when set) or n jobs
+// have been sent, or immediately if stop is closed.
+func generateJobs(jobs chan<- struct{}, n int64, duration time.Duration, rate float64, stop <-chan struct{}) {
+	defer close(jobs)
 
-	// 1. Create Test Package
-	start := time.Now()
-	resp, err := requestClient.POST("/session/test-package", nil, opts)
-	elapsed := time.Since(start)
-	if err != nil {
-		fmt.Println(err)
-		return results
+	var ticker *time.Ticker
+	if rate > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
 	}
-	var testPackageResponse testPackageResponse
-	client.UnmarshalBody(resp, &testPackageResponse)
-	packageId := testPackageResponse.Body.PackageID
-	fmt.Printf("PackageID : %s [Delay: %v]\n", packageId, elapsed)
 
-	results = append(results, RequestResult{
-		Name:     "Create Package",
-		Method:   "POST",
-		Endpoint: "/session/test-package",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
-
-	// 2. Start Session
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	body := map[string]interface{}{
-		"operator_id": operatorID,
-	}
-	resp, err = requestClient.POST("/session/start", body, opts)
-	elapsed = time.Since(start)
-	if err != nil {
-		fmt.Println(err)
-		return results
+	var deadline time.Time
+	if duration > 0 {
+		deadline = time.Now().Add(duration)
 	}
-	var startSessionResponse startSessionResponse
-	client.UnmarshalBody(resp, &startSessionResponse)
-	sessionID := startSessionResponse.Body.SessionID
-	fmt.Printf("SessionID : %s [Delay: %v]\n", sessionID, elapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Start Session",
-		Method:   "POST",
-		Endpoint: "/session/start",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
 
-	// 3. Scan Package
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	endpoint := fmt.Sprintf("/session/%s/scan/%s", sessionID, packageId)
-	_, err = requestClient.GET(endpoint, opts)
-	elapsed = time.Since(start)
-	if err != nil {
-		fmt.Println(err)
-		return results
+	var dispatched int64
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return
+		}
+		if deadline.IsZero() && dispatched >= n {
+			return
+		}
+		if ticker != nil {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case jobs <- struct{}{}:
+			dispatched++
+		case <-stop:
+			return
+		}
 	}
-	fmt.Printf("Package scan success [Delay: %v]\n", elapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Scan Package",
-		Method:   "GET",
-		Endpoint: "session/:id/scan/:packageId",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
+}
 
-	// 4. Validate Package
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	endpoint = fmt.Sprintf("/session/%s/validate", sessionID)
-	body = map[string]interface{}{
-		"package_id": packageId,
-		"signature":  "any",
-	}
-	_, err = requestClient.POST(endpoint, body, opts)
-	elapsed = time.Since(start)
+func newCSVWriter(path string, header []string) (*os.File, *csv.Writer, error) {
+	file, err := os.Create(path)
 	if err != nil {
-		fmt.Println(err)
-		return results
-	}
-	fmt.Printf("Package validation success [Delay: %v]\n", elapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Validate Package",
-		Method:   "POST",
-		Endpoint: "session/:id/validate",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
-
-	// 5. Quality Check
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	endpoint = fmt.Sprintf("/session/%s/qc", sessionID)
-	body = map[string]interface{}{
-		"passed": true,
-		"issues": []string{"all good"},
+		return nil, nil, err
 	}
-	_, err = requestClient.POST(endpoint, body, opts)
-	elapsed = time.Since(start)
-	if err != nil {
-		fmt.Println(err)
-		return results
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		file.Close()
+		return nil, nil, err
 	}
-	fmt.Printf("QC request successful [Delay: %v]\n", elapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Quality Check",
-		Method:   "POST",
-		Endpoint: "session/:id/qc",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
+	return file, writer, nil
+}
 
-	// 6. Label Package
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	endpoint = fmt.Sprintf("/session/%s/label", sessionID)
-	body = map[string]interface{}{
-		"destination": destination,
-		"priority":    priority,
-		"courier_id":  courierID,
-	}
-	_, err = requestClient.POST(endpoint, body, opts)
-	elapsed = time.Since(start)
-	if err != nil {
-		fmt.Println(err)
-		return results
+// writeRequestRecord appends one RequestResult as a row of the per-request
+// CSV. iteration identifies the scenario run r came from; warmup marks
+// rows excluded from the histograms/summary so they can still be told
+// apart after the fact.
+func writeRequestRecord(writer *csv.Writer, iteration int64, r RequestResult, warmup bool) {
+	errMsg := ""
+	if r.Err != nil {
+		errMsg = r.Err.Error()
+	}
+	record := []string{
+		strconv.FormatInt(iteration, 10),
+		r.Name,
+		r.Method,
+		r.Endpoint,
+		r.Layer,
+		r.StartTime.Format(time.RFC3339Nano),
+		strconv.Itoa(r.GoroutineID),
+		strconv.FormatInt(r.Latency.Milliseconds(), 10),
+		strconv.FormatInt(r.BlockHeight, 10),
+		strconv.FormatBool(warmup),
+		errMsg,
+	}
+	if err := writer.Write(record); err != nil {
+		fmt.Printf("Error writing record to CSV: %v\n", err)
 	}
-	fmt.Printf("Package labelling successful [Delay: %v]\n", elapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Label Package",
-		Method:   "POST",
-		Endpoint: "session/:id/label",
-		Layer:    "L2",
-		Latency:  elapsed,
-	})
+}
 
-	// 7. Commit
-	time.Sleep(100 * time.Millisecond)
-	start = time.Now()
-	endpoint = fmt.Sprintf("/commit/%s", sessionID)
-	body = map[string]interface{}{
-		"operator_id":        operatorID,
-		"package_id":         packageId,
-		"supplier_signature": "any",
-		"destination":        destination,
-		"priority":           priority,
-		"courier_id":         courierID,
-	}
-	resp, err = requestClient.POST(endpoint, body, opts)
-	elapsed = time.Since(start)
+// writeSummaryCSV writes one row per step: its percentiles, max latency,
+// throughput, and error rate over the measurement window.
+func writeSummaryCSV(path string, summaries []stepSummary) error {
+	file, writer, err := newCSVWriter(path,
+		[]string{"Step", "Count", "Errors", "ErrorRate", "P50_ms", "P90_ms", "P99_ms", "P999_ms", "Max_ms", "ThroughputRPS"})
 	if err != nil {
-		fmt.Println(err)
-		return results
+		return err
 	}
-	var commitSessionResponse commitSessionResponse
-	client.UnmarshalBody(resp, &commitSessionResponse)
-	blockHeight := commitSessionResponse.Body.L1.BlockHeight
-	fmt.Printf("Session %s, committed successfully to L1, block height %d [Delay: %v]\n", sessionID, blockHeight, elapsed)
-
-	results = append(results, RequestResult{
-		Name:        "Commit Session",
-		Method:      "POST",
-		Endpoint:    "commit/:id",
-		Layer:       "L1+L2",
-		Latency:     elapsed,
-		BlockHeight: blockHeight,
-	})
-
-	totalElapsed := time.Since(totalStart)
-	fmt.Printf("\nTotal workflow execution time: %v\n", totalElapsed)
-
-	results = append(results, RequestResult{
-		Name:     "Complete Workflow",
-		Method:   "WORKFLOW",
-		Endpoint: "complete-workflow",
-		Layer:    "TOTAL",
-		Latency:  totalElapsed,
-	})
+	defer file.Close()
+	defer writer.Flush()
 
-	return results
+	for _, s := range summaries {
+		errorRate := 0.0
+		if s.Count > 0 {
+			errorRate = float64(s.Errors) / float64(s.Count)
+		}
+		record := []string{
+			s.Name,
+			strconv.FormatInt(s.Count, 10),
+			strconv.FormatInt(s.Errors, 10),
+			strconv.FormatFloat(errorRate, 'f', 4, 64),
+			strconv.FormatInt(s.P50.Milliseconds(), 10),
+			strconv.FormatInt(s.P90.Milliseconds(), 10),
+			strconv.FormatInt(s.P99.Milliseconds(), 10),
+			strconv.FormatInt(s.P999.Milliseconds(), 10),
+			strconv.FormatInt(s.Max.Milliseconds(), 10),
+			strconv.FormatFloat(s.ThroughputRPS, 'f', 2, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState is a per-host circuit breaker: after circuitBreakerThreshold
+// consecutive failures it opens and fails fast until circuitBreakerCooldown
+// has passed, then allows one half-open trial request before deciding
+// whether to close (on success) or re-open (on failure).
+type breakerState struct {
+	mu              sync.Mutex
+	consecutiveFail int
+	open            bool
+	openedAt        time.Time
+	halfOpenTrial   bool
+}
+
+// allow reports whether a request may proceed, transitioning an open
+// breaker to half-open once the cooldown has elapsed.
+func (b *breakerState) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if time.Since(b.openedAt) < circuitBreakerCooldown {
+		return false
+	}
+	if b.halfOpenTrial {
+		// A trial is already in flight; don't let a pile-up of callers
+		// all probe the backend at once.
+		return false
+	}
+	b.halfOpenTrial = true
+	return true
+}
+
+func (b *breakerState) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFail = 0
+	b.open = false
+	b.halfOpenTrial = false
+}
+
+func (b *breakerState) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFail++
+	b.halfOpenTrial = false
+	if b.consecutiveFail >= circuitBreakerThreshold {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
+
+// breakerRegistry hands out one breakerState per host, created lazily.
+type breakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*breakerState
+}
+
+func newBreakerRegistry() *breakerRegistry {
+	return &breakerRegistry{breakers: make(map[string]*breakerState)}
+}
+
+func (r *breakerRegistry) get(host string) *breakerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[host]
+	if !ok {
+		b = &breakerState{}
+		r.breakers[host] = b
+	}
+	return b
+}
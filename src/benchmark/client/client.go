@@ -6,17 +6,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"time"
 )
 
+// RequestOptions configures one call through HTTPClient.Call.
 type RequestOptions struct {
 	Headers         map[string]string
 	Timeout         time.Duration
 	FollowRedirects bool
 	Context         context.Context
+
+	// MaxRetries is the number of additional attempts after the first one
+	// fails, bounded by RetryableStatus and the idempotency rule below.
+	// Zero (the default) means no retries.
+	MaxRetries int
+	// BackoffBase and BackoffMax bound the exponential backoff-with-jitter
+	// delay between retries. Defaults (DefaultBackoffBase/DefaultBackoffMax)
+	// are used when left zero.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// IdempotencyKey, if set, is sent as the Idempotency-Key header and
+	// marks this call as safe to retry even for methods (POST, PATCH) that
+	// aren't inherently idempotent. Without it, only GET/PUT/DELETE/HEAD/
+	// OPTIONS calls are retried.
+	IdempotencyKey string
 }
 
+// RetryableStatus reports whether resp warrants a retry. The default
+// (DefaultRetryableStatus) retries server errors and 429s.
+type RetryableStatus func(*Response) bool
+
+// Response is the outcome of one HTTP call, including the final attempt's
+// error if every attempt failed.
 type Response struct {
 	StatusCode int
 	Headers    http.Header
@@ -24,47 +48,191 @@ type Response struct {
 	Error      error
 }
 
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultBackoffBase         = 100 * time.Millisecond
+	DefaultBackoffMax          = 5 * time.Second
+
+	// circuitBreakerThreshold is the number of consecutive failures that
+	// opens a host's breaker.
+	circuitBreakerThreshold = 5
+	// circuitBreakerCooldown is how long a breaker stays open before
+	// allowing a single half-open trial request.
+	circuitBreakerCooldown = 30 * time.Second
+)
+
+// TransportConfig tunes the pooled *http.Transport every HTTPClient request
+// is issued through.
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+}
+
+// DefaultTransportConfig mirrors Go's http.DefaultTransport pooling, tuned
+// slightly higher for a client making many requests to a small number of
+// nodes.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		DialTimeout:         30 * time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+func newTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+}
+
+// DefaultRetryableStatus retries on server errors and rate limiting; client
+// errors (4xx other than 429) are treated as permanent.
+func DefaultRetryableStatus(resp *Response) bool {
+	if resp == nil {
+		return true // transport-level error, no response at all
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// HTTPClient issues requests against BaseURL through a pooled transport,
+// retrying transient failures and tripping a per-host circuit breaker when
+// a backend looks down.
 type HTTPClient struct {
-	BaseURL     string
-	Client      *http.Client
-	DefaultOpts RequestOptions
+	BaseURL         string
+	Client          *http.Client
+	DefaultOpts     RequestOptions
+	RetryableStatus RetryableStatus
+
+	breakers *breakerRegistry
 }
 
+// NewHTTPClient creates a client with a pooled transport and no retries by
+// default (matching the old single-attempt behavior); set MaxRetries on
+// DefaultOpts or per-call RequestOptions to enable them.
 func NewHTTPClient(baseURL string) *HTTPClient {
 	return &HTTPClient{
 		BaseURL: baseURL,
 		Client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(DefaultTransportConfig()),
 		},
 		DefaultOpts: RequestOptions{
 			Headers:         map[string]string{},
 			Timeout:         30 * time.Second,
 			FollowRedirects: true,
 			Context:         context.Background(),
+			BackoffBase:     DefaultBackoffBase,
+			BackoffMax:      DefaultBackoffMax,
 		},
+		RetryableStatus: DefaultRetryableStatus,
+		breakers:        newBreakerRegistry(),
 	}
 }
 
-func (c *HTTPClient) Call(method, endpoint string, body interface{}, opts *RequestOptions) (*Response, error) {
+// NewHTTPClientWithTransport is like NewHTTPClient but lets the caller tune
+// connection pooling (MaxIdleConns, MaxIdleConnsPerHost, keep-alive).
+func NewHTTPClientWithTransport(baseURL string, transportCfg TransportConfig) *HTTPClient {
+	c := NewHTTPClient(baseURL)
+	c.Client.Transport = newTransport(transportCfg)
+	return c
+}
 
+// idempotentMethods are safe to retry without an explicit idempotency key.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+func (c *HTTPClient) Call(method, endpoint string, body interface{}, opts *RequestOptions) (*Response, error) {
 	if opts == nil {
 		opts = &c.DefaultOpts
 	}
 
 	url := c.BaseURL + endpoint
+	host := c.hostOf(url)
 
-	var bodyReader io.Reader
+	var bodyJSON []byte
 	if body != nil {
-		bodyJSON, err := json.Marshal(body)
+		var err error
+		bodyJSON, err = json.Marshal(body)
 		if err != nil {
 			return &Response{Error: err}, err
 		}
-		bodyReader = bytes.NewBuffer(bodyJSON)
 	}
 
+	retryable := c.RetryableStatus
+	if retryable == nil {
+		retryable = DefaultRetryableStatus
+	}
+
+	canRetry := opts.IdempotencyKey != "" || idempotentMethods[method]
+	maxAttempts := 1
+	if canRetry && opts.MaxRetries > 0 {
+		maxAttempts = opts.MaxRetries + 1
+	}
+
+	breaker := c.breakers.get(host)
+
+	var resp *Response
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if !breaker.allow() {
+			return &Response{Error: fmt.Errorf("circuit breaker open for %s", host)},
+				fmt.Errorf("circuit breaker open for %s", host)
+		}
+
+		var bodyReader io.Reader
+		if bodyJSON != nil {
+			bodyReader = bytes.NewReader(bodyJSON)
+		}
+
+		resp, err = c.doOnce(method, url, bodyReader, body != nil, opts)
+		if err == nil && !retryable(resp) {
+			breaker.recordSuccess()
+			return resp, nil
+		}
+
+		breaker.recordFailure()
+
+		if attempt == maxAttempts-1 {
+			break
+		}
+		if !sleepBackoff(opts, attempt) {
+			break // context cancelled
+		}
+	}
+
+	if resp == nil {
+		return &Response{Error: err}, err
+	}
+	return resp, err
+}
+
+// doOnce issues a single HTTP attempt. Redirect handling is a per-request
+// http.Client clone (sharing the pooled Transport) rather than mutating
+// c.Client.CheckRedirect, which would race under concurrent calls.
+func (c *HTTPClient) doOnce(method, url string, bodyReader io.Reader, hasBody bool, opts *RequestOptions) (*Response, error) {
 	ctx := opts.Context
+	var cancel context.CancelFunc
 	if ctx == nil {
-		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(context.Background(), opts.Timeout)
 		defer cancel()
 	}
@@ -77,20 +245,23 @@ func (c *HTTPClient) Call(method, endpoint string, body interface{}, opts *Reque
 	for key, value := range opts.Headers {
 		req.Header.Set(key, value)
 	}
-
-	if body != nil && req.Header.Get("Content-Type") == "" {
+	if hasBody && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if opts.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", opts.IdempotencyKey)
+	}
 
+	httpClient := c.Client
 	if !opts.FollowRedirects {
-		c.Client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		clone := *c.Client
+		clone.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
-	} else {
-		c.Client.CheckRedirect = nil
+		httpClient = &clone
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return &Response{Error: err}, err
 	}
@@ -113,6 +284,51 @@ func (c *HTTPClient) Call(method, endpoint string, body interface{}, opts *Reque
 	}, nil
 }
 
+// sleepBackoff waits the exponential-backoff-with-jitter delay for attempt,
+// returning false if opts' context is cancelled first.
+func sleepBackoff(opts *RequestOptions, attempt int) bool {
+	base := opts.BackoffBase
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	max := opts.BackoffMax
+	if max <= 0 {
+		max = DefaultBackoffMax
+	}
+
+	delay := base * time.Duration(1<<uint(attempt))
+	if delay > max || delay <= 0 {
+		delay = max
+	}
+	delay = time.Duration(float64(delay) * (0.5 + rand.Float64()*0.5))
+
+	ctx := opts.Context
+	if ctx == nil {
+		time.Sleep(delay)
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// hostOf extracts the host:port a URL targets, for circuit-breaker keying.
+// It falls back to the whole URL on a parse failure so breaker state is
+// still per-target, just coarser.
+func (c *HTTPClient) hostOf(rawURL string) string {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return rawURL
+	}
+	return req.Host
+}
+
 func (c *HTTPClient) GET(endpoint string, opts *RequestOptions) (*Response, error) {
 	return c.Call(http.MethodGet, endpoint, nil, opts)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// histogramMaxLatency is the upper bound of every per-step histogram, wide
+// enough to cover a stalled L1 commit without the histogram clipping it.
+const histogramMaxLatency = 5 * time.Minute
+
+// histogramSigFigs is the number of significant decimal digits each
+// histogram bucket preserves, matching hdrhistogram's own recommended
+// default for latency measurements.
+const histogramSigFigs = 3
+
+// stepHistograms accumulates a per-step HDR histogram of latencies plus
+// request/error counts, built up concurrently by every worker goroutine
+// and read back once at the end of the run to produce the summary CSV.
+type stepHistograms struct {
+	mu     sync.Mutex
+	hists  map[string]*hdrhistogram.Histogram
+	counts map[string]int64
+	errs   map[string]int64
+}
+
+func newStepHistograms() *stepHistograms {
+	return &stepHistograms{
+		hists:  make(map[string]*hdrhistogram.Histogram),
+		counts: make(map[string]int64),
+		errs:   make(map[string]int64),
+	}
+}
+
+// record adds r's latency to its step's histogram, unless r.Err is set, in
+// which case it only counts toward that step's error rate.
+func (s *stepHistograms) record(r RequestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[r.Name]++
+	if r.Err != nil {
+		s.errs[r.Name]++
+		return
+	}
+
+	h, ok := s.hists[r.Name]
+	if !ok {
+		h = hdrhistogram.New(1, int64(histogramMaxLatency/time.Microsecond), histogramSigFigs)
+		s.hists[r.Name] = h
+	}
+	_ = h.RecordValue(r.Latency.Microseconds())
+}
+
+// stepSummary is one step's row in the summary CSV.
+type stepSummary struct {
+	Name          string
+	Count         int64
+	Errors        int64
+	P50           time.Duration
+	P90           time.Duration
+	P99           time.Duration
+	P999          time.Duration
+	Max           time.Duration
+	ThroughputRPS float64
+}
+
+// summaries computes percentiles, max, and throughput (against elapsed,
+// the post-warmup measurement window) for every step seen so far, sorted
+// by step name for a stable CSV row order.
+func (s *stepHistograms) summaries(elapsed time.Duration) []stepSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.counts))
+	for name := range s.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := make([]stepSummary, 0, len(names))
+	for _, name := range names {
+		sum := stepSummary{Name: name, Count: s.counts[name], Errors: s.errs[name]}
+		if h := s.hists[name]; h != nil {
+			sum.P50 = microseconds(h.ValueAtQuantile(50))
+			sum.P90 = microseconds(h.ValueAtQuantile(90))
+			sum.P99 = microseconds(h.ValueAtQuantile(99))
+			sum.P999 = microseconds(h.ValueAtQuantile(99.9))
+			sum.Max = microseconds(h.Max())
+		}
+		if elapsed > 0 {
+			sum.ThroughputRPS = float64(sum.Count) / elapsed.Seconds()
+		}
+		summaries = append(summaries, sum)
+	}
+	return summaries
+}
+
+func microseconds(v int64) time.Duration {
+	return time.Duration(v) * time.Microsecond
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/benchmark/client"
+)
+
+// RequestResult is one HTTP call's outcome within a scenario run.
+type RequestResult struct {
+	Name        string
+	Method      string
+	Endpoint    string
+	Layer       string
+	StartTime   time.Time
+	GoroutineID int
+	Latency     time.Duration
+	BlockHeight int64
+	Err         error
+}
+
+// scenarioFunc runs one workflow against requestClient and reports every
+// step it took, stopping early (with the failed step marked via Err) if a
+// call fails.
+type scenarioFunc func(requestClient *client.HTTPClient, opts *client.RequestOptions) []RequestResult
+
+// scenarioByName resolves a -scenario flag value to the workflow it runs,
+// or nil if name isn't recognized.
+func scenarioByName(name string) scenarioFunc {
+	switch name {
+	case "full":
+		return runFullWorkflow
+	case "start-only":
+		return runStartOnlyWorkflow
+	case "mix":
+		return runMixedWorkflow
+	default:
+		return nil
+	}
+}
+
+type testPackageResponse struct {
+	Body struct {
+		PackageID string `json:"package_id"`
+	} `json:"body"`
+}
+
+type startSessionResponse struct {
+	Body struct {
+		SessionID string `json:"id"`
+	} `json:"body"`
+}
+
+type commitSessionResponse struct {
+	Body struct {
+		L1 struct {
+			BlockHeight int64 `json:"BlockHeight"`
+		} `json:"l1"`
+	} `json:"body"`
+}
+
+const (
+	operatorID  = "OPR-001"
+	destination = "CUSTOMER A"
+	courierID   = "COU-001"
+	priority    = "standard"
+)
+
+// runMixedWorkflow picks one of the other scenarios per call, for a
+// -scenario mix run that exercises more than one endpoint shape under the
+// same load generator.
+func runMixedWorkflow(requestClient *client.HTTPClient, opts *client.RequestOptions) []RequestResult {
+	if rand.Intn(2) == 0 {
+		return runFullWorkflow(requestClient, opts)
+	}
+	return runStartOnlyWorkflow(requestClient, opts)
+}
+
+// runStartOnlyWorkflow exercises only /session/start, for measuring that
+// endpoint's throughput in isolation from the rest of the packaging flow.
+func runStartOnlyWorkflow(requestClient *client.HTTPClient, opts *client.RequestOptions) []RequestResult {
+	start := time.Now()
+	body := map[string]interface{}{"operator_id": operatorID}
+	_, err := requestClient.POST("/session/start", body, opts)
+
+	return []RequestResult{{
+		Name:     "Start Session",
+		Method:   "POST",
+		Endpoint: "/session/start",
+		Layer:    "L2",
+		Latency:  time.Since(start),
+		Err:      err,
+	}}
+}
+
+// runFullWorkflow runs the seven-step packaging happy path: create a test
+// package, start a session, scan/validate/QC/label the package, then
+// commit the session to L1. It returns every step attempted, stopping
+// after the first failure (that step's Err is set; later steps that
+// depend on its response are never attempted).
+func runFullWorkflow(requestClient *client.HTTPClient, opts *client.RequestOptions) []RequestResult {
+	var results []RequestResult
+	totalStart := time.Now()
+
+	// 1. Create Test Package
+	start := time.Now()
+	resp, err := requestClient.POST("/session/test-package", nil, opts)
+	results = append(results, RequestResult{
+		Name: "Create Package", Method: "POST", Endpoint: "/session/test-package",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+	var pkgResp testPackageResponse
+	client.UnmarshalBody(resp, &pkgResp)
+	packageId := pkgResp.Body.PackageID
+
+	// 2. Start Session
+	start = time.Now()
+	body := map[string]interface{}{"operator_id": operatorID}
+	resp, err = requestClient.POST("/session/start", body, opts)
+	results = append(results, RequestResult{
+		Name: "Start Session", Method: "POST", Endpoint: "/session/start",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+	var sessionResp startSessionResponse
+	client.UnmarshalBody(resp, &sessionResp)
+	sessionID := sessionResp.Body.SessionID
+
+	// 3. Scan Package
+	start = time.Now()
+	endpoint := fmt.Sprintf("/session/%s/scan/%s", sessionID, packageId)
+	_, err = requestClient.GET(endpoint, opts)
+	results = append(results, RequestResult{
+		Name: "Scan Package", Method: "GET", Endpoint: "session/:id/scan/:packageId",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+
+	// 4. Validate Package
+	start = time.Now()
+	endpoint = fmt.Sprintf("/session/%s/validate", sessionID)
+	body = map[string]interface{}{"package_id": packageId, "signature": "any"}
+	_, err = requestClient.POST(endpoint, body, opts)
+	results = append(results, RequestResult{
+		Name: "Validate Package", Method: "POST", Endpoint: "session/:id/validate",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+
+	// 5. Quality Check
+	start = time.Now()
+	endpoint = fmt.Sprintf("/session/%s/qc", sessionID)
+	body = map[string]interface{}{"passed": true, "issues": []string{"all good"}}
+	_, err = requestClient.POST(endpoint, body, opts)
+	results = append(results, RequestResult{
+		Name: "Quality Check", Method: "POST", Endpoint: "session/:id/qc",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+
+	// 6. Label Package
+	start = time.Now()
+	endpoint = fmt.Sprintf("/session/%s/label", sessionID)
+	body = map[string]interface{}{
+		"destination": destination,
+		"priority":    priority,
+		"courier_id":  courierID,
+	}
+	_, err = requestClient.POST(endpoint, body, opts)
+	results = append(results, RequestResult{
+		Name: "Label Package", Method: "POST", Endpoint: "session/:id/label",
+		Layer: "L2", Latency: time.Since(start), Err: err,
+	})
+	if err != nil {
+		return results
+	}
+
+	// 7. Commit
+	start = time.Now()
+	endpoint = fmt.Sprintf("/commit/%s", sessionID)
+	body = map[string]interface{}{
+		"operator_id":        operatorID,
+		"package_id":         packageId,
+		"supplier_signature": "any",
+		"destination":        destination,
+		"priority":           priority,
+		"courier_id":         courierID,
+	}
+	resp, err = requestClient.POST(endpoint, body, opts)
+	commitResult := RequestResult{
+		Name: "Commit Session", Method: "POST", Endpoint: "commit/:id",
+		Layer: "L1+L2", Latency: time.Since(start), Err: err,
+	}
+	if err == nil {
+		var commitResp commitSessionResponse
+		client.UnmarshalBody(resp, &commitResp)
+		commitResult.BlockHeight = commitResp.Body.L1.BlockHeight
+	}
+	results = append(results, commitResult)
+	if err != nil {
+		return results
+	}
+
+	results = append(results, RequestResult{
+		Name: "Complete Workflow", Method: "WORKFLOW", Endpoint: "complete-workflow",
+		Layer: "TOTAL", Latency: time.Since(totalStart),
+	})
+	return results
+}
@@ -0,0 +1,478 @@
+// Package api contains the transport-agnostic business logic for the layer-1
+// node: submitting session operations to consensus, looking up transaction
+// status, reading blocks, and reporting node health. It depends on a
+// consensus.Engine, the repository, and the service registry - never on
+// net/http or a concrete consensus backend - so the same methods can be
+// bound over REST, gRPC, or JSON-RPC, and run against any Engine
+// implementation, without duplicating logic.
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/consensus"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/proof"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository/statuscache"
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg/txcodec"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	nm "github.com/cometbft/cometbft/node"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// API exposes the node's capabilities as typed methods operating on
+// request/response structs rather than *http.Request/http.ResponseWriter.
+// It depends on consensus.Engine rather than CometBFT's RPC client directly,
+// so the ordering layer can be swapped without changing any of these
+// methods.
+type API struct {
+	node            *nm.Node
+	repository      *repository.Repository
+	serviceRegistry *service_registry.ServiceRegistry
+	engine          consensus.Engine
+	logger          cmtlog.Logger
+	startTime       time.Time
+	statusCache     *statuscache.Cache
+	txCodecs        *txcodec.Registry
+}
+
+// New creates the API layer shared by every transport.
+func New(node *nm.Node, repo *repository.Repository, serviceRegistry *service_registry.ServiceRegistry, engine consensus.Engine, logger cmtlog.Logger) *API {
+	codecs := txcodec.NewRegistry(txcodec.JSONCodec{})
+	codecs.Register(txcodec.CBORCodec{})
+	codecs.Register(txcodec.ProtobufCodec{})
+
+	return &API{
+		node:            node,
+		repository:      repo,
+		serviceRegistry: serviceRegistry,
+		engine:          engine,
+		logger:          logger,
+		startTime:       time.Now(),
+		statusCache:     statuscache.New(),
+		txCodecs:        codecs,
+	}
+}
+
+// SubmitSessionOp runs a consensus request (start session, scan, validate,
+// qc, label, commit, ...) through the service registry and blockchain,
+// waiting for the commit level described by opts before responding.
+// txFormat is the client's requested wire encoding for the transaction
+// itself (its Content-Type header), not the response; an empty value keeps
+// the original unprefixed-JSON encoding.
+func (a *API) SubmitSessionOp(ctx context.Context, request *service_registry.Request, opts CommitOptions, txFormat string) (*ClientResponse, error) {
+	opCtx, cancel := context.WithTimeout(ctx, operationTimeout(request.Path))
+	defer cancel()
+
+	response, err := request.GenerateResponse(opCtx, a.serviceRegistry)
+	if err != nil {
+		if opCtx.Err() == context.DeadlineExceeded {
+			return deadlineExceededResponse(request), nil
+		}
+		return nil, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	transaction := &service_registry.Transaction{
+		Request:      *request,
+		Response:     *response,
+		OriginNodeID: string(a.node.ConsensusReactor().Switch.NodeInfo().ID()),
+	}
+
+	if opts.Level == CommitBroadcast {
+		return a.broadcastOnly(ctx, transaction, response, txFormat)
+	}
+
+	consensusResponse, repoErr := a.repository.RunConsensus(ctx, transaction, txFormat)
+	if repoErr != nil {
+		return nil, fmt.Errorf("%s: %s", repoErr.Code, repoErr.Message)
+	}
+
+	blockHeight := consensusResponse.BlockHeight
+	transaction.BlockHeight = blockHeight
+
+	if opts.Level == CommitConfirmed && opts.Confirmations > 0 {
+		if err := a.waitForConfirmations(ctx, blockHeight, opts.Confirmations); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := a.engine.GetBlock(ctx, &blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	if block.Block == nil {
+		a.logger.Info("API", "Block not found", blockHeight)
+	}
+
+	status := "included"
+	if opts.Level == CommitConfirmed {
+		status = "confirmed"
+	}
+
+	clientResponse := &ClientResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       response.ParseBody(),
+		Meta: TransactionStatus{
+			TxID:        consensusResponse.TxHash,
+			RequestID:   request.RequestID,
+			Status:      status,
+			BlockHeight: blockHeight,
+			ConfirmTime: time.Now(),
+			ResponseInfo: ResponseInfo{
+				StatusCode:  response.StatusCode,
+				ContentType: response.Headers["Content-Type"],
+				BodyLength:  len(response.Body),
+			},
+			BlockTxs: newBlockTxsDetail(block.Block.Txs, a.txCodecs, a.logger),
+		},
+		NodeID: transaction.OriginNodeID,
+	}
+
+	a.logger.Info("=== Req-Res Pair Result ===",
+		transaction.Request.Path,
+		transaction.Request.Method,
+		transaction.Request.Body,
+		transaction.Response.StatusCode,
+		transaction.Response.Body,
+	)
+
+	return clientResponse, nil
+}
+
+// broadcastOnly submits the transaction and returns as soon as it passes
+// CheckTx in the mempool, without waiting for inclusion in a block.
+func (a *API) broadcastOnly(ctx context.Context, transaction *service_registry.Transaction, response *service_registry.Response, txFormat string) (*ClientResponse, error) {
+	txBytes, err := a.encodeTx(txFormat, transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+
+	result, err := a.engine.BroadcastTx(ctx, txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("transaction rejected by mempool: %s", result.Log)
+	}
+
+	return &ClientResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       response.ParseBody(),
+		Meta: TransactionStatus{
+			TxID:        result.Hash.String(),
+			RequestID:   transaction.Request.RequestID,
+			Status:      "broadcasted",
+			ConfirmTime: time.Now(),
+			ResponseInfo: ResponseInfo{
+				StatusCode:  response.StatusCode,
+				ContentType: response.Headers["Content-Type"],
+				BodyLength:  len(response.Body),
+			},
+		},
+		NodeID: transaction.OriginNodeID,
+	}, nil
+}
+
+// waitForConfirmations blocks until `confirmations` further blocks have
+// landed on top of includedHeight. It returns early if ctx is cancelled.
+func (a *API) waitForConfirmations(ctx context.Context, includedHeight, confirmations int64) error {
+	return a.engine.WaitForCommit(ctx, includedHeight+confirmations)
+}
+
+// encodeTx serializes tx for broadcast, honoring format (a client-supplied
+// Content-Type) when it names a registered non-default codec. An empty or
+// "application/json" format keeps the original unprefixed JSON encoding
+// used before multi-format support existed, so transactions already on
+// chain stay decodable without a magic prefix.
+func (a *API) encodeTx(format string, tx *service_registry.Transaction) ([]byte, error) {
+	if format == "" || format == "application/json" {
+		return tx.SerializeToBytes()
+	}
+	return a.txCodecs.Encode(format, tx)
+}
+
+// GetTxStatus looks up a transaction by hash and reports its consensus
+// status, serving from the status cache when possible and falling back to
+// the consensus engine on a cache miss.
+func (a *API) GetTxStatus(ctx context.Context, txID string) (*TransactionStatus, error) {
+	if entry, ok := a.statusCache.GetByTxHash(txID); ok {
+		var cached TransactionStatus
+		if err := json.Unmarshal(entry.Payload, &cached); err == nil {
+			return &cached, nil
+		}
+		a.logger.Error("Failed to unmarshal cached tx status, falling back to RPC", "tx_id", txID)
+	}
+
+	query := fmt.Sprintf("tx.hash='%s'", txID)
+	res, err := a.engine.TxSearch(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for transaction: %w", err)
+	}
+
+	if len(res.Txs) == 0 {
+		return nil, nil // Transaction not found
+	}
+
+	tx := res.Txs[0]
+
+	var completeTx service_registry.Transaction
+	if err := json.Unmarshal(tx.Tx, &completeTx); err != nil {
+		return nil, fmt.Errorf("error parsing transaction: %w", err)
+	}
+
+	status := "pending"
+	for _, event := range tx.TxResult.Events {
+		if event.Type == "dews_tx" {
+			for _, attr := range event.Attributes {
+				if string(attr.Key) == "status" {
+					status = string(attr.Value)
+				}
+			}
+		}
+	}
+
+	block, err := a.engine.GetBlock(ctx, &tx.Height)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	if block.Block == nil {
+		a.logger.Info("API", "Block not found", tx.Height)
+	}
+
+	txStatus := &TransactionStatus{
+		TxID:        txID,
+		RequestID:   completeTx.Request.RequestID,
+		Status:      status,
+		BlockHeight: tx.Height,
+		BlockHash:   fmt.Sprintf("%X", tx.Hash),
+		ConfirmTime: time.Unix(0, time.Now().Unix()),
+		ResponseInfo: ResponseInfo{
+			StatusCode:  completeTx.Response.StatusCode,
+			ContentType: completeTx.Response.Headers["Content-Type"],
+			BodyLength:  len(completeTx.Response.Body),
+		},
+		BlockTxs: newBlockTxsDetail(block.Block.Txs, a.txCodecs, a.logger),
+	}
+
+	if payload, err := json.Marshal(txStatus); err == nil {
+		sessionID := sessionIDFromPath(completeTx.Request.Path)
+		digest := statuscache.Digest(tx.Tx)
+		a.statusCache.Put(txID, sessionID, tx.Height, digest, payload)
+	}
+
+	return txStatus, nil
+}
+
+// GetSessionStatus reports the consensus status of the transaction that
+// committed a session, serving from the status cache when possible.
+func (a *API) GetSessionStatus(ctx context.Context, sessionID string) (*TransactionStatus, error) {
+	if entry, ok := a.statusCache.GetBySessionID(sessionID); ok {
+		var cached TransactionStatus
+		if err := json.Unmarshal(entry.Payload, &cached); err == nil {
+			return &cached, nil
+		}
+		a.logger.Error("Failed to unmarshal cached session status, falling back to RPC", "session_id", sessionID)
+	}
+
+	record, repoErr := a.repository.GetTransactionRecordBySessionID(ctx, sessionID)
+	if repoErr != nil {
+		return nil, fmt.Errorf("%s: %s", repoErr.Code, repoErr.Message)
+	}
+	if record == nil {
+		return nil, nil // Session has no committed transaction
+	}
+
+	return a.GetTxStatus(ctx, record.TxHash)
+}
+
+// GetTxProof builds a Merkle inclusion proof for a committed transaction,
+// so a caller can verify against a trusted validator set that the
+// transaction is really in the block it claims to be in.
+func (a *API) GetTxProof(ctx context.Context, txID string) (*proof.TxInclusionProof, error) {
+	query := fmt.Sprintf("tx.hash='%s'", txID)
+	res, err := a.engine.TxSearch(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for transaction: %w", err)
+	}
+	if len(res.Txs) == 0 {
+		return nil, nil // Transaction not found
+	}
+
+	return a.buildTxProof(ctx, res.Txs[0].Height, int(res.Txs[0].Index))
+}
+
+// GetSessionProof builds a Merkle inclusion proof for the transaction that
+// committed the given session, looked up via the repository's session ->
+// tx-hash index.
+func (a *API) GetSessionProof(ctx context.Context, sessionID string) (*proof.TxInclusionProof, error) {
+	record, repoErr := a.repository.GetTransactionRecordBySessionID(ctx, sessionID)
+	if repoErr != nil {
+		return nil, fmt.Errorf("%s: %s", repoErr.Code, repoErr.Message)
+	}
+	if record == nil {
+		return nil, nil // Session has no committed transaction
+	}
+
+	return a.GetTxProof(ctx, record.TxHash)
+}
+
+// buildTxProof fetches the block and commit at height and delegates to the
+// proof package to walk the transaction up to the block's DataHash.
+func (a *API) buildTxProof(ctx context.Context, height int64, txIndex int) (*proof.TxInclusionProof, error) {
+	block, err := a.engine.GetBlock(ctx, &height)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching block: %w", err)
+	}
+	if block.Block == nil {
+		return nil, nil
+	}
+
+	commit, err := a.engine.GetCommit(ctx, &height)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching commit: %w", err)
+	}
+
+	return proof.BuildTxInclusionProof(block.Block, commit, txIndex)
+}
+
+// GetBlock returns block information and decoded transactions for a height.
+func (a *API) GetBlock(ctx context.Context, height int64) (*BlockInfo, error) {
+	block, err := a.engine.GetBlock(ctx, &height)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching block: %w", err)
+	}
+	if block.Block == nil {
+		return nil, nil // Block not found
+	}
+
+	transactions, transactionsB64 := decodeBlockTxs(block.Block.Txs, a.txCodecs, a.logger)
+
+	return &BlockInfo{
+		Height:          block.Block.Height,
+		Hash:            fmt.Sprintf("%X", block.BlockID.Hash),
+		Time:            block.Block.Time,
+		NumTxs:          len(block.Block.Txs),
+		Transactions:    transactions,
+		TransactionsB64: transactionsB64,
+		ProposerAddress: fmt.Sprintf("%X", block.Block.ProposerAddress),
+	}, nil
+}
+
+// Debug reports the node's current CometBFT/ABCI health.
+func (a *API) Debug(ctx context.Context) (DebugInfo, error) {
+	nodeStatus := "online"
+	if a.node.ConsensusReactor().WaitSync() {
+		nodeStatus = "syncing"
+	}
+	if !a.node.IsListening() {
+		nodeStatus = "offline"
+	}
+
+	debugInfo := DebugInfo{
+		"node_id":     string(a.node.NodeInfo().ID()),
+		"node_status": nodeStatus,
+		"p2p_address": a.node.Config().P2P.ListenAddress,
+		"rpc_address": a.node.Config().RPC.ListenAddress,
+		"uptime":      time.Since(a.startTime).String(),
+	}
+
+	status, err := a.engine.Status(ctx)
+	outboundPeers, inboundPeers, dialingPeers := a.node.Switch().NumPeers()
+	debugInfo["num_peers_out"] = outboundPeers
+	debugInfo["num_peers_in"] = inboundPeers
+	debugInfo["num_peers_dialing"] = dialingPeers
+	if err != nil {
+		debugInfo["tendermint_error"] = err.Error()
+	} else {
+		debugInfo["node_status"] = "online"
+		debugInfo["latest_block_height"] = status.SyncInfo.LatestBlockHeight
+		debugInfo["latest_block_time"] = status.SyncInfo.LatestBlockTime
+		debugInfo["catching_up"] = status.SyncInfo.CatchingUp
+		debugInfo["peers"] = make([]map[string]interface{}, 0, len(a.node.Switch().Peers().Copy()))
+	}
+
+	abciInfo, err := a.engine.ABCIInfo(ctx)
+	if err != nil {
+		debugInfo["abci_error"] = err.Error()
+	} else {
+		debugInfo["abci_version"] = abciInfo.Response.Version
+		debugInfo["app_version"] = abciInfo.Response.AppVersion
+		debugInfo["last_block_height"] = abciInfo.Response.LastBlockHeight
+		debugInfo["last_block_app_hash"] = fmt.Sprintf("%X", abciInfo.Response.LastBlockAppHash)
+	}
+
+	return debugInfo, nil
+}
+
+// IngestBlock feeds a newly committed block to the status cache so
+// subsequent /status/ and /session/ reads can be served in O(1). It hashes
+// each transaction into a digest, invalidating any cached entry whose
+// digest changed, and lets the cache detect and roll back reorgs by
+// comparing the block's LastBlockID against its own recorded tip. Called
+// from the WebServer's NewBlock event subscriber.
+func (a *API) IngestBlock(height int64, blockHash, lastBlockHash string, txs [][]byte) {
+	digests := make(map[string]string, len(txs))
+	for _, tx := range txs {
+		txHash := fmt.Sprintf("%X", cmttypes.Tx(tx).Hash())
+		digests[txHash] = statuscache.Digest(tx)
+	}
+	a.statusCache.OnNewBlock(height, blockHash, lastBlockHash, digests)
+}
+
+// InvalidateFromHeight drops every status cache entry at or above height.
+// It's used by a follower.FollowerServer on reorg detection, since a
+// follower has no NewBlock subscription of its own to drive IngestBlock and
+// must invalidate explicitly once it knows the fork height.
+func (a *API) InvalidateFromHeight(height int64) {
+	a.statusCache.Rollback(height)
+}
+
+// Shutdown waits for the service registry's in-flight handlers to finish
+// (or ctx's deadline to pass) after the transport has stopped accepting
+// new work, so a SIGTERM doesn't cut off a request already in consensus.
+func (a *API) Shutdown(ctx context.Context) error {
+	return a.serviceRegistry.Shutdown(ctx)
+}
+
+// sessionIDFromPath extracts the session ID from a session-scoped request
+// path such as "/session/{sessionID}/commit-l1", returning "" if the path
+// doesn't follow that shape.
+func sessionIDFromPath(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "session" {
+		return parts[1]
+	}
+	return ""
+}
+
+// decodeBlockTxs base64-encodes raw block transactions and, best-effort,
+// decodes them into service_registry.Transaction values via codecs, which
+// dispatches on each transaction's wire format (JSON, CBOR, or protobuf).
+func decodeBlockTxs(txs [][]byte, codecs *txcodec.Registry, logger cmtlog.Logger) ([]service_registry.Transaction, []string) {
+	var transactions []service_registry.Transaction
+	var transactionsB64 []string
+
+	for _, tx := range txs {
+		b64Tx := base64.StdEncoding.EncodeToString(tx)
+		transactionsB64 = append(transactionsB64, b64Tx)
+
+		parsedTx, err := codecs.Decode(tx)
+		if err == nil {
+			parsedTx.Response.BodyInterface = parsedTx.Response.ParseBody()
+			transactions = append(transactions, *parsedTx)
+		} else {
+			logger.Error("Failed to parse transaction", "err", err)
+		}
+	}
+
+	return transactions, transactionsB64
+}
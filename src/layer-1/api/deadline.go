@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+)
+
+// defaultOperationTimeout bounds any session operation whose path doesn't
+// match one of the per-operation cases in operationTimeout below.
+const defaultOperationTimeout = 5 * time.Second
+
+// operationTimeout returns how long SubmitSessionOp should let a session
+// operation run before its context is cancelled and the caller sees
+// http.StatusGatewayTimeout, mirroring the per-route deadlines layer-2's
+// ServiceRegistry.WithDeadline configures (2s for scan, 8s for commit,
+// ...). Layer-1 has no route trie to hang a deadline off of, so the
+// mapping lives here instead, keyed off the verb at the end of path.
+func operationTimeout(path string) time.Duration {
+	switch {
+	case strings.HasSuffix(path, "/scan"):
+		return 2 * time.Second
+	case strings.HasSuffix(path, "/validate"):
+		return 4 * time.Second
+	case strings.HasSuffix(path, "/qc"):
+		return 4 * time.Second
+	case strings.HasSuffix(path, "/label"):
+		return 4 * time.Second
+	case strings.HasPrefix(path, "/commit/"), strings.HasPrefix(path, "/replica/commit/"):
+		return 8 * time.Second
+	default:
+		return defaultOperationTimeout
+	}
+}
+
+// deadlineExceededResponse is what SubmitSessionOp returns in place of an
+// error when request's per-operation timeout fires before GenerateResponse
+// returns, so an operator-aborted or stalled session surfaces as a
+// Gateway Timeout instead of a generic Internal Server Error.
+func deadlineExceededResponse(request *service_registry.Request) *ClientResponse {
+	return &ClientResponse{
+		StatusCode: http.StatusGatewayTimeout,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       map[string]string{"error": "session operation exceeded its deadline"},
+		Meta: TransactionStatus{
+			RequestID:   request.RequestID,
+			Status:      "timed_out",
+			ConfirmTime: time.Now(),
+		},
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg/txcodec"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// CommitLevel controls how long SubmitSessionOp blocks before responding,
+// mirroring the tradeoff between eth_sendRawTransaction and
+// eth_getTransactionReceipt.
+type CommitLevel int
+
+const (
+	// CommitBroadcast returns as soon as the transaction passes CheckTx in
+	// the mempool, without waiting for it to be included in a block.
+	CommitBroadcast CommitLevel = iota
+	// CommitIncluded waits until the transaction is included in a block.
+	// This is the historical, default behavior of SubmitSessionOp.
+	CommitIncluded
+	// CommitConfirmed waits until Confirmations further blocks have landed
+	// on top of the one the transaction was included in.
+	CommitConfirmed
+)
+
+// CommitOptions describes the requested commit level for SubmitSessionOp.
+type CommitOptions struct {
+	Level         CommitLevel
+	Confirmations int64
+}
+
+// DefaultCommitOptions is CommitIncluded, preserving SubmitSessionOp's
+// behavior prior to the introduction of configurable commit levels.
+var DefaultCommitOptions = CommitOptions{Level: CommitIncluded}
+
+// ParseCommitOptions parses a `commit` query parameter or X-Commit-Level
+// header value: "broadcast", "included", or "confirmed:N". An empty value
+// yields DefaultCommitOptions.
+func ParseCommitOptions(raw string) (CommitOptions, error) {
+	raw = strings.TrimSpace(strings.ToLower(raw))
+	if raw == "" {
+		return DefaultCommitOptions, nil
+	}
+
+	level, param, _ := strings.Cut(raw, ":")
+	switch level {
+	case "broadcast":
+		return CommitOptions{Level: CommitBroadcast}, nil
+	case "included":
+		return CommitOptions{Level: CommitIncluded}, nil
+	case "confirmed":
+		if param == "" {
+			return CommitOptions{Level: CommitConfirmed, Confirmations: 1}, nil
+		}
+		n, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || n < 0 {
+			return CommitOptions{}, fmt.Errorf("invalid confirmation count %q", param)
+		}
+		return CommitOptions{Level: CommitConfirmed, Confirmations: n}, nil
+	default:
+		return CommitOptions{}, fmt.Errorf("unknown commit level %q", level)
+	}
+}
+
+// TransactionStatus represents the consensus status of a transaction
+type TransactionStatus struct {
+	TxID         string         `json:"tx_id"`
+	RequestID    string         `json:"request_id"`
+	Status       string         `json:"status"`
+	BlockHeight  int64          `json:"block_height"`
+	BlockHash    string         `json:"block_hash,omitempty"`
+	ConfirmTime  time.Time      `json:"confirm_time"`
+	ResponseInfo ResponseInfo   `json:"response_info"`
+	BlockTxs     BlockTxsDetail `json:"block_txs"`
+}
+
+// BlockTxsDetail lazily decodes a block's raw transactions through codecs
+// as it's marshaled, rather than decoding the whole block into a
+// []service_registry.Transaction up front, so /status/{txID} stays
+// responsive on blocks with thousands of transactions.
+type BlockTxsDetail struct {
+	rawTxs [][]byte
+	codecs *txcodec.Registry
+	logger cmtlog.Logger
+}
+
+// newBlockTxsDetail wraps a block's raw transactions for lazy decoding.
+func newBlockTxsDetail(rawTxs [][]byte, codecs *txcodec.Registry, logger cmtlog.Logger) BlockTxsDetail {
+	return BlockTxsDetail{rawTxs: rawTxs, codecs: codecs, logger: logger}
+}
+
+// MarshalJSON decodes and re-encodes each transaction one at a time rather
+// than building a []service_registry.Transaction slice first, so a failed
+// decode only drops that one entry instead of aborting the whole response.
+func (b BlockTxsDetail) MarshalJSON() ([]byte, error) {
+	rawTransactions := make([]json.RawMessage, 0, len(b.rawTxs))
+	b64Transactions := make([]string, 0, len(b.rawTxs))
+
+	var buf bytes.Buffer
+	for _, raw := range b.rawTxs {
+		b64Transactions = append(b64Transactions, base64.StdEncoding.EncodeToString(raw))
+
+		tx, err := b.codecs.Decode(raw)
+		if err != nil {
+			b.logger.Error("Failed to decode transaction", "err", err)
+			continue
+		}
+		tx.Response.BodyInterface = tx.Response.ParseBody()
+
+		buf.Reset()
+		if err := json.NewEncoder(&buf).Encode(tx); err != nil {
+			return nil, fmt.Errorf("block txs detail: encoding tx: %w", err)
+		}
+		rawTransactions = append(rawTransactions, json.RawMessage(bytes.TrimRight(buf.Bytes(), "\n")))
+	}
+
+	return json.Marshal(struct {
+		BlockTransactions    []json.RawMessage `json:"block_transactions"`
+		BlockTransactionsB64 []string          `json:"block_transactions_b64"`
+	}{
+		BlockTransactions:    rawTransactions,
+		BlockTransactionsB64: b64Transactions,
+	})
+}
+
+// ResponseInfo contains information about the response
+type ResponseInfo struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	BodyLength  int    `json:"body_length"`
+}
+
+// ConsensusInfo contains information about the consensus process
+type ConsensusInfo struct {
+	TotalNodes     int           `json:"total_nodes"`
+	AgreementNodes int           `json:"agreement_nodes"`
+	NodeResponses  []bool        `json:"node_responses,omitempty"`
+	Votes          []interface{} `json:"votes"`
+}
+
+// ClientResponse is the transport-agnostic response returned by SubmitSessionOp
+type ClientResponse struct {
+	StatusCode    int               `json:"-"`
+	Headers       map[string]string `json:"-"`
+	Body          interface{}       `json:"body"`
+	Meta          TransactionStatus `json:"meta"`
+	BlockchainRef string            `json:"blockchain_ref"`
+	NodeID        string            `json:"node_id"`
+}
+
+// BlockInfo describes a single CometBFT block and its decoded transactions
+type BlockInfo struct {
+	Height          int64                          `json:"height"`
+	Hash            string                         `json:"hash"`
+	Time            time.Time                      `json:"time"`
+	NumTxs          int                            `json:"num_txs"`
+	Transactions    []service_registry.Transaction `json:"transactions"`
+	TransactionsB64 []string                       `json:"transactions_b64"`
+	ProposerAddress string                         `json:"proposer_address"`
+}
+
+// DebugInfo is the node's self-reported health/consensus status
+type DebugInfo map[string]interface{}
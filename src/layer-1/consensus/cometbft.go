@@ -0,0 +1,95 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// rpcClient is the subset of CometBFT's client.Client that CometBFTEngine
+// needs. *cmtrpc.Local (an in-process client) and the HTTP client returned
+// by cmthttp.New both satisfy it, so the same engine works whether the node
+// is embedded or reached over the wire.
+type rpcClient interface {
+	BroadcastTxSync(ctx context.Context, tx []byte) (*cmtcoretypes.ResultBroadcastTx, error)
+	Block(ctx context.Context, height *int64) (*cmtcoretypes.ResultBlock, error)
+	Commit(ctx context.Context, height *int64) (*cmtcoretypes.ResultCommit, error)
+	TxSearch(ctx context.Context, query string, prove bool, page, perPage *int, orderBy string) (*cmtcoretypes.ResultTxSearch, error)
+	Status(ctx context.Context) (*cmtcoretypes.ResultStatus, error)
+	ABCIInfo(ctx context.Context) (*cmtcoretypes.ResultABCIInfo, error)
+	Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan cmtcoretypes.ResultEvent, error)
+	Unsubscribe(ctx context.Context, subscriber, query string) error
+}
+
+// CometBFTEngine adapts a CometBFT RPC client to the Engine interface. It is
+// the only backend this node ships with traffic-serving behavior; the other
+// implementations in this package exist to prove the HTTP and API layers
+// don't depend on CometBFT concrete types.
+type CometBFTEngine struct {
+	rpc rpcClient
+}
+
+// NewCometBFTEngine wraps rpc (typically *cmtrpc.Local, constructed from the
+// embedded node) as an Engine.
+func NewCometBFTEngine(rpc rpcClient) *CometBFTEngine {
+	return &CometBFTEngine{rpc: rpc}
+}
+
+func (e *CometBFTEngine) BroadcastTx(ctx context.Context, tx []byte) (*cmtcoretypes.ResultBroadcastTx, error) {
+	return e.rpc.BroadcastTxSync(ctx, tx)
+}
+
+func (e *CometBFTEngine) GetBlock(ctx context.Context, height *int64) (*cmtcoretypes.ResultBlock, error) {
+	return e.rpc.Block(ctx, height)
+}
+
+func (e *CometBFTEngine) GetCommit(ctx context.Context, height *int64) (*cmtcoretypes.ResultCommit, error) {
+	return e.rpc.Commit(ctx, height)
+}
+
+func (e *CometBFTEngine) TxSearch(ctx context.Context, query string) (*cmtcoretypes.ResultTxSearch, error) {
+	return e.rpc.TxSearch(ctx, query, false, nil, nil, "")
+}
+
+func (e *CometBFTEngine) Status(ctx context.Context) (*cmtcoretypes.ResultStatus, error) {
+	return e.rpc.Status(ctx)
+}
+
+func (e *CometBFTEngine) ABCIInfo(ctx context.Context) (*cmtcoretypes.ResultABCIInfo, error) {
+	return e.rpc.ABCIInfo(ctx)
+}
+
+func (e *CometBFTEngine) Subscribe(ctx context.Context, subscriber, query string) (<-chan cmtcoretypes.ResultEvent, error) {
+	return e.rpc.Subscribe(ctx, subscriber, query)
+}
+
+func (e *CometBFTEngine) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return e.rpc.Unsubscribe(ctx, subscriber, query)
+}
+
+// WaitForCommit blocks until the chain height reaches targetHeight, woken by
+// NewBlock events rather than polling on a timer.
+func (e *CometBFTEngine) WaitForCommit(ctx context.Context, targetHeight int64) error {
+	const subscriber = "consensus-wait-for-commit"
+
+	blockEvents, err := e.Subscribe(ctx, subscriber, cmttypes.EventQueryNewBlock.String())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe for commit wait: %w", err)
+	}
+	defer e.Unsubscribe(context.Background(), subscriber, cmttypes.EventQueryNewBlock.String())
+
+	for {
+		status, err := e.Status(ctx)
+		if err == nil && status.SyncInfo.LatestBlockHeight >= targetHeight {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-blockEvents:
+		}
+	}
+}
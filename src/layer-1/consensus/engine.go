@@ -0,0 +1,43 @@
+// Package consensus abstracts the ordering layer that api.API and
+// server.WebServer submit transactions to and read blocks from. CometBFT is
+// the only backend shipped today, but the HTTP layer and the API layer
+// should depend on Engine rather than on CometBFT's RPC types directly, so
+// an alternative BFT implementation (HotStuff, DiemBFT, ...) can be dropped
+// in without touching request parsing or response encoding.
+package consensus
+
+import (
+	"context"
+
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// Engine is the ordering layer a node submits transactions to and reads
+// committed blocks from. Implementations are expected to be safe for
+// concurrent use, matching how *cmtrpc.Local is used today.
+type Engine interface {
+	// BroadcastTx submits a transaction and returns as soon as it passes
+	// CheckTx, without waiting for inclusion in a block.
+	BroadcastTx(ctx context.Context, tx []byte) (*cmtcoretypes.ResultBroadcastTx, error)
+	// WaitForCommit blocks until the engine's height reaches targetHeight,
+	// or ctx is cancelled.
+	WaitForCommit(ctx context.Context, targetHeight int64) error
+	// GetBlock returns the block at height, or the latest block if height
+	// is nil.
+	GetBlock(ctx context.Context, height *int64) (*cmtcoretypes.ResultBlock, error)
+	// GetCommit returns the signed commit for the block at height.
+	GetCommit(ctx context.Context, height *int64) (*cmtcoretypes.ResultCommit, error)
+	// TxSearch runs a CometBFT-style tx search query (e.g. "tx.hash='...'")
+	// and returns the matching transactions.
+	TxSearch(ctx context.Context, query string) (*cmtcoretypes.ResultTxSearch, error)
+	// Status reports the engine's current sync/height information.
+	Status(ctx context.Context) (*cmtcoretypes.ResultStatus, error)
+	// ABCIInfo reports the application's last-committed height and hash.
+	ABCIInfo(ctx context.Context) (*cmtcoretypes.ResultABCIInfo, error)
+	// Subscribe registers subscriber for events matching query, delivered
+	// on the returned channel until Unsubscribe is called or ctx is
+	// cancelled.
+	Subscribe(ctx context.Context, subscriber, query string) (<-chan cmtcoretypes.ResultEvent, error)
+	// Unsubscribe removes a subscription previously created with Subscribe.
+	Unsubscribe(ctx context.Context, subscriber, query string) error
+}
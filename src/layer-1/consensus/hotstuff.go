@@ -0,0 +1,59 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// HotStuffEngine is a placeholder for a HotStuff/DiemBFT-style ordering
+// backend. It satisfies Engine so callers can be built and wired against it
+// today, but every method is unimplemented until this project actually
+// adopts a HotStuff client library - the point of this stub is to prove
+// that swapping the ordering layer doesn't require touching api.API or
+// server.WebServer, not to ship a working alternative consensus.
+type HotStuffEngine struct{}
+
+// NewHotStuffEngine returns the HotStuff stub engine.
+func NewHotStuffEngine() *HotStuffEngine {
+	return &HotStuffEngine{}
+}
+
+var errHotStuffNotImplemented = fmt.Errorf("hotstuff consensus engine: not yet implemented")
+
+func (e *HotStuffEngine) BroadcastTx(ctx context.Context, tx []byte) (*cmtcoretypes.ResultBroadcastTx, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) WaitForCommit(ctx context.Context, targetHeight int64) error {
+	return errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) GetBlock(ctx context.Context, height *int64) (*cmtcoretypes.ResultBlock, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) GetCommit(ctx context.Context, height *int64) (*cmtcoretypes.ResultCommit, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) TxSearch(ctx context.Context, query string) (*cmtcoretypes.ResultTxSearch, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) Status(ctx context.Context) (*cmtcoretypes.ResultStatus, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) ABCIInfo(ctx context.Context) (*cmtcoretypes.ResultABCIInfo, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) Subscribe(ctx context.Context, subscriber, query string) (<-chan cmtcoretypes.ResultEvent, error) {
+	return nil, errHotStuffNotImplemented
+}
+
+func (e *HotStuffEngine) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return errHotStuffNotImplemented
+}
@@ -0,0 +1,199 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmtypes "github.com/cometbft/cometbft/types"
+)
+
+// InMemoryEngine is a single-node Engine that appends every broadcast
+// transaction to its own height immediately, with no mempool, voting, or
+// networking. It exists so unit tests can exercise api.API and
+// server.WebServer without standing up a real CometBFT node.
+type InMemoryEngine struct {
+	mu     sync.Mutex
+	blocks []*cmtypes.Block
+	subs   map[string]chan cmtcoretypes.ResultEvent
+}
+
+// NewInMemoryEngine creates an engine with an empty chain.
+func NewInMemoryEngine() *InMemoryEngine {
+	return &InMemoryEngine{
+		subs: make(map[string]chan cmtcoretypes.ResultEvent),
+	}
+}
+
+func (e *InMemoryEngine) BroadcastTx(ctx context.Context, tx []byte) (*cmtcoretypes.ResultBroadcastTx, error) {
+	e.mu.Lock()
+	height := int64(len(e.blocks) + 1)
+	block := &cmtypes.Block{
+		Header: cmtypes.Header{
+			Height: height,
+			Time:   time.Now(),
+		},
+		Data: cmtypes.Data{
+			Txs: cmtypes.Txs{cmtypes.Tx(tx)},
+		},
+	}
+	e.blocks = append(e.blocks, block)
+	subs := make([]chan cmtcoretypes.ResultEvent, 0, len(e.subs))
+	for _, ch := range e.subs {
+		subs = append(subs, ch)
+	}
+	e.mu.Unlock()
+
+	event := cmtcoretypes.ResultEvent{
+		Query: cmtypes.EventQueryNewBlock.String(),
+		Data:  cmtypes.EventDataNewBlock{Block: block},
+	}
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+
+	return &cmtcoretypes.ResultBroadcastTx{
+		Code: 0,
+		Hash: cmtypes.Tx(tx).Hash(),
+	}, nil
+}
+
+func (e *InMemoryEngine) WaitForCommit(ctx context.Context, targetHeight int64) error {
+	for {
+		e.mu.Lock()
+		reached := int64(len(e.blocks)) >= targetHeight
+		e.mu.Unlock()
+		if reached {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (e *InMemoryEngine) GetBlock(ctx context.Context, height *int64) (*cmtcoretypes.ResultBlock, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	block, err := e.blockAtLocked(height)
+	if err != nil {
+		return nil, err
+	}
+	return &cmtcoretypes.ResultBlock{
+		BlockID: cmtypes.BlockID{Hash: block.Hash()},
+		Block:   block,
+	}, nil
+}
+
+func (e *InMemoryEngine) GetCommit(ctx context.Context, height *int64) (*cmtcoretypes.ResultCommit, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	block, err := e.blockAtLocked(height)
+	if err != nil {
+		return nil, err
+	}
+	return &cmtcoretypes.ResultCommit{
+		SignedHeader: cmtypes.SignedHeader{
+			Header: &block.Header,
+			Commit: &cmtypes.Commit{Height: block.Height},
+		},
+		CanonicalCommit: true,
+	}, nil
+}
+
+// TxSearch understands only "tx.hash='<HEX>'" queries, matching that one
+// transaction; any other query matches every transaction.
+func (e *InMemoryEngine) TxSearch(ctx context.Context, query string) (*cmtcoretypes.ResultTxSearch, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	wantHash := ""
+	if strings.HasPrefix(query, "tx.hash='") && strings.HasSuffix(query, "'") {
+		wantHash = strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(query, "tx.hash='"), "'"))
+	}
+
+	var matches []*cmtcoretypes.ResultTx
+	for _, block := range e.blocks {
+		for i, tx := range block.Data.Txs {
+			if wantHash != "" && fmt.Sprintf("%X", tx.Hash()) != wantHash {
+				continue
+			}
+			matches = append(matches, &cmtcoretypes.ResultTx{
+				Hash:   tx.Hash(),
+				Height: block.Height,
+				Index:  uint32(i),
+				Tx:     tx,
+			})
+		}
+	}
+	return &cmtcoretypes.ResultTxSearch{Txs: matches, TotalCount: len(matches)}, nil
+}
+
+func (e *InMemoryEngine) Status(ctx context.Context) (*cmtcoretypes.ResultStatus, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var status cmtcoretypes.ResultStatus
+	status.SyncInfo.LatestBlockHeight = int64(len(e.blocks))
+	if len(e.blocks) > 0 {
+		status.SyncInfo.LatestBlockTime = e.blocks[len(e.blocks)-1].Time
+	}
+	return &status, nil
+}
+
+func (e *InMemoryEngine) ABCIInfo(ctx context.Context) (*cmtcoretypes.ResultABCIInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return &cmtcoretypes.ResultABCIInfo{
+		Response: abcitypes.ResponseInfo{
+			LastBlockHeight: int64(len(e.blocks)),
+		},
+	}, nil
+}
+
+func (e *InMemoryEngine) Subscribe(ctx context.Context, subscriber, query string) (<-chan cmtcoretypes.ResultEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ch := make(chan cmtcoretypes.ResultEvent, 8)
+	e.subs[subscriber] = ch
+	return ch, nil
+}
+
+func (e *InMemoryEngine) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ch, ok := e.subs[subscriber]; ok {
+		close(ch)
+		delete(e.subs, subscriber)
+	}
+	return nil
+}
+
+// blockAtLocked returns the block at height, or the latest block if height
+// is nil. Callers must hold e.mu.
+func (e *InMemoryEngine) blockAtLocked(height *int64) (*cmtypes.Block, error) {
+	if len(e.blocks) == 0 {
+		return nil, fmt.Errorf("in-memory engine: no blocks yet")
+	}
+	if height == nil {
+		return e.blocks[len(e.blocks)-1], nil
+	}
+	if *height < 1 || *height > int64(len(e.blocks)) {
+		return nil, fmt.Errorf("in-memory engine: height %d out of range", *height)
+	}
+	return e.blocks[*height-1], nil
+}
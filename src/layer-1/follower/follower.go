@@ -0,0 +1,196 @@
+// Package follower implements a read-only mirror of the layer-1 repository
+// that tracks a primary node's chain without itself being a validator. A
+// FollowerServer polls the primary through a consensus.Engine (typically a
+// CometBFT HTTP client pointed at the primary's RPC address, but any Engine
+// works - including another follower, for multi-hop replication), replays
+// newly committed transactions into its own GORM store, and rolls back rows
+// written from an abandoned branch when it detects a reorg. WebServer uses
+// a FollowerServer in place of a validating nm.Node to serve GET-only
+// traffic (/status/, /session/, /block/) without adding another validator.
+package follower
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/consensus"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// DefaultPollInterval is how often RunDetectChanges checks the primary for
+// a new block when the caller doesn't specify one.
+const DefaultPollInterval = 1 * time.Second
+
+// HeightHash identifies one block, used both to record the follower's own
+// chain and to notify subscribers when a reorg invalidates a height.
+type HeightHash struct {
+	Height int64
+	Hash   string
+}
+
+// FollowerServer continuously syncs a local repository from a primary
+// node's chain, with reorg detection, and is safe for concurrent read
+// access while RunDetectChanges is running.
+type FollowerServer struct {
+	repository   *repository.Repository
+	primary      consensus.Engine
+	logger       cmtlog.Logger
+	pollInterval time.Duration
+
+	mu          sync.RWMutex
+	blockHashAt map[int64]string // height -> block hash, for reorg detection
+	tipHeight   int64
+
+	reorgs chan HeightHash
+	stop   chan struct{}
+}
+
+// NewFollowerServer creates a follower that mirrors primary into repo. Call
+// RunDetectChanges to start syncing.
+func NewFollowerServer(repo *repository.Repository, primary consensus.Engine, logger cmtlog.Logger) *FollowerServer {
+	return &FollowerServer{
+		repository:   repo,
+		primary:      primary,
+		logger:       logger,
+		pollInterval: DefaultPollInterval,
+		blockHashAt:  make(map[int64]string),
+		reorgs:       make(chan HeightHash, 16),
+		stop:         make(chan struct{}),
+	}
+}
+
+// WithPollInterval overrides DefaultPollInterval; it must be called before
+// RunDetectChanges starts.
+func (f *FollowerServer) WithPollInterval(d time.Duration) *FollowerServer {
+	f.pollInterval = d
+	return f
+}
+
+// Reorgs returns the channel HeightHash values are posted to whenever
+// RunDetectChanges rolls back to forkHeight, so WebServer's WebSocket
+// subscription subsystem (and the status cache it feeds) can invalidate
+// anything cached at or above that height.
+func (f *FollowerServer) Reorgs() <-chan HeightHash {
+	return f.reorgs
+}
+
+// Stop halts RunDetectChanges.
+func (f *FollowerServer) Stop() {
+	close(f.stop)
+}
+
+// TipHeight returns the highest height this follower has replayed.
+func (f *FollowerServer) TipHeight() int64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.tipHeight
+}
+
+// RunDetectChanges polls the primary every pollInterval, replaying any new
+// blocks and detecting reorgs by comparing the stored hash at height-1
+// against the new block's LastBlockID. It blocks until ctx is cancelled or
+// Stop is called.
+func (f *FollowerServer) RunDetectChanges(ctx context.Context) error {
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-f.stop:
+			return nil
+		case <-ticker.C:
+			if err := f.syncOnce(ctx); err != nil {
+				f.logger.Error("follower: sync failed", "err", err)
+			}
+		}
+	}
+}
+
+// syncOnce catches the local tip up to the primary's latest height, one
+// block at a time, so a reorg partway through a multi-block gap is caught
+// at the first affected height rather than papered over.
+func (f *FollowerServer) syncOnce(ctx context.Context) error {
+	status, err := f.primary.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("follower: querying primary status: %w", err)
+	}
+
+	for {
+		f.mu.RLock()
+		next := f.tipHeight + 1
+		f.mu.RUnlock()
+
+		if next > status.SyncInfo.LatestBlockHeight {
+			return nil
+		}
+
+		if err := f.replayHeight(ctx, next); err != nil {
+			return err
+		}
+	}
+}
+
+// replayHeight fetches height from the primary, checks it extends our
+// recorded tip (rolling back first if it doesn't), replays its
+// transactions into the repository, and records its hash.
+func (f *FollowerServer) replayHeight(ctx context.Context, height int64) error {
+	block, err := f.primary.GetBlock(ctx, &height)
+	if err != nil {
+		return fmt.Errorf("follower: fetching block %d: %w", height, err)
+	}
+	if block.Block == nil {
+		return fmt.Errorf("follower: primary has no block %d", height)
+	}
+
+	lastHash := fmt.Sprintf("%X", block.Block.LastBlockID.Hash)
+
+	f.mu.Lock()
+	if prevHash, ok := f.blockHashAt[height-1]; ok && prevHash != "" && prevHash != lastHash {
+		forkHeight := height - 1
+		f.rollbackFromLocked(forkHeight)
+		f.mu.Unlock()
+
+		if repoErr := f.repository.RollbackToHeight(ctx, forkHeight); repoErr != nil {
+			return fmt.Errorf("follower: rolling back repository to height %d: %s: %s", forkHeight, repoErr.Code, repoErr.Message)
+		}
+		f.reorgs <- HeightHash{Height: forkHeight}
+
+		// tipHeight is now forkHeight-1, so syncOnce's next pass replays
+		// forward from forkHeight on the primary's new branch.
+		return nil
+	}
+	f.mu.Unlock()
+
+	for _, tx := range block.Block.Txs {
+		if repoErr := f.repository.ReplayTransaction(ctx, []byte(tx), height); repoErr != nil {
+			return fmt.Errorf("follower: replaying tx at height %d: %s: %s", height, repoErr.Code, repoErr.Message)
+		}
+	}
+
+	hash := fmt.Sprintf("%X", block.Block.Hash())
+
+	f.mu.Lock()
+	f.blockHashAt[height] = hash
+	f.tipHeight = height
+	f.mu.Unlock()
+
+	return nil
+}
+
+// rollbackFromLocked discards every recorded block hash at or above
+// forkHeight, so a subsequent replayHeight re-derives them from the
+// primary's new canonical branch. Callers must hold f.mu.
+func (f *FollowerServer) rollbackFromLocked(forkHeight int64) {
+	for height := range f.blockHashAt {
+		if height >= forkHeight {
+			delete(f.blockHashAt, height)
+		}
+	}
+	f.tipHeight = forkHeight - 1
+}
@@ -15,9 +15,9 @@ import (
 	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository"
 	"github.com/ahmadzakiakmal/thesis/src/layer-1/server"
 	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
 	cfg "github.com/cometbft/cometbft/config"
 	cmtflags "github.com/cometbft/cometbft/libs/cli/flags"
-	cmtlog "github.com/cometbft/cometbft/libs/log"
 	nm "github.com/cometbft/cometbft/node"
 	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/privval"
@@ -28,17 +28,29 @@ import (
 )
 
 var (
-	homeDir      string
-	httpPort     string
-	postgresHost string
-	isByzantine  bool
+	homeDir          string
+	httpPort         string
+	postgresHost     string
+	byzantineProfile string
+	maxSessions      int
+	sessionTimeout   time.Duration
+	logLevel         string
+	logFormat        string
+	authSecret       string
+	snapshotInterval uint64
 )
 
 func init() {
 	flag.StringVar(&homeDir, "cmt-home", "./node-config/simulator-node", "Path to the CometBFT config directory")
 	flag.StringVar(&httpPort, "http-port", "5000", "HTTP web server port")
 	flag.StringVar(&postgresHost, "postgres-host", "l1-postgres0:5432", "DB host address")
-	flag.BoolVar(&isByzantine, "byzantine", false, "Byzantine Option")
+	flag.StringVar(&byzantineProfile, "byzantine", "", "Byzantine fault-injection profile spec (e.g. equivocation:seed=42), empty for honest behavior")
+	flag.IntVar(&maxSessions, "max-sessions", server.DefaultMaxSessions, "Maximum number of concurrent WebSocket subscription sessions")
+	flag.DurationVar(&sessionTimeout, "session-timeout", server.DefaultSessionTimeout, "Idle timeout before a WebSocket subscription session is evicted")
+	flag.StringVar(&logLevel, "log-level", "info", "Application log level (debug, info, warn, error)")
+	flag.StringVar(&logFormat, "log-format", "text", "Application log format (text, json)")
+	flag.StringVar(&authSecret, "auth-secret", "dev-insecure-secret-change-me", "Secret signing operator login tokens; must be overridden in production")
+	flag.Uint64Var(&snapshotInterval, "snapshot-interval", 0, "Create a state-sync snapshot every N committed blocks (0 disables snapshotting)")
 }
 
 func main() {
@@ -83,17 +95,39 @@ func main() {
 
 	// Create ABCI Application
 	appConfig := &app.AppConfig{
-		NodeID:        filepath.Base(homeDir), // Use directory name as node ID
-		RequiredVotes: 1,
-		LogAllTxs:     true,
+		NodeID:           filepath.Base(homeDir), // Use directory name as node ID
+		RequiredVotes:    1,
+		LogAllTxs:        true,
+		BadgerPath:       badgerPath,
+		SnapshotInterval: snapshotInterval,
 	}
-	logger := cmtlog.NewTMLogger(cmtlog.NewSyncWriter(os.Stdout))
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		log.Fatalf("Parsing -log-level flag: %v", err)
+	}
+	format, err := logging.ParseFormat(logFormat)
+	if err != nil {
+		log.Fatalf("Parsing -log-format flag: %v", err)
+	}
+	appLogger := logging.New(logging.Options{Level: level, Format: format, Output: os.Stdout}).With("node_id", appConfig.NodeID)
+
+	// cmtLogger adapts appLogger for CometBFT's node/consensus machinery,
+	// which only knows cmtlog.Logger, so both sides of the stack write
+	// into the same sink and format.
+	cmtLogger := logging.NewCometLogger(appLogger)
 
 	// Initialize Service Registry
-	serviceRegistry := service_registry.NewServiceRegistry(repository, logger, false)
+	var profile service_registry.ByzantineProfile
+	if byzantineProfile != "" {
+		profile, err = service_registry.ParseByzantineProfile(byzantineProfile)
+		if err != nil {
+			log.Fatalf("Parsing -byzantine flag: %v", err)
+		}
+	}
+	serviceRegistry := service_registry.NewServiceRegistry(repository, appLogger, profile, []byte(authSecret))
 	serviceRegistry.RegisterDefaultServices()
 
-	app := app.NewABCIApplication(db, serviceRegistry, appConfig, logger, repository)
+	app := app.NewABCIApplication(db, serviceRegistry, appConfig, appLogger, repository)
 
 	// Private Validator
 	pv := privval.LoadFilePV(
@@ -107,7 +141,7 @@ func main() {
 		log.Fatalf("failed to load node's key: %v", err)
 	}
 
-	logger, err = cmtflags.ParseLogLevel(config.LogLevel, logger, cfg.DefaultLogLevel)
+	cmtLogger, err = cmtflags.ParseLogLevel(config.LogLevel, cmtLogger, cfg.DefaultLogLevel)
 	if err != nil {
 		log.Fatalf("failed to parse log level: %v", err)
 	}
@@ -122,7 +156,7 @@ func main() {
 		nm.DefaultGenesisDocProviderFunc(config),
 		cfg.DefaultDBProvider,
 		nm.DefaultMetricsProvider(config.Instrumentation),
-		logger,
+		cmtLogger,
 	)
 	if err != nil {
 		log.Fatalf("Creating node: %v", err)
@@ -143,7 +177,7 @@ func main() {
 	}()
 
 	// Start Web Server
-	webserver, err := server.NewWebServer(app, httpPort, logger, node, serviceRegistry, repository)
+	webserver, err := server.NewWebServer(app, httpPort, cmtLogger, node, serviceRegistry, repository, maxSessions, sessionTimeout)
 	if err != nil {
 		log.Fatalf("Creating web server: %v", err)
 	}
@@ -165,7 +199,7 @@ func main() {
 	// Shutdown the web server
 	err = webserver.Shutdown(ctx)
 	if err != nil {
-		logger.Error("Shutting down HTTP web server", "err", err)
+		appLogger.Error("shutting down HTTP web server", "err", err)
 	}
-	logger.Info("HTTP web server gracefully stopped")
+	appLogger.Info("HTTP web server gracefully stopped")
 }
@@ -0,0 +1,183 @@
+// Package proof builds and verifies Merkle inclusion proofs for
+// transactions committed by the layer-1 node, so external light clients
+// (layer-2 nodes, auditors) can confirm a session was committed without
+// trusting a single node's REST response.
+package proof
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/cometbft/cometbft/crypto/merkle"
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// MerklePath is the JSON-stable representation of a merkle.Proof: the
+// sibling hashes ("aunts") needed to walk a leaf up to the block's
+// DataHash, alongside the leaf's position and the tree's total leaf count.
+type MerklePath struct {
+	Total    int64    `json:"total"`
+	Index    int64    `json:"index"`
+	LeafHash string   `json:"leaf_hash"` // base64
+	Aunts    []string `json:"aunts"`     // base64, bottom-up
+}
+
+// CommitSig is one validator's vote on a block, as found in the block's
+// commit.
+type CommitSig struct {
+	ValidatorAddress string    `json:"validator_address"` // hex
+	Timestamp        time.Time `json:"timestamp"`
+	Signature        string    `json:"signature"` // base64, empty if absent
+	BlockIDFlag      int32     `json:"block_id_flag"`
+}
+
+// BlockHeaderInfo carries the fields of a signed block header a verifier
+// needs: enough to recompute the header hash and check it against the
+// commit signatures, without pulling in the full cmttypes.Block.
+type BlockHeaderInfo struct {
+	ChainID            string      `json:"chain_id"`
+	Height             int64       `json:"height"`
+	Time               time.Time   `json:"time"`
+	AppHash            string      `json:"app_hash"`             // hex
+	DataHash           string      `json:"data_hash"`            // hex
+	ValidatorsHash     string      `json:"validators_hash"`      // hex
+	NextValidatorsHash string      `json:"next_validators_hash"` // hex
+	ConsensusHash      string      `json:"consensus_hash"`       // hex
+	LastCommitHash     string      `json:"last_commit_hash"`     // hex
+	ProposerAddress    string      `json:"proposer_address"`     // hex
+	CommitSignatures   []CommitSig `json:"commit_signatures"`
+}
+
+// TxInclusionProof is the compact, JSON-stable proof returned by the
+// /proof/tx/{txhash} and /proof/session/{sessionID} endpoints.
+type TxInclusionProof struct {
+	TxHash      string          `json:"tx_hash"`      // hex
+	TxBytesB64  string          `json:"tx_bytes_b64"` // raw tx bytes
+	BlockHeight int64           `json:"block_height"`
+	MerklePath  MerklePath      `json:"merkle_path"`
+	BlockHeader BlockHeaderInfo `json:"block_header"`
+}
+
+// BuildTxInclusionProof constructs a TxInclusionProof for the transaction
+// at txIndex within block, using merkle.ProofsFromByteSlices to compute the
+// sibling path up to block.Data's root (block.Header.DataHash).
+func BuildTxInclusionProof(block *cmttypes.Block, commit *cmtcoretypes.ResultCommit, txIndex int) (*TxInclusionProof, error) {
+	if txIndex < 0 || txIndex >= len(block.Data.Txs) {
+		return nil, fmt.Errorf("tx index %d out of range for block with %d txs", txIndex, len(block.Data.Txs))
+	}
+
+	txBytes := []byte(block.Data.Txs[txIndex])
+	items := make([][]byte, len(block.Data.Txs))
+	for i, tx := range block.Data.Txs {
+		items[i] = []byte(tx)
+	}
+
+	rootHash, merkleProofs := merkle.ProofsFromByteSlices(items)
+	mp := merkleProofs[txIndex]
+
+	aunts := make([]string, len(mp.Aunts))
+	for i, aunt := range mp.Aunts {
+		aunts[i] = base64.StdEncoding.EncodeToString(aunt)
+	}
+
+	sigs := make([]CommitSig, len(commit.SignedHeader.Commit.Signatures))
+	for i, sig := range commit.SignedHeader.Commit.Signatures {
+		sigs[i] = CommitSig{
+			ValidatorAddress: sig.ValidatorAddress.String(),
+			Timestamp:        sig.Timestamp,
+			Signature:        base64.StdEncoding.EncodeToString(sig.Signature),
+			BlockIDFlag:      int32(sig.BlockIDFlag),
+		}
+	}
+
+	header := block.Header
+	return &TxInclusionProof{
+		TxHash:      fmt.Sprintf("%X", cmttypes.Tx(txBytes).Hash()),
+		TxBytesB64:  base64.StdEncoding.EncodeToString(txBytes),
+		BlockHeight: block.Height,
+		MerklePath: MerklePath{
+			Total:    mp.Total,
+			Index:    mp.Index,
+			LeafHash: base64.StdEncoding.EncodeToString(mp.LeafHash),
+			Aunts:    aunts,
+		},
+		BlockHeader: BlockHeaderInfo{
+			ChainID:            header.ChainID,
+			Height:             header.Height,
+			Time:               header.Time,
+			AppHash:            fmt.Sprintf("%X", header.AppHash),
+			DataHash:           fmt.Sprintf("%X", rootHash),
+			ValidatorsHash:     fmt.Sprintf("%X", header.ValidatorsHash),
+			NextValidatorsHash: fmt.Sprintf("%X", header.NextValidatorsHash),
+			ConsensusHash:      fmt.Sprintf("%X", header.ConsensusHash),
+			LastCommitHash:     fmt.Sprintf("%X", header.LastCommitHash),
+			ProposerAddress:    fmt.Sprintf("%X", header.ProposerAddress),
+			CommitSignatures:   sigs,
+		},
+	}, nil
+}
+
+// VerifyTxProof checks that a TxInclusionProof's Merkle path is internally
+// consistent (the transaction hashes up to the claimed DataHash) and that
+// enough of trustedValidatorSet's voting power signed the block's commit.
+// It does not re-derive the header hash from trustedValidatorSet's chain
+// state; callers that need full light-client security should additionally
+// verify BlockHeader against a trusted header chain.
+func VerifyTxProof(p *TxInclusionProof, trustedValidatorSet *cmttypes.ValidatorSet) error {
+	leafHash, err := base64.StdEncoding.DecodeString(p.MerklePath.LeafHash)
+	if err != nil {
+		return fmt.Errorf("invalid leaf hash: %w", err)
+	}
+	dataHash, err := hex.DecodeString(p.BlockHeader.DataHash)
+	if err != nil {
+		return fmt.Errorf("invalid data hash: %w", err)
+	}
+	txBytes, err := base64.StdEncoding.DecodeString(p.TxBytesB64)
+	if err != nil {
+		return fmt.Errorf("invalid tx bytes: %w", err)
+	}
+
+	aunts := make([][]byte, len(p.MerklePath.Aunts))
+	for i, a := range p.MerklePath.Aunts {
+		aunt, err := base64.StdEncoding.DecodeString(a)
+		if err != nil {
+			return fmt.Errorf("invalid aunt %d: %w", i, err)
+		}
+		aunts[i] = aunt
+	}
+
+	merkleProof := &merkle.Proof{
+		Total:    p.MerklePath.Total,
+		Index:    p.MerklePath.Index,
+		LeafHash: leafHash,
+		Aunts:    aunts,
+	}
+	if err := merkleProof.Verify(dataHash, txBytes); err != nil {
+		return fmt.Errorf("merkle proof verification failed: %w", err)
+	}
+
+	if trustedValidatorSet != nil {
+		signedPower := int64(0)
+		for _, sig := range p.BlockHeader.CommitSignatures {
+			if sig.BlockIDFlag != int32(cmttypes.BlockIDFlagCommit) {
+				continue
+			}
+			addr, err := hex.DecodeString(sig.ValidatorAddress)
+			if err != nil {
+				continue
+			}
+			_, val := trustedValidatorSet.GetByAddress(addr)
+			if val != nil {
+				signedPower += val.VotingPower
+			}
+		}
+		if 3*signedPower <= 2*trustedValidatorSet.TotalVotingPower() {
+			return fmt.Errorf("insufficient signed voting power: %d of %d", signedPower, trustedValidatorSet.TotalVotingPower())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Package statuscache maintains an in-memory, reorg-safe cache of
+// transaction/session status lookups so WebServer's /status/ and /session/
+// reads don't have to re-hit CometBFT RPC and re-parse a whole block on
+// every request. It is kept up to date by a background subscriber to
+// NewBlock events (see server.WebServer.handleBlockEvent), which hashes each
+// block's transactions into a digest, invalidates entries whose digest
+// changed, and rolls back entries above the fork point on a reorg.
+package statuscache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// keepDepth is how many blocks below the tip blockHashAt retains, for reorg
+// detection against the most recent history. Older entries can't be the
+// fork point of a reorg we'd still act on, so pruning them keeps the map
+// from growing without bound over the life of the process.
+const keepDepth = 100
+
+// Entry is one cached status lookup result.
+type Entry struct {
+	TxHash      string
+	SessionID   string
+	BlockHeight int64
+	Digest      string
+	Payload     []byte // JSON-encoded api.TransactionStatus
+	CachedAt    time.Time
+}
+
+// Cache is a hashX-style status cache: tx hash and session ID both resolve
+// to the same Entry, and entries are invalidated by block height rather
+// than by a TTL.
+type Cache struct {
+	mu          sync.RWMutex
+	byTxHash    map[string]*Entry
+	bySessionID map[string]*Entry
+	blockHashAt map[int64]string // height -> block hash, for reorg detection
+	tipHeight   int64
+}
+
+// New creates an empty status cache.
+func New() *Cache {
+	return &Cache{
+		byTxHash:    make(map[string]*Entry),
+		bySessionID: make(map[string]*Entry),
+		blockHashAt: make(map[int64]string),
+	}
+}
+
+// GetByTxHash returns the cached entry for a transaction hash, if present.
+func (c *Cache) GetByTxHash(txHash string) (*Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.byTxHash[txHash]
+	return e, ok
+}
+
+// GetBySessionID returns the cached entry for a session ID, if present.
+func (c *Cache) GetBySessionID(sessionID string) (*Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.bySessionID[sessionID]
+	return e, ok
+}
+
+// Put inserts or replaces the cached entry for a tx hash (and, if non-empty,
+// its session ID).
+func (c *Cache) Put(txHash, sessionID string, blockHeight int64, digest string, payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &Entry{
+		TxHash:      txHash,
+		SessionID:   sessionID,
+		BlockHeight: blockHeight,
+		Digest:      digest,
+		Payload:     payload,
+		CachedAt:    time.Now(),
+	}
+	c.byTxHash[txHash] = entry
+	if sessionID != "" {
+		c.bySessionID[sessionID] = entry
+	}
+}
+
+// Digest hashes a block's transaction bytes and status events into a
+// single digest, used to detect whether a cached key's underlying data
+// changed without re-parsing the whole block.
+func Digest(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// OnNewBlock records the block at height/hash and handles both
+// invalidation paths:
+//
+//   - Reorg: if lastBlockHash doesn't match what we recorded for height-1,
+//     every cached entry at or above the fork point (height-1) is dropped
+//     so the next read re-fetches from RPC.
+//   - Digest change: entries named in txDigests whose stored digest
+//     differs from the new one are dropped, since their status (e.g. vote
+//     tally, confirmation count) may have changed even without a reorg.
+func (c *Cache) OnNewBlock(height int64, blockHash, lastBlockHash string, txDigests map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prevHash, ok := c.blockHashAt[height-1]; ok && prevHash != "" && prevHash != lastBlockHash {
+		c.rollbackFromLocked(height - 1)
+	}
+
+	c.blockHashAt[height] = blockHash
+	if height > c.tipHeight {
+		c.tipHeight = height
+	}
+	c.pruneBlockHashesLocked()
+
+	for txHash, digest := range txDigests {
+		entry, ok := c.byTxHash[txHash]
+		if ok && entry.Digest != digest {
+			c.evictLocked(entry)
+		}
+	}
+}
+
+// Rollback discards every cached entry and block-hash record at or above
+// forkHeight. Unlike OnNewBlock's reorg handling, which derives forkHeight
+// by comparing recorded block hashes, this is for callers (such as a
+// follower.FollowerServer) that already know the fork height from their own
+// reorg detection and just need the cache invalidated to match.
+func (c *Cache) Rollback(forkHeight int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollbackFromLocked(forkHeight)
+}
+
+// rollbackFromLocked discards every cached entry and block-hash record at
+// or above forkHeight. Callers must hold c.mu.
+func (c *Cache) rollbackFromLocked(forkHeight int64) {
+	for height := range c.blockHashAt {
+		if height >= forkHeight {
+			delete(c.blockHashAt, height)
+		}
+	}
+	for _, entry := range c.byTxHash {
+		if entry.BlockHeight >= forkHeight {
+			c.evictLocked(entry)
+		}
+	}
+}
+
+// pruneBlockHashesLocked discards blockHashAt entries more than keepDepth
+// below the tip. On the normal forward path (no reorg) nothing else ever
+// prunes this map, so without this it grows by one entry per block for as
+// long as the process runs. Callers must hold c.mu.
+func (c *Cache) pruneBlockHashesLocked() {
+	cutoff := c.tipHeight - keepDepth
+	if cutoff <= 0 {
+		return
+	}
+	for height := range c.blockHashAt {
+		if height < cutoff {
+			delete(c.blockHashAt, height)
+		}
+	}
+}
+
+// evictLocked removes an entry from both indexes. Callers must hold c.mu.
+func (c *Cache) evictLocked(entry *Entry) {
+	delete(c.byTxHash, entry.TxHash)
+	if entry.SessionID != "" {
+		delete(c.bySessionID, entry.SessionID)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	coreapi "github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// CommitHandler serves /v1/commit/, a narrower route than /v1/session/ for
+// clients that only ever finalize a session (the ReceiveCommitHandler step
+// of the service registry) and don't need the GET status-read half of
+// SessionHandler. It shares submitSessionOp with SessionHandler rather than
+// duplicating the HTTP request -> consensus request conversion.
+type CommitHandler struct {
+	api    *coreapi.API
+	codecs *codec.Registry
+	logger cmtlog.Logger
+}
+
+// NewCommitHandler creates a CommitHandler bound to the given dependencies.
+func NewCommitHandler(deps Dependencies) *CommitHandler {
+	return &CommitHandler{api: deps.API, codecs: deps.Codecs, logger: deps.Logger}
+}
+
+func (h *CommitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	submitSessionOp(h.api, h.codecs, h.logger, w, r)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	coreapi "github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
+)
+
+// DebugHandler serves GET /v1/debug, reporting the node's CometBFT/ABCI
+// health.
+type DebugHandler struct {
+	api     *coreapi.API
+	codecs  *codec.Registry
+	metrics *Metrics
+}
+
+// NewDebugHandler creates a DebugHandler bound to the given dependencies
+// and metrics counter.
+func NewDebugHandler(deps Dependencies, metrics *Metrics) *DebugHandler {
+	return &DebugHandler{api: deps.API, codecs: deps.Codecs, metrics: metrics}
+}
+
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	debugInfo, err := h.api.Debug(r.Context())
+	if err != nil {
+		internal.JSONError(w, "Error collecting debug info: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if h.metrics != nil {
+		debugInfo["http_request_counts"] = h.metrics.Snapshot()
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, debugInfo)
+}
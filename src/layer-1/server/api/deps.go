@@ -0,0 +1,44 @@
+// Package api implements the versioned (/v1/...) HTTP surface for the
+// layer-1 node, as a set of small, constructor-injected sub-routers
+// (SessionHandler, CommitHandler, StatusHandler, DebugHandler) composed
+// behind a shared middleware chain, instead of the large handler methods
+// server.WebServer used to carry directly. It is mounted by
+// server.WebServer alongside the original unversioned routes, which stay
+// in place so existing clients keep working; /v1/... is where future
+// breaking changes to the HTTP surface should land.
+package api
+
+import (
+	"net/http"
+
+	coreapi "github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// Dependencies are the shared collaborators every sub-handler is
+// constructed with.
+type Dependencies struct {
+	API    *coreapi.API
+	Codecs *codec.Registry
+	Logger cmtlog.Logger
+
+	// Auth, if set, is run by the auth middleware on every /v1/ request.
+	// Leave nil to leave the versioned surface unauthenticated, matching
+	// the unversioned routes.
+	Auth AuthFunc
+	// RateLimit bounds requests per RemoteAddr per minute; 0 disables it.
+	RateLimit int
+}
+
+// writeResponse encodes v with the codec negotiated for r, matching
+// server.WebServer.writeResponse's content negotiation so both the
+// versioned and unversioned surfaces behave identically for a given
+// Accept header or ?format= value.
+func writeResponse(codecs *codec.Registry, w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) {
+	if err := codec.Write(w, r, codecs, statusCode, v); err != nil {
+		internal.JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
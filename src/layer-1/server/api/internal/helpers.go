@@ -0,0 +1,53 @@
+// Package internal holds small helpers shared by the versioned api
+// package's sub-handlers, factored out so each handler file stays focused
+// on its own route instead of redefining request ID generation or error
+// formatting.
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// GenerateRequestID returns a random hex-encoded request ID, used to
+// correlate an inbound HTTP request with the consensus transaction it
+// produces.
+func GenerateRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// JSONError sends a JSON formatted error response with the given status
+// code and message.
+func JSONError(w http.ResponseWriter, message string, statusCode int) {
+	errorResponse := struct {
+		Error string `json:"error"`
+	}{
+		Error: message,
+	}
+	jsonBytes, err := json.Marshal(errorResponse)
+	if err != nil {
+		// If JSON marshaling fails, fall back to plain text
+		JSONError(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(jsonBytes)
+}
+
+// ExtractPortFromAddress extracts the port from an address string.
+func ExtractPortFromAddress(address string) string {
+	for i := len(address) - 1; i >= 0; i-- {
+		if address[i] == ':' {
+			return address[i+1:]
+		}
+	}
+	return ""
+}
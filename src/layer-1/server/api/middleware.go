@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// Middleware wraps an http.Handler with cross-cutting behavior (logging,
+// auth, rate limiting, ...). Handlers are composed with Chain, outermost
+// first, matching how the standard library's own http.Handler wrapping
+// reads.
+type Middleware func(http.Handler) http.Handler
+
+// Chain combines middlewares into a single Middleware that applies them in
+// the order given: Chain(RequestID, Logging)(h) runs RequestID first, then
+// Logging, then h.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(final http.Handler) http.Handler {
+		h := final
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			h = middlewares[i](h)
+		}
+		return h
+	}
+}
+
+type contextKey string
+
+// requestIDContextKey is how a downstream handler recovers the request ID
+// RequestID generated, e.g. to include it in a JSON error body.
+const requestIDContextKey contextKey = "request_id"
+
+// RequestIDFromContext returns the request ID RequestID attached to ctx, or
+// "" if the RequestID middleware wasn't applied.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// RequestID generates a random request ID (unless the client already sent
+// one via X-Request-ID), attaches it to the request context, and echoes it
+// back on the response so a client can correlate logs across a request.
+func RequestID(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	id := r.Header.Get("X-Request-ID")
+	if id == "" {
+		id = generateRequestID()
+	}
+	w.Header().Set("X-Request-ID", id)
+	ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func generateRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDMiddleware adapts RequestID to the Middleware signature.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestID(w, r, next)
+	})
+}
+
+// loggingMiddleware logs the method, path, status code, and duration of
+// every request that passes through it.
+func loggingMiddleware(logger cmtlog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			logger.Info("http request",
+				"request_id", RequestIDFromContext(r.Context()),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", sw.status,
+				"duration", time.Since(start).String(),
+			)
+		})
+	}
+}
+
+// statusWriter records the status code written through it, since
+// http.ResponseWriter doesn't expose one after the fact.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// recoverMiddleware turns a panic in a handler into a 500 response instead
+// of taking down the whole web server.
+func recoverMiddleware(logger cmtlog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.Error("panic handling request", "request_id", RequestIDFromContext(r.Context()), "recover", rec)
+					internal.JSONError(w, "Internal server error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthFunc validates a request's credentials (e.g. an API key header),
+// returning an error describing why the request is unauthorized.
+type AuthFunc func(r *http.Request) error
+
+// authMiddleware rejects requests that fail check with 401. A nil check
+// means every request is authorized, keeping auth opt-in per deployment.
+func authMiddleware(check AuthFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if check != nil {
+				if err := check(r); err != nil {
+					internal.JSONError(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimiter is a simple fixed-window counter per RemoteAddr; it trades
+// precision for a single map lookup per request, which is enough to shed
+// load from a single runaway client without needing a token-bucket library.
+type rateLimiter struct {
+	mu         sync.Mutex
+	window     time.Duration
+	limit      int
+	windowEnds time.Time
+	counts     map[string]int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:      limit,
+		window:     window,
+		windowEnds: time.Now().Add(window),
+		counts:     make(map[string]int),
+	}
+}
+
+func (rl *rateLimiter) allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if time.Now().After(rl.windowEnds) {
+		rl.counts = make(map[string]int)
+		rl.windowEnds = time.Now().Add(rl.window)
+	}
+
+	rl.counts[key]++
+	return rl.counts[key] <= rl.limit
+}
+
+// rateLimitMiddleware rejects requests over limit-per-window from the same
+// RemoteAddr with 429. limit <= 0 disables rate limiting entirely.
+func rateLimitMiddleware(limit int, window time.Duration) Middleware {
+	if limit <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	rl := newRateLimiter(limit, window)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rl.allow(r.RemoteAddr) {
+				internal.JSONError(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Metrics counts requests handled per route, exposed for a /v1/debug-style
+// endpoint to report. It's intentionally minimal - an in-memory counter,
+// not a Prometheus client - since nothing else in this codebase pulls in a
+// metrics dependency yet.
+type Metrics struct {
+	counts sync.Map // route string -> *int64
+}
+
+// NewMetrics creates an empty counter set.
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// Snapshot returns a copy of the current per-route request counts.
+func (m *Metrics) Snapshot() map[string]int64 {
+	out := make(map[string]int64)
+	m.counts.Range(func(key, value interface{}) bool {
+		out[key.(string)] = atomic.LoadInt64(value.(*int64))
+		return true
+	})
+	return out
+}
+
+func (m *Metrics) increment(route string) {
+	v, _ := m.counts.LoadOrStore(route, new(int64))
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+// metricsMiddleware increments m's counter for route on every request
+// through it.
+func metricsMiddleware(m *Metrics, route string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.increment(route)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
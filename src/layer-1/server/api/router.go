@@ -0,0 +1,53 @@
+package api
+
+import "net/http"
+
+// Router mounts the versioned (/v1/...) HTTP surface onto an
+// http.ServeMux, wrapping every route in the same middleware chain:
+// RequestID, Recover, Auth, RateLimit, Logging, Metrics.
+type Router struct {
+	deps    Dependencies
+	metrics *Metrics
+	chain   Middleware
+}
+
+// NewRouter builds a Router from deps. Auth/RateLimit behavior comes from
+// the corresponding Dependencies fields.
+func NewRouter(deps Dependencies) *Router {
+	metrics := NewMetrics()
+	return &Router{
+		deps:    deps,
+		metrics: metrics,
+		chain: Chain(
+			requestIDMiddleware,
+			recoverMiddleware(deps.Logger),
+			authMiddleware(deps.Auth),
+			rateLimitMiddleware(deps.RateLimit, defaultRateLimitWindow),
+			loggingMiddleware(deps.Logger),
+		),
+	}
+}
+
+// defaultRateLimitWindow is the window rate limiting is measured over when
+// Dependencies.RateLimit is set.
+const defaultRateLimitWindow = 60 * 1_000_000_000 // 1 minute, in time.Duration's ns units
+
+func (router *Router) wrap(route string, h http.Handler) http.Handler {
+	return Chain(router.chain, metricsMiddleware(router.metrics, route))(h)
+}
+
+// RegisterRoutes mounts every /v1/... route onto mux.
+func (router *Router) RegisterRoutes(mux *http.ServeMux) {
+	session := NewSessionHandler(router.deps)
+	commit := NewCommitHandler(router.deps)
+	status := NewStatusHandler(router.deps)
+	debug := NewDebugHandler(router.deps, router.metrics)
+
+	mux.Handle("/v1/session/", router.wrap("session", session))
+	mux.Handle("/v1/commit/", router.wrap("commit", commit))
+	mux.Handle("/v1/status/", router.wrap("status", http.HandlerFunc(status.Transaction)))
+	mux.Handle("/v1/block/", router.wrap("block", http.HandlerFunc(status.Block)))
+	mux.Handle("/v1/proof/tx/", router.wrap("proof_tx", http.HandlerFunc(status.TxProof)))
+	mux.Handle("/v1/proof/session/", router.wrap("proof_session", http.HandlerFunc(status.SessionProof)))
+	mux.Handle("/v1/debug", router.wrap("debug", debug))
+}
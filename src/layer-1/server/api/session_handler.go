@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	coreapi "github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// SessionHandler serves /v1/session/..., the same surface as
+// server.WebServer.handleSessionAPI: a GET is a status read served from the
+// status cache when possible, anything else is a session operation
+// submitted to consensus at the commit level and wire format the request
+// asks for.
+type SessionHandler struct {
+	api    *coreapi.API
+	codecs *codec.Registry
+	logger cmtlog.Logger
+}
+
+// NewSessionHandler creates a SessionHandler bound to the given
+// dependencies.
+func NewSessionHandler(deps Dependencies) *SessionHandler {
+	return &SessionHandler{api: deps.API, codecs: deps.Codecs, logger: deps.Logger}
+}
+
+func (h *SessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.status(w, r)
+		return
+	}
+	h.submit(w, r)
+}
+
+// status serves GET /v1/session/{sessionID}/... as a status read for that
+// session's committed transaction.
+func (h *SessionHandler) status(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := sessionIDFromRequestPath(r.URL.Path)
+	if !ok {
+		internal.JSONError(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.api.GetSessionStatus(r.Context(), sessionID)
+	if err != nil {
+		internal.JSONError(w, "Error checking session status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if status == nil {
+		internal.JSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, status)
+}
+
+// submit converts a non-GET request into a consensus request and submits
+// it through coreapi.API.SubmitSessionOp.
+func (h *SessionHandler) submit(w http.ResponseWriter, r *http.Request) {
+	submitSessionOp(h.api, h.codecs, h.logger, w, r)
+}
+
+// submitSessionOp does the HTTP request -> consensus request -> response
+// conversion shared by SessionHandler's non-GET path and CommitHandler, so
+// the two routes don't carry two copies of the same ~30 lines.
+func submitSessionOp(a *coreapi.API, codecs *codec.Registry, logger cmtlog.Logger, w http.ResponseWriter, r *http.Request) {
+	requestID, err := internal.GenerateRequestID()
+	if err != nil {
+		internal.JSONError(w, "Internal Server Error", http.StatusInternalServerError)
+		logger.Error("Failed to generate request ID", "err", err)
+		return
+	}
+
+	request, err := service_registry.ConvertHttpRequestToConsensusRequest(r, requestID)
+	if err != nil {
+		internal.JSONError(w, "Failed to convert request: "+err.Error(), http.StatusUnprocessableEntity)
+		logger.Error("Failed to convert HTTP request", "err", err)
+		return
+	}
+
+	commitOpts, err := commitOptionsFromRequest(r)
+	if err != nil {
+		internal.JSONError(w, "Invalid commit level: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	apiResponse, err := a.SubmitSessionOp(r.Context(), request, commitOpts, txFormatFromRequest(r))
+	if err != nil {
+		internal.JSONError(w, "Failed to submit session operation: "+err.Error(), http.StatusInternalServerError)
+		logger.Error("Failed to submit session operation", "err", err)
+		return
+	}
+
+	for key, value := range apiResponse.Headers {
+		w.Header().Set(key, value)
+	}
+	writeResponse(codecs, w, r, apiResponse.StatusCode, apiResponse)
+}
+
+// sessionIDFromRequestPath extracts the session ID from a path shaped like
+// "/v1/session/{sessionID}" or "/v1/session/{sessionID}/...".
+func sessionIDFromRequestPath(path string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "v1" || parts[1] != "session" || parts[2] == "" {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// commitOptionsFromRequest reads the commit level from the `commit` query
+// parameter, falling back to the X-Commit-Level header, e.g.
+// "?commit=confirmed:3" or "X-Commit-Level: broadcast".
+func commitOptionsFromRequest(r *http.Request) (coreapi.CommitOptions, error) {
+	raw := r.URL.Query().Get("commit")
+	if raw == "" {
+		raw = r.Header.Get("X-Commit-Level")
+	}
+	return coreapi.ParseCommitOptions(raw)
+}
+
+// txFormatFromRequest resolves the wire format a submitted transaction
+// should be broadcast as, from the request's Content-Type header. An empty
+// value keeps the original unprefixed JSON encoding.
+func txFormatFromRequest(r *http.Request) string {
+	return r.Header.Get("Content-Type")
+}
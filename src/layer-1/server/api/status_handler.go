@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	coreapi "github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/api/internal"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// StatusHandler serves the read-only /v1/status/, /v1/block/,
+// /v1/proof/tx/, and /v1/proof/session/ routes, mirroring
+// server.WebServer's handleTransactionStatus/handleBlockInfo/
+// handleTxProof/handleSessionProof but as methods on one constructor-
+// injected type instead of four free functions closing over *WebServer.
+type StatusHandler struct {
+	api    *coreapi.API
+	codecs *codec.Registry
+	logger cmtlog.Logger
+}
+
+// NewStatusHandler creates a StatusHandler bound to the given dependencies.
+func NewStatusHandler(deps Dependencies) *StatusHandler {
+	return &StatusHandler{api: deps.API, codecs: deps.Codecs, logger: deps.Logger}
+}
+
+// Transaction serves GET /v1/status/{txID}.
+func (h *StatusHandler) Transaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID, ok := lastPathSegment(r.URL.Path, "status")
+	if !ok {
+		internal.JSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.api.GetTxStatus(r.Context(), txID)
+	if err != nil {
+		internal.JSONError(w, "Error checking transaction status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if status == nil {
+		internal.JSONError(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, status)
+}
+
+// Block serves GET /v1/block/{height}.
+func (h *StatusHandler) Block(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	heightStr, ok := lastPathSegment(r.URL.Path, "block")
+	if !ok {
+		internal.JSONError(w, "Invalid block height", http.StatusBadRequest)
+		return
+	}
+	height, err := strconv.ParseInt(heightStr, 10, 64)
+	if err != nil {
+		internal.JSONError(w, "Invalid block height format", http.StatusBadRequest)
+		return
+	}
+
+	blockInfo, err := h.api.GetBlock(r.Context(), height)
+	if err != nil {
+		internal.JSONError(w, "Error fetching block: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if blockInfo == nil {
+		internal.JSONError(w, "Block not found", http.StatusNotFound)
+		return
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, blockInfo)
+}
+
+// TxProof serves GET /v1/proof/tx/{txID}.
+func (h *StatusHandler) TxProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID, ok := lastPathSegment(r.URL.Path, "tx")
+	if !ok {
+		internal.JSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	txProof, err := h.api.GetTxProof(r.Context(), txID)
+	if err != nil {
+		internal.JSONError(w, "Error building proof: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if txProof == nil {
+		internal.JSONError(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, txProof)
+}
+
+// SessionProof serves GET /v1/proof/session/{sessionID}.
+func (h *StatusHandler) SessionProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		internal.JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID, ok := lastPathSegment(r.URL.Path, "session")
+	if !ok {
+		internal.JSONError(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	sessionProof, err := h.api.GetSessionProof(r.Context(), sessionID)
+	if err != nil {
+		internal.JSONError(w, "Error building proof: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sessionProof == nil {
+		internal.JSONError(w, "Session has no committed transaction", http.StatusNotFound)
+		return
+	}
+
+	writeResponse(h.codecs, w, r, http.StatusOK, sessionProof)
+}
+
+// lastPathSegment returns a path's final segment, requiring that the
+// segment before it equal parent (e.g. "tx" in "/v1/proof/tx/{txID}").
+func lastPathSegment(path, parent string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] != parent || parts[len(parts)-1] == "" {
+		return "", false
+	}
+	return parts[len(parts)-1], true
+}
@@ -0,0 +1,17 @@
+package codec
+
+import (
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOREncoder serializes values as CBOR, for clients (embedded/mobile)
+// that prefer it over MessagePack or protobuf.
+type CBOREncoder struct{}
+
+func (CBOREncoder) MediaType() string { return "application/cbor" }
+
+func (CBOREncoder) Encode(w http.ResponseWriter, v interface{}) error {
+	return cbor.NewEncoder(w).Encode(v)
+}
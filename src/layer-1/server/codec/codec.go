@@ -0,0 +1,100 @@
+// Package codec implements content negotiation for WebServer responses.
+// Large payloads like block dumps and session transactions are expensive
+// to re-encode as JSON on every request; Registry lets a handler write a
+// Go value once and have it serialized as JSON, MessagePack, CBOR, or
+// protobuf depending on what the client asked for.
+package codec
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Encoder serializes a Go value onto an http.ResponseWriter body for one
+// media type.
+type Encoder interface {
+	// MediaType is the value written to the Content-Type header.
+	MediaType() string
+	// Encode writes v's serialized form to w.
+	Encode(w http.ResponseWriter, v interface{}) error
+}
+
+// formatAliases maps the short `?format=` query values to their media
+// types, so clients that can't set Accept headers (e.g. a browser address
+// bar) can still pick a codec.
+var formatAliases = map[string]string{
+	"json":     "application/json",
+	"msgpack":  "application/x-msgpack",
+	"cbor":     "application/cbor",
+	"protobuf": "application/x-protobuf",
+}
+
+// Registry resolves an *http.Request to the Encoder it should be served
+// with, falling back to a default media type when the client expresses no
+// preference or asks for one that isn't registered.
+type Registry struct {
+	mu       sync.RWMutex
+	encoders map[string]Encoder
+	fallback string
+}
+
+// NewRegistry creates an empty registry that falls back to fallbackMediaType
+// when a request's Accept header and ?format= param match nothing
+// registered.
+func NewRegistry(fallbackMediaType string) *Registry {
+	return &Registry{
+		encoders: make(map[string]Encoder),
+		fallback: fallbackMediaType,
+	}
+}
+
+// Register adds enc under its MediaType, replacing any existing encoder for
+// that type.
+func (r *Registry) Register(enc Encoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.encoders[enc.MediaType()] = enc
+}
+
+// Resolve picks an Encoder for req: the `?format=` query param wins if it
+// names a registered alias or media type, otherwise the first Accept
+// header entry that matches a registered media type is used, and failing
+// that the registry's fallback.
+func (r *Registry) Resolve(req *http.Request) Encoder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if format := req.URL.Query().Get("format"); format != "" {
+		mediaType := format
+		if alias, ok := formatAliases[strings.ToLower(format)]; ok {
+			mediaType = alias
+		}
+		if enc, ok := r.encoders[mediaType]; ok {
+			return enc
+		}
+	}
+
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(accept, ";", 2)[0])
+		if enc, ok := r.encoders[mediaType]; ok {
+			return enc
+		}
+	}
+
+	return r.encoders[r.fallback]
+}
+
+// Write resolves the Encoder for req, writes the status code and the
+// encoder's Content-Type header, and encodes v onto w.
+func Write(w http.ResponseWriter, req *http.Request, reg *Registry, statusCode int, v interface{}) error {
+	enc := reg.Resolve(req)
+	if enc == nil {
+		return fmt.Errorf("no codec registered (not even the fallback)")
+	}
+
+	w.Header().Set("Content-Type", enc.MediaType())
+	w.WriteHeader(statusCode)
+	return enc.Encode(w, v)
+}
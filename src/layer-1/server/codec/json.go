@@ -0,0 +1,18 @@
+package codec
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JSONEncoder serializes values as indented JSON, matching the formatting
+// WebServer used before content negotiation was introduced.
+type JSONEncoder struct{}
+
+func (JSONEncoder) MediaType() string { return "application/json" }
+
+func (JSONEncoder) Encode(w http.ResponseWriter, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
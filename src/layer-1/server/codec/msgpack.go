@@ -0,0 +1,18 @@
+package codec
+
+import (
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackEncoder serializes values as MessagePack, which is considerably
+// more compact than JSON for the large block/transaction dumps returned
+// from /block/ and /session/.
+type MsgpackEncoder struct{}
+
+func (MsgpackEncoder) MediaType() string { return "application/x-msgpack" }
+
+func (MsgpackEncoder) Encode(w http.ResponseWriter, v interface{}) error {
+	return msgpack.NewEncoder(w).Encode(v)
+}
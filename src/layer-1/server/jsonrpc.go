@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/api"
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+)
+
+// sessionOpParams mirrors the fields ConvertHttpRequestToConsensusRequest
+// would otherwise derive from an *http.Request, so JSON-RPC callers can
+// submit a session operation without going through the REST route.
+type sessionOpParams struct {
+	Path   string          `json:"path"`
+	Method string          `json:"method"`
+	Body   json.RawMessage `json:"body"`
+	Commit string          `json:"commit"` // "broadcast", "included" (default), or "confirmed:N"
+}
+
+type txStatusParams struct {
+	TxID string `json:"tx_id"`
+}
+
+type blockParams struct {
+	Height int64 `json:"height"`
+}
+
+// handleJSONRPC exposes the same operations as the REST routes (session
+// submission, tx status, block lookup, debug) over JSON-RPC 2.0 request/
+// response, reusing the envelope types defined in ws.go for the WebSocket
+// transport.
+func (ws *WebServer) handleJSONRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req jsonRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonRPCResponse{JSONRPC: "2.0", Error: "invalid request: " + err.Error()})
+		return
+	}
+
+	switch req.Method {
+	case "submitSessionOp":
+		ws.jsonRPCSubmitSessionOp(w, r, req)
+	case "getTxStatus":
+		ws.jsonRPCGetTxStatus(w, r, req)
+	case "getBlock":
+		ws.jsonRPCGetBlock(w, r, req)
+	case "debug":
+		ws.jsonRPCDebug(w, r, req)
+	default:
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "unknown method: " + req.Method})
+	}
+}
+
+func (ws *WebServer) jsonRPCSubmitSessionOp(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+	var params sessionOpParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params: " + err.Error()})
+		return
+	}
+
+	requestID, err := generateRequestID()
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "failed to generate request id"})
+		return
+	}
+
+	request := &service_registry.Request{
+		RequestID: requestID,
+		Path:      params.Path,
+		Method:    params.Method,
+		Body:      string(params.Body),
+	}
+
+	commitOpts, err := api.ParseCommitOptions(params.Commit)
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid commit level: " + err.Error()})
+		return
+	}
+
+	apiResponse, err := ws.api.SubmitSessionOp(r.Context(), request, commitOpts, "")
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: apiResponse})
+}
+
+func (ws *WebServer) jsonRPCGetTxStatus(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+	var params txStatusParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params: " + err.Error()})
+		return
+	}
+
+	status, err := ws.api.GetTxStatus(r.Context(), params.TxID)
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: status})
+}
+
+func (ws *WebServer) jsonRPCGetBlock(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+	var params blockParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params: " + err.Error()})
+		return
+	}
+
+	block, err := ws.api.GetBlock(r.Context(), params.Height)
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: block})
+}
+
+func (ws *WebServer) jsonRPCDebug(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+	debugInfo, err := ws.api.Debug(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: debugInfo})
+}
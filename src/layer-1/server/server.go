@@ -3,7 +3,6 @@ package server
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -12,97 +11,65 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/api"
 	"github.com/ahmadzakiakmal/thesis/src/layer-1/app"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/consensus"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/follower"
 	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository"
+	versionedapi "github.com/ahmadzakiakmal/thesis/src/layer-1/server/api"
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/server/codec"
 	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
 
 	cmtlog "github.com/cometbft/cometbft/libs/log"
 	nm "github.com/cometbft/cometbft/node"
-	"github.com/cometbft/cometbft/rpc/client"
 	cmthttp "github.com/cometbft/cometbft/rpc/client/http"
 	cmtrpc "github.com/cometbft/cometbft/rpc/client/local"
 )
 
-// WebServer handles HTTP requests
+// WebServer binds the api package's transport-agnostic methods onto
+// net/http. It owns no consensus/repository logic of its own - that all
+// lives in api.API, bound to a consensus.Engine - and is responsible only
+// for request parsing, routing, and response encoding.
+//
+// A WebServer built with NewWebServer runs a full validator node; one built
+// with NewFollowerWebServer instead mirrors a primary through a
+// follower.FollowerServer and serves GET-only traffic, with node left nil.
 type WebServer struct {
-	app                *app.Application
-	httpAddr           string
-	server             *http.Server
-	logger             cmtlog.Logger
-	node               *nm.Node
-	startTime          time.Time
-	serviceRegistry    *service_registry.ServiceRegistry
-	cometBftHttpClient client.Client
-	cometBftRpcClient  *cmtrpc.Local
-	peers              map[string]string // nodeID -> RPC URL
-	repository         *repository.Repository
+	app            *app.Application
+	httpAddr       string
+	server         *http.Server
+	logger         cmtlog.Logger
+	node           *nm.Node
+	api            *api.API
+	engine         consensus.Engine
+	follower       *follower.FollowerServer
+	peers          map[string]string // nodeID -> RPC URL
+	repository     *repository.Repository
+	sessionManager *SessionManager
+	notifierCancel context.CancelFunc
+	codecs         *codec.Registry
 }
 
-// TransactionStatus represents the consensus status of a transaction
-type TransactionStatus struct {
-	TxID         string         `json:"tx_id"`
-	RequestID    string         `json:"request_id"`
-	Status       string         `json:"status"`
-	BlockHeight  int64          `json:"block_height"`
-	BlockHash    string         `json:"block_hash,omitempty"`
-	ConfirmTime  time.Time      `json:"confirm_time"`
-	ResponseInfo ResponseInfo   `json:"response_info"`
-	BlockTxs     BlockTxsDetail `json:"block_txs"`
+// newCodecRegistry registers the content negotiation codecs WebServer
+// supports, defaulting to JSON for clients that express no preference.
+func newCodecRegistry() *codec.Registry {
+	reg := codec.NewRegistry("application/json")
+	reg.Register(codec.JSONEncoder{})
+	reg.Register(codec.MsgpackEncoder{})
+	reg.Register(codec.CBOREncoder{})
+	reg.Register(codec.ProtobufEncoder{})
+	return reg
 }
 
-// BlockTxsDetail contains the transactions within a block
-type BlockTxsDetail struct {
-	BlockTransactions    []service_registry.Transaction `json:"block_transactions"`
-	BlockTransactionsB64 []string                       `json:"block_transactions_b64"`
-}
-
-// ResponseInfo contains information about the response
-type ResponseInfo struct {
-	StatusCode  int    `json:"status_code"`
-	ContentType string `json:"content_type,omitempty"`
-	BodyLength  int    `json:"body_length"`
-}
-
-// ConsensusInfo contains information about the consensus process
-type ConsensusInfo struct {
-	TotalNodes     int           `json:"total_nodes"`
-	AgreementNodes int           `json:"agreement_nodes"`
-	NodeResponses  []bool        `json:"node_responses,omitempty"`
-	Votes          []interface{} `json:"votes"`
-}
-
-// ClientResponse is the response format sent to clients
-type ClientResponse struct {
-	StatusCode int               `json:"-"` // Not included in JSON
-	Headers    map[string]string `json:"-"` // Not included in JSON
-	// Body          string            `json:"body,omitempty"`
-	Body          interface{}       `json:"body"`
-	Meta          TransactionStatus `json:"meta"`
-	BlockchainRef string            `json:"blockchain_ref"`
-	NodeID        string            `json:"node_id"`
-}
-
-// NewWebServer creates a new web server
-func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, node *nm.Node, serviceRegistry *service_registry.ServiceRegistry, repository *repository.Repository) (*WebServer, error) {
+// NewWebServer creates a new web server. maxSessions and sessionTimeout bound
+// the WebSocket subscription manager; pass 0 for either to use the defaults.
+func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, node *nm.Node, serviceRegistry *service_registry.ServiceRegistry, repository *repository.Repository, maxSessions int, sessionTimeout time.Duration) (*WebServer, error) {
 	mux := http.NewServeMux()
 
 	rpcAddr := fmt.Sprintf("http://localhost:%s", extractPortFromAddress(node.Config().RPC.ListenAddress))
 	logger.Info("Connecting to CometBFT RPC", "address", rpcAddr)
 
-	// Create HTTP client without WebSocket
-	cometBftHttpClient, err := cmthttp.NewWithClient(
-		rpcAddr,
-		&http.Client{
-			Timeout: 10 * time.Second,
-		},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create CometBFT client: %w", err)
-	}
-	err = cometBftHttpClient.Start()
-	if err != nil {
-		return nil, fmt.Errorf("failed to start CometBFT client: %w", err)
-	}
+	engine := consensus.NewCometBFTEngine(cmtrpc.New(node))
 
 	server := &WebServer{
 		app:      app,
@@ -111,23 +78,84 @@ func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, n
 			Addr:    ":" + httpPort,
 			Handler: mux,
 		},
-		logger:             logger,
-		node:               node,
-		startTime:          time.Now(),
-		serviceRegistry:    serviceRegistry,
-		cometBftHttpClient: cometBftHttpClient,
-		cometBftRpcClient:  cmtrpc.New(node),
-		peers:              make(map[string]string),
-		repository:         repository,
+		logger:         logger,
+		node:           node,
+		api:            api.New(node, repository, serviceRegistry, engine, logger),
+		engine:         engine,
+		peers:          make(map[string]string),
+		repository:     repository,
+		sessionManager: NewSessionManager(maxSessions, sessionTimeout, logger),
+		codecs:         newCodecRegistry(),
 	}
 
-	// Register routes
+	// REST transport
 	mux.HandleFunc("/", server.handleRoot)
 	mux.HandleFunc("/debug", server.handleDebug)
 	mux.HandleFunc("/status/", server.handleTransactionStatus)
 	mux.HandleFunc("/block/", server.handleBlockInfo)
+	mux.HandleFunc("/proof/tx/", server.handleTxProof)
+	mux.HandleFunc("/proof/session/", server.handleSessionProof)
 	// Session Endpoints
 	mux.HandleFunc("/session/", server.handleSessionAPI)
+	// WebSocket subscription endpoint
+	mux.HandleFunc("/ws", server.handleWebSocket)
+	// JSON-RPC 2.0 transport, same API surface as the REST routes above
+	mux.HandleFunc("/jsonrpc", server.handleJSONRPC)
+	// Versioned surface; additive, the routes above keep serving existing clients
+	versionedapi.NewRouter(versionedapi.Dependencies{
+		API:    server.api,
+		Codecs: server.codecs,
+		Logger: logger,
+	}).RegisterRoutes(mux)
+
+	return server, nil
+}
+
+// NewFollowerWebServer creates a read-only web server that mirrors
+// primaryRPCAddr's chain into repo through a follower.FollowerServer instead
+// of running a validating node. It registers only the GET-only routes
+// (/status/, /block/, /proof/tx/, /proof/session/, /session/, /ws); there is
+// no /, /debug, or /jsonrpc, and /session/ is routed straight to
+// handleSessionStatus, so there is no session submission path.
+func NewFollowerWebServer(primaryRPCAddr, httpPort string, logger cmtlog.Logger, repo *repository.Repository, maxSessions int, sessionTimeout time.Duration) (*WebServer, error) {
+	mux := http.NewServeMux()
+
+	logger.Info("Connecting follower to primary RPC", "address", primaryRPCAddr)
+	httpClient, err := cmthttp.New(primaryRPCAddr, "/websocket")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create follower RPC client: %w", err)
+	}
+
+	engine := consensus.NewCometBFTEngine(httpClient)
+	fs := follower.NewFollowerServer(repo, engine, logger)
+
+	server := &WebServer{
+		httpAddr: ":" + httpPort,
+		server: &http.Server{
+			Addr:    ":" + httpPort,
+			Handler: mux,
+		},
+		logger:         logger,
+		api:            api.New(nil, repo, nil, engine, logger),
+		engine:         engine,
+		follower:       fs,
+		peers:          make(map[string]string),
+		repository:     repo,
+		sessionManager: NewSessionManager(maxSessions, sessionTimeout, logger),
+		codecs:         newCodecRegistry(),
+	}
+
+	mux.HandleFunc("/status/", server.handleTransactionStatus)
+	mux.HandleFunc("/block/", server.handleBlockInfo)
+	mux.HandleFunc("/proof/tx/", server.handleTxProof)
+	mux.HandleFunc("/proof/session/", server.handleSessionProof)
+	mux.HandleFunc("/session/", server.handleSessionStatus)
+	mux.HandleFunc("/ws", server.handleWebSocket)
+	versionedapi.NewRouter(versionedapi.Dependencies{
+		API:    server.api,
+		Codecs: server.codecs,
+		Logger: logger,
+	}).RegisterRoutes(mux)
 
 	return server, nil
 }
@@ -135,6 +163,21 @@ func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, n
 // Start starts the web server
 func (ws *WebServer) Start() error {
 	ws.logger.Info("Starting web server", "addr", ws.httpAddr)
+
+	notifierCtx, cancel := context.WithCancel(context.Background())
+	ws.notifierCancel = cancel
+
+	if ws.follower != nil {
+		go func() {
+			if err := ws.follower.RunDetectChanges(notifierCtx); err != nil && err != context.Canceled {
+				ws.logger.Error("follower sync loop stopped", "err", err)
+			}
+		}()
+		go ws.watchFollowerReorgs(notifierCtx)
+	} else if err := ws.startEventNotifier(notifierCtx); err != nil {
+		ws.logger.Error("failed to start WebSocket event notifier", "err", err)
+	}
+
 	go func() {
 		if err := ws.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			ws.logger.Error("web server error: ", "err", err)
@@ -143,10 +186,36 @@ func (ws *WebServer) Start() error {
 	return nil
 }
 
+// watchFollowerReorgs invalidates the status cache and notifies subscribed
+// WebSocket clients whenever the follower rolls back to an earlier height.
+func (ws *WebServer) watchFollowerReorgs(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case hh, ok := <-ws.follower.Reorgs():
+			if !ok {
+				return
+			}
+			ws.api.InvalidateFromHeight(hh.Height)
+			ws.sessionManager.Broadcast("reorg", func(SubscriptionFilter) bool { return true }, map[string]interface{}{
+				"topic":       "reorg",
+				"fork_height": hh.Height,
+			})
+		}
+	}
+}
+
 // Shutdown gracefully shuts down the web server
 func (ws *WebServer) Shutdown(ctx context.Context) error {
 	ws.logger.Info("Shutting down web server")
-	return ws.server.Shutdown(ctx)
+	if ws.notifierCancel != nil {
+		ws.notifierCancel()
+	}
+	if err := ws.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return ws.api.Shutdown(ctx)
 }
 
 // handleRoot handles the root endpoint which shows node status
@@ -172,60 +241,13 @@ func (ws *WebServer) handleDebug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Collect debug information
-	nodeStatus := "online"
-	if ws.node.ConsensusReactor().WaitSync() {
-		nodeStatus = "syncing"
-	}
-	if !ws.node.IsListening() {
-		nodeStatus = "offline"
-	}
-
-	debugInfo := map[string]interface{}{
-		"node_id":     string(ws.node.NodeInfo().ID()),
-		"node_status": nodeStatus,
-		"p2p_address": ws.node.Config().P2P.ListenAddress,
-		"rpc_address": ws.node.Config().RPC.ListenAddress,
-		"uptime":      time.Since(ws.startTime).String(),
-	}
-
-	// Get Tendermint status
-	status, err := ws.cometBftRpcClient.Status(context.Background())
-	outboundPeers, inboundPeers, dialingPeers := ws.node.Switch().NumPeers()
-	debugInfo["num_peers_out"] = outboundPeers
-	debugInfo["num_peers_in"] = inboundPeers
-	debugInfo["num_peers_dialing"] = dialingPeers
-	if err != nil {
-		debugInfo["tendermint_error"] = err.Error()
-	} else {
-		debugInfo["node_status"] = "online"
-		debugInfo["latest_block_height"] = status.SyncInfo.LatestBlockHeight
-		debugInfo["latest_block_time"] = status.SyncInfo.LatestBlockTime
-		debugInfo["catching_up"] = status.SyncInfo.CatchingUp
-
-		peers := make([]map[string]interface{}, 0, len(ws.node.Switch().Peers().Copy()))
-		debugInfo["peers"] = peers
-	}
-
-	// Add ABCI info
-	abciInfo, err := ws.cometBftRpcClient.ABCIInfo(context.Background())
+	debugInfo, err := ws.api.Debug(r.Context())
 	if err != nil {
-		debugInfo["abci_error"] = err.Error()
-	} else {
-		debugInfo["abci_version"] = abciInfo.Response.Version
-		debugInfo["app_version"] = abciInfo.Response.AppVersion
-		debugInfo["last_block_height"] = abciInfo.Response.LastBlockHeight
-		debugInfo["last_block_app_hash"] = fmt.Sprintf("%X", abciInfo.Response.LastBlockAppHash)
-	}
-
-	// Return as JSON
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(debugInfo); err != nil {
-		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		JSONError(w, "Error collecting debug info: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	ws.writeResponse(w, r, http.StatusOK, debugInfo)
 }
 
 // handleTransactionStatus returns the status of a transaction
@@ -244,8 +266,7 @@ func (ws *WebServer) handleTransactionStatus(w http.ResponseWriter, r *http.Requ
 
 	txID := pathParts[2]
 
-	// Check transaction status
-	status, err := ws.checkTransactionStatus(txID)
+	status, err := ws.api.GetTxStatus(r.Context(), txID)
 	if err != nil {
 		JSONError(w, "Error checking transaction status: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -256,276 +277,127 @@ func (ws *WebServer) handleTransactionStatus(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Return status as JSON
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	err = encoder.Encode(status)
-	if err != nil {
-		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+	ws.writeResponse(w, r, http.StatusOK, status)
+}
+
+// handleTxProof returns a Merkle inclusion proof for a transaction, which a
+// light client can verify against a trusted validator set without trusting
+// this node's response.
+func (ws *WebServer) handleTxProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-}
 
-// handleSessionAPI handels API requests regarding supply chain session
-func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
-	requestID, err := generateRequestID()
-	if err != nil {
-		JSONError(w, "Internal Server Error", http.StatusInternalServerError)
-		ws.logger.Error("Failed to generate request ID", "err", err)
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) != 4 || pathParts[1] != "proof" || pathParts[2] != "tx" {
+		JSONError(w, "Invalid transaction ID", http.StatusBadRequest)
 		return
 	}
 
-	request, err := service_registry.ConvertHttpRequestToConsensusRequest(r, requestID)
+	txProof, err := ws.api.GetTxProof(r.Context(), pathParts[3])
 	if err != nil {
-		JSONError(w, "Failed to convert request: "+err.Error(), http.StatusUnprocessableEntity)
-		ws.logger.Error("Failed to convert HTTP request", "err", err)
+		JSONError(w, "Error building proof: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if txProof == nil {
+		JSONError(w, "Transaction not found", http.StatusNotFound)
 		return
 	}
-	// request.Body = strings.TrimSpace(request.Body)
 
-	response, err := request.GenerateResponse(ws.serviceRegistry)
-	if err != nil {
-		JSONError(w, "Failed to generate response: "+err.Error(), http.StatusUnprocessableEntity)
-		ws.logger.Error("Failed to generate response", "err", err)
+	ws.writeResponse(w, r, http.StatusOK, txProof)
+}
+
+// handleSessionProof returns a Merkle inclusion proof for the transaction
+// that committed a session.
+func (ws *WebServer) handleSessionProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	transaction := &service_registry.Transaction{
-		Request:      *request,
-		Response:     *response,
-		OriginNodeID: string(ws.node.ConsensusReactor().Switch.NodeInfo().ID()),
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) != 4 || pathParts[1] != "proof" || pathParts[2] != "session" {
+		JSONError(w, "Invalid session ID", http.StatusBadRequest)
+		return
 	}
 
-	// Simulate transaction consensus
-	consensusResponse, repoErr := ws.repository.RunConsensus(context.Background(), transaction)
-	if repoErr != nil {
-		if repoErr.Code == "CONSENSUS_ERROR" {
-			JSONError(w, "Consensus error occurred: "+repoErr.Detail, http.StatusInternalServerError)
-			return
-		}
-		JSONError(w, "An error occured: "+repoErr.Message, http.StatusInternalServerError)
-	}
-
-	// Add block height info to consensus transaction
-	blockHeight := consensusResponse.BlockHeight
-	transaction.BlockHeight = blockHeight
-
-	// TODO: If it is l1 commit, store the transaction record in DB
-	// if strings.Contains(request.Path, "commit-l1") {
-	// 	fmt.Println("Detected commit-l1 request")
-	// 	fmt.Println(consensusResponse)
-	// 	pathParts := strings.Split(r.URL.Path, "/")
-	// 	sessionID := pathParts[2]
-	// 	transactionRecord, repoErr := ws.repository.CreateTransactionRecord(consensusResponse.TxHash, sessionID, blockHeight, "committed")
-	// 	if repoErr != nil {
-	// 		repoErrBytes, _ := json.Marshal(repoErr)
-	// 		JSONError(w, string(repoErrBytes), http.StatusInternalServerError)
-	// 	}
-	// 	newResponseBodyBytes, _ := json.Marshal(transactionRecord)
-	// 	response.Body = string(newResponseBodyBytes)
-	// 	// Respond to client
-	// 	apiResponse := ClientResponse{
-	// 		StatusCode: response.StatusCode,
-	// 		Headers:    response.Headers,
-	// 		Body:       response.ParseBody(),
-	// 		Meta: TransactionStatus{
-	// 			TxID:        consensusResponse.TxHash,
-	// 			RequestID:   requestID,
-	// 			Status:      "confirmed",
-	// 			BlockHeight: blockHeight,
-	// 			// BlockHash:   hex.EncodeToString(consensusResponse.Hash),
-	// 			ConfirmTime: time.Now(),
-	// 			ResponseInfo: ResponseInfo{
-	// 				StatusCode:  response.StatusCode,
-	// 				ContentType: response.Headers["Content-Type"],
-	// 				BodyLength:  len(response.Body),
-	// 			},
-	// 		},
-	// 		NodeID: transaction.OriginNodeID,
-	// 	}
-	// 	for key, value := range response.Headers {
-	// 		w.Header().Set(key, value)
-	// 	}
-	// 	w.Header().Set("Content-Type", "application/json")
-	// 	w.WriteHeader(response.StatusCode)
-	// 	encoder := json.NewEncoder(w)
-	// 	encoder.SetIndent("", "  ")
-	// 	err = encoder.Encode(apiResponse)
-	// 	if err != nil {
-	// 		ws.logger.Error("Failed to encode client response", "err", err)
-	// 	}
-	// 	return
-	// }
-
-	block, err := ws.cometBftRpcClient.Block(context.Background(), &blockHeight)
+	sessionProof, err := ws.api.GetSessionProof(r.Context(), pathParts[3])
 	if err != nil {
-		JSONError(w, err.Error(), http.StatusInternalServerError)
+		JSONError(w, "Error building proof: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	if block.Block == nil {
-		ws.logger.Info("Web Server", "Block not found")
-	}
-
-	var blockTransactionsB64 []string
-	var blockTransactions []service_registry.Transaction
-	for _, tx := range block.Block.Txs {
-		// Convert the transaction bytes to base64
-		b64Tx := base64.StdEncoding.EncodeToString(tx)
-		blockTransactionsB64 = append(blockTransactionsB64, b64Tx)
-
-		// Try to parse the transaction
-		var parsedTx service_registry.Transaction
-		if err := json.Unmarshal(tx, &parsedTx); err == nil {
-			parsedTx.Response.BodyInterface = parsedTx.Response.ParseBody()
-			blockTransactions = append(blockTransactions, parsedTx)
-		} else {
-			// If parsing fails, you might want to log the error
-			ws.logger.Error("Failed to parse transaction", "err", err)
-		}
+	if sessionProof == nil {
+		JSONError(w, "Session has no committed transaction", http.StatusNotFound)
+		return
 	}
 
-	// Respond to client
-	apiResponse := ClientResponse{
-		StatusCode: response.StatusCode,
-		Headers:    response.Headers,
-		Body:       response.ParseBody(),
-		Meta: TransactionStatus{
-			TxID:        consensusResponse.TxHash,
-			RequestID:   requestID,
-			Status:      "confirmed",
-			BlockHeight: blockHeight,
-			// BlockHash:   hex.EncodeToString(consensusResponse.Hash),
-			ConfirmTime: time.Now(),
-			ResponseInfo: ResponseInfo{
-				StatusCode:  response.StatusCode,
-				ContentType: response.Headers["Content-Type"],
-				BodyLength:  len(response.Body),
-			},
-			BlockTxs: BlockTxsDetail{
-				BlockTransactions:    blockTransactions,
-				BlockTransactionsB64: blockTransactionsB64,
-			},
-		},
-		NodeID: transaction.OriginNodeID,
-	}
+	ws.writeResponse(w, r, http.StatusOK, sessionProof)
+}
 
-	for key, value := range response.Headers {
-		w.Header().Set(key, value)
+// handleSessionAPI handels API requests regarding supply chain session.
+// GET requests are treated as a status read, served from the status cache
+// when possible; everything else is a session operation submitted to
+// consensus.
+func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		ws.handleSessionStatus(w, r)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(response.StatusCode)
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	err = encoder.Encode(apiResponse)
+	requestID, err := generateRequestID()
 	if err != nil {
-		ws.logger.Error("Failed to encode client response", "err", err)
+		JSONError(w, "Internal Server Error", http.StatusInternalServerError)
+		ws.logger.Error("Failed to generate request ID", "err", err)
+		return
 	}
 
-	ws.logger.Info("=== Req-Res Pair Result ===",
-		transaction.Request.Path,
-		transaction.Request.Method,
-		transaction.Request.Body,
-		transaction.Response.StatusCode,
-		transaction.Response.Body,
-	)
-}
-
-// checkTransactionStatus checks the status of a transaction in the blockchain
-func (ws *WebServer) checkTransactionStatus(txID string) (*TransactionStatus, error) {
-	// Query the blockchain for the transaction
-	ws.logger.Info("WEBSERVER CHECK TRANSACTION STATUS 1")
-	query := fmt.Sprintf("tx.hash='%s'", txID)
-	res, err := ws.cometBftRpcClient.TxSearch(context.Background(), query, false, nil, nil, "")
+	request, err := service_registry.ConvertHttpRequestToConsensusRequest(r, requestID)
 	if err != nil {
-		return nil, fmt.Errorf("error searching for transaction: %w", err)
+		JSONError(w, "Failed to convert request: "+err.Error(), http.StatusUnprocessableEntity)
+		ws.logger.Error("Failed to convert HTTP request", "err", err)
+		return
 	}
 
-	consensusInfo := ConsensusInfo{
-		AgreementNodes: 0,               // We'll calculate this
-		NodeResponses:  make([]bool, 0), // Track individual node responses
+	commitOpts, err := commitOptionsFromRequest(r)
+	if err != nil {
+		JSONError(w, "Invalid commit level: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if len(res.Txs) == 0 {
-		return nil, nil // Transaction not found
+	apiResponse, err := ws.api.SubmitSessionOp(r.Context(), request, commitOpts, txFormatFromRequest(r))
+	if err != nil {
+		JSONError(w, "Failed to submit session operation: "+err.Error(), http.StatusInternalServerError)
+		ws.logger.Error("Failed to submit session operation", "err", err)
+		return
 	}
 
-	tx := res.Txs[0]
-
-	// Parse the transaction
-	var completeTx service_registry.Transaction
-	err = json.Unmarshal(tx.Tx, &completeTx)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing transaction: %w", err)
+	for key, value := range apiResponse.Headers {
+		w.Header().Set(key, value)
 	}
+	ws.writeResponse(w, r, apiResponse.StatusCode, apiResponse)
+}
 
-	// Extract events
-	status := "pending"
-	for _, event := range tx.TxResult.Events {
-		if event.Type == "tm.event.Vote" || event.Type == "vote" {
-			consensusInfo.AgreementNodes++
-			consensusInfo.NodeResponses = append(consensusInfo.NodeResponses, true)
-		}
-		if event.Type == "dews_tx" {
-			for _, attr := range event.Attributes {
-				if string(attr.Key) == "status" {
-					status = string(attr.Value)
-				}
-			}
-		}
+// handleSessionStatus serves GET /session/{sessionID}/... as a status read
+// for that session's committed transaction.
+func (ws *WebServer) handleSessionStatus(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 3 || pathParts[1] != "session" || pathParts[2] == "" {
+		JSONError(w, "Invalid session ID", http.StatusBadRequest)
+		return
 	}
 
-	block, err := ws.cometBftRpcClient.Block(context.Background(), &tx.Height)
+	status, err := ws.api.GetSessionStatus(r.Context(), pathParts[2])
 	if err != nil {
-		return nil, fmt.Errorf("error getting block: %w", err)
-	}
-	if block.Block == nil {
-		ws.logger.Info("Web Server", "Block not found")
-	}
-	ws.logger.Info("Web Server", "Block", block)
-
-	// Create response info
-	responseInfo := ResponseInfo{
-		StatusCode:  completeTx.Response.StatusCode,
-		ContentType: completeTx.Response.Headers["Content-Type"],
-		BodyLength:  len(completeTx.Response.Body),
-	}
-
-	var blockTransactionsB64 []string
-	var blockTransactions []service_registry.Transaction
-	for _, tx := range block.Block.Txs {
-		// Convert the transaction bytes to base64
-		b64Tx := base64.StdEncoding.EncodeToString(tx)
-		blockTransactionsB64 = append(blockTransactionsB64, b64Tx)
-
-		// Try to parse the transaction
-		var parsedTx service_registry.Transaction
-		if err := json.Unmarshal(tx, &parsedTx); err == nil {
-			parsedTx.Response.BodyInterface = parsedTx.Response.ParseBody()
-			blockTransactions = append(blockTransactions, parsedTx)
-		} else {
-			// If parsing fails, you might want to log the error
-			ws.logger.Error("Failed to parse transaction", "err", err)
-		}
+		JSONError(w, "Error checking session status: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
-
-	// Create transaction status
-	txStatus := &TransactionStatus{
-		TxID:         txID,
-		RequestID:    completeTx.Request.RequestID,
-		Status:       status,
-		BlockHeight:  tx.Height,
-		BlockHash:    fmt.Sprintf("%X", tx.Hash),
-		ConfirmTime:  time.Unix(0, time.Now().Unix()), // TODO
-		ResponseInfo: responseInfo,
-		BlockTxs: BlockTxsDetail{
-			BlockTransactions:    blockTransactions,
-			BlockTransactionsB64: blockTransactionsB64,
-		},
+	if status == nil {
+		JSONError(w, "Session not found", http.StatusNotFound)
+		return
 	}
 
-	return txStatus, nil
+	ws.writeResponse(w, r, http.StatusOK, status)
 }
 
 // handleBlockInfo returns block information for a given height
@@ -542,67 +414,50 @@ func (ws *WebServer) handleBlockInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	heightStr := pathParts[2]
-	height, err := strconv.ParseInt(heightStr, 10, 64)
+	height, err := strconv.ParseInt(pathParts[2], 10, 64)
 	if err != nil {
 		JSONError(w, "Invalid block height format", http.StatusBadRequest)
 		return
 	}
 
-	// Get block info from the blockchain
-	block, err := ws.cometBftRpcClient.Block(context.Background(), &height)
+	blockInfo, err := ws.api.GetBlock(r.Context(), height)
 	if err != nil {
 		JSONError(w, "Error fetching block: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	if block.Block == nil {
+	if blockInfo == nil {
 		JSONError(w, "Block not found", http.StatusNotFound)
 		return
 	}
 
-	// Parse transactions in the block
-	var transactions []service_registry.Transaction
-	var transactionsB64 []string
-	for _, tx := range block.Block.Txs {
-		// Add base64 version
-		b64Tx := base64.StdEncoding.EncodeToString(tx)
-		transactionsB64 = append(transactionsB64, b64Tx)
+	ws.writeResponse(w, r, http.StatusOK, blockInfo)
+}
 
-		// Parse and add structured version if possible
-		var parsedTx service_registry.Transaction
-		if err := json.Unmarshal(tx, &parsedTx); err == nil {
-			parsedTx.Response.BodyInterface = parsedTx.Response.ParseBody()
-			transactions = append(transactions, parsedTx)
-		}
-	}
+// txFormatFromRequest resolves the wire format a submitted transaction
+// should be broadcast as from the request's Content-Type header, letting a
+// client submit CBOR- or protobuf-encoded transactions alongside the
+// default JSON by setting it accordingly. An empty value keeps the
+// original unprefixed JSON encoding.
+func txFormatFromRequest(r *http.Request) string {
+	return r.Header.Get("Content-Type")
+}
 
-	// Create block info response
-	blockInfo := struct {
-		Height          int64                          `json:"height"`
-		Hash            string                         `json:"hash"`
-		Time            time.Time                      `json:"time"`
-		NumTxs          int                            `json:"num_txs"`
-		Transactions    []service_registry.Transaction `json:"transactions"`
-		TransactionsB64 []string                       `json:"transactions_b64"`
-		ProposerAddress string                         `json:"proposer_address"`
-	}{
-		Height:          block.Block.Height,
-		Hash:            fmt.Sprintf("%X", block.BlockID.Hash),
-		Time:            block.Block.Time,
-		NumTxs:          len(block.Block.Txs),
-		Transactions:    transactions,
-		TransactionsB64: transactionsB64,
-		ProposerAddress: fmt.Sprintf("%X", block.Block.ProposerAddress),
+// commitOptionsFromRequest reads the commit level from the `commit` query
+// parameter, falling back to the X-Commit-Level header, e.g.
+// "?commit=confirmed:3" or "X-Commit-Level: broadcast".
+func commitOptionsFromRequest(r *http.Request) (api.CommitOptions, error) {
+	raw := r.URL.Query().Get("commit")
+	if raw == "" {
+		raw = r.Header.Get("X-Commit-Level")
 	}
+	return api.ParseCommitOptions(raw)
+}
 
-	// Return as JSON
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(blockInfo); err != nil {
+// writeResponse encodes v with the codec negotiated for r (via ?format= or
+// Accept, falling back to JSON) and writes it with the given status code.
+func (ws *WebServer) writeResponse(w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) {
+	if err := codec.Write(w, r, ws.codecs, statusCode, v); err != nil {
 		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
-		return
 	}
 }
 
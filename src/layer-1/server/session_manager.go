@@ -0,0 +1,217 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultMaxSessions and DefaultSessionTimeout are used when the web server
+// is not configured with explicit limits.
+const (
+	DefaultMaxSessions    = 256
+	DefaultSessionTimeout = 10 * time.Minute
+	notificationQueueSize = 64
+)
+
+// SubscriptionFilter narrows the notifications a client session receives.
+// A zero-value filter matches everything of the given Topic.
+type SubscriptionFilter struct {
+	Topic      string // "blocks", "tx", or "session"
+	SessionID  string // only for Topic == "session"
+	TxHash     string // only for Topic == "tx"
+	OriginNode string // optional, matches Transaction.OriginNodeID
+	PathPrefix string // optional, matches Request.Path
+}
+
+// ClientSession represents one connected WebSocket client and the set of
+// topics it has subscribed to.
+type ClientSession struct {
+	id            string
+	conn          *websocket.Conn
+	send          chan interface{}
+	subscriptions map[string]SubscriptionFilter // subscription ID -> filter
+	createdAt     time.Time
+	lastActivity  time.Time
+	mu            sync.Mutex
+	closed        bool
+	closeOnce     sync.Once
+}
+
+// touch records activity so the session is not reaped by the idle timeout.
+func (cs *ClientSession) touch() {
+	cs.mu.Lock()
+	cs.lastActivity = time.Now()
+	cs.mu.Unlock()
+}
+
+func (cs *ClientSession) idleSince() time.Duration {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return time.Since(cs.lastActivity)
+}
+
+// enqueue attempts a non-blocking send; if the client's queue is full it is
+// considered a slow consumer and gets dropped. It holds cs.mu across the
+// send so a concurrent close can't close cs.send out from under it - close
+// also takes cs.mu before closing the channel, so the two can never
+// interleave.
+func (cs *ClientSession) enqueue(notification interface{}) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return false
+	}
+
+	select {
+	case cs.send <- notification:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cs *ClientSession) close() {
+	cs.closeOnce.Do(func() {
+		cs.mu.Lock()
+		cs.closed = true
+		cs.mu.Unlock()
+		close(cs.send)
+		cs.conn.Close()
+	})
+}
+
+// SessionManager tracks every connected WebSocket client and fans out
+// notifications to the ones whose filters match.
+type SessionManager struct {
+	mu             sync.RWMutex
+	sessions       map[string]*ClientSession
+	maxSessions    int
+	sessionTimeout time.Duration
+	logger         cmtlog.Logger
+}
+
+// NewSessionManager creates a session manager bounded by maxSessions; a
+// background goroutine evicts sessions idle for longer than sessionTimeout.
+func NewSessionManager(maxSessions int, sessionTimeout time.Duration, logger cmtlog.Logger) *SessionManager {
+	if maxSessions <= 0 {
+		maxSessions = DefaultMaxSessions
+	}
+	if sessionTimeout <= 0 {
+		sessionTimeout = DefaultSessionTimeout
+	}
+
+	sm := &SessionManager{
+		sessions:       make(map[string]*ClientSession),
+		maxSessions:    maxSessions,
+		sessionTimeout: sessionTimeout,
+		logger:         logger,
+	}
+
+	go sm.reapLoop()
+
+	return sm
+}
+
+// Register adds a new client session, rejecting it if the manager is at
+// capacity.
+func (sm *SessionManager) Register(id string, conn *websocket.Conn) (*ClientSession, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if len(sm.sessions) >= sm.maxSessions {
+		return nil, false
+	}
+
+	cs := &ClientSession{
+		id:            id,
+		conn:          conn,
+		send:          make(chan interface{}, notificationQueueSize),
+		subscriptions: make(map[string]SubscriptionFilter),
+		createdAt:     time.Now(),
+		lastActivity:  time.Now(),
+	}
+	sm.sessions[id] = cs
+	return cs, true
+}
+
+// Unregister removes and closes a client session.
+func (sm *SessionManager) Unregister(id string) {
+	sm.mu.Lock()
+	cs, ok := sm.sessions[id]
+	if ok {
+		delete(sm.sessions, id)
+	}
+	sm.mu.Unlock()
+
+	if ok {
+		cs.close()
+	}
+}
+
+// Count returns the number of currently connected sessions.
+func (sm *SessionManager) Count() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions)
+}
+
+// AtCapacity reports whether the manager is already at maxSessions, so
+// callers can reject a new subscribe request with a JSON-RPC error instead
+// of silently accepting it.
+func (sm *SessionManager) AtCapacity() bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions) >= sm.maxSessions
+}
+
+// Broadcast fans a notification out to every session with a matching
+// subscription, dropping any session whose queue is full.
+func (sm *SessionManager) Broadcast(topic string, matches func(SubscriptionFilter) bool, notification interface{}) {
+	sm.mu.RLock()
+	recipients := make([]*ClientSession, 0, len(sm.sessions))
+	for _, cs := range sm.sessions {
+		cs.mu.Lock()
+		for _, filter := range cs.subscriptions {
+			if filter.Topic == topic && matches(filter) {
+				recipients = append(recipients, cs)
+				break
+			}
+		}
+		cs.mu.Unlock()
+	}
+	sm.mu.RUnlock()
+
+	for _, cs := range recipients {
+		if !cs.enqueue(notification) {
+			sm.logger.Info("dropping slow WebSocket consumer", "session_id", cs.id)
+			sm.Unregister(cs.id)
+		}
+	}
+}
+
+// reapLoop periodically disconnects sessions that have been idle for longer
+// than sessionTimeout.
+func (sm *SessionManager) reapLoop() {
+	ticker := time.NewTicker(sm.sessionTimeout / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sm.mu.RLock()
+		var stale []string
+		for id, cs := range sm.sessions {
+			if cs.idleSince() > sm.sessionTimeout {
+				stale = append(stale, id)
+			}
+		}
+		sm.mu.RUnlock()
+
+		for _, id := range stale {
+			sm.logger.Info("evicting idle WebSocket session", "session_id", id)
+			sm.Unregister(id)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cmtcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/gorilla/websocket"
+)
+
+// upgrader relaxes the origin check since subscribers are trusted
+// dashboards/backoffice tools rather than arbitrary browsers.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// jsonRPCRequest is a minimal JSON-RPC 2.0 request as sent by subscribers.
+type jsonRPCRequest struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// jsonRPCNotification is a JSON-RPC 2.0 notification pushed to subscribers.
+type jsonRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// jsonRPCResponse acknowledges a subscribe/unsubscribe call.
+type jsonRPCResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type subscribeParams struct {
+	Topic      string `json:"topic"`       // "blocks", "tx", "session"
+	SessionID  string `json:"session_id"`  // required for topic == "session"
+	TxHash     string `json:"tx_hash"`     // required for topic == "tx"
+	OriginNode string `json:"origin_node"` // optional filter
+	PathPrefix string `json:"path_prefix"` // optional filter
+}
+
+type unsubscribeParams struct {
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type txSubscribeParams struct {
+	TxHash string `json:"tx_hash"`
+}
+
+type sessionSubscribeParams struct {
+	SessionID string `json:"session_id"`
+}
+
+// handleWebSocket upgrades the connection and services subscribe/unsubscribe
+// requests for the lifetime of the socket.
+func (ws *WebServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		ws.logger.Error("WebSocket upgrade failed", "err", err)
+		return
+	}
+
+	requestID, err := generateRequestID()
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	cs, ok := ws.sessionManager.Register(requestID, conn)
+	if !ok {
+		conn.WriteJSON(jsonRPCResponse{JSONRPC: "2.0", Error: "too many connected sessions"})
+		conn.Close()
+		return
+	}
+	ws.logger.Info("WebSocket session connected", "session_id", cs.id)
+
+	go ws.writePump(cs)
+	ws.readPump(cs)
+}
+
+// writePump drains the session's notification channel onto the socket.
+// It is the only goroutine ever allowed to call cs.conn.WriteJSON, since
+// gorilla/websocket forbids concurrent writers on one connection - acks
+// and errors from readPump's handlers go through reply/cs.send instead of
+// writing the conn directly, for the same reason.
+func (ws *WebServer) writePump(cs *ClientSession) {
+	for notification := range cs.send {
+		if err := cs.conn.WriteJSON(notification); err != nil {
+			ws.logger.Info("WebSocket write failed, disconnecting", "session_id", cs.id, "err", err)
+			ws.sessionManager.Unregister(cs.id)
+			return
+		}
+	}
+}
+
+// reply enqueues resp onto cs.send so writePump is the one that actually
+// writes it to the connection, rather than writing cs.conn directly from
+// whatever goroutine is handling the client's request.
+func (ws *WebServer) reply(cs *ClientSession, resp interface{}) {
+	if !cs.enqueue(resp) {
+		ws.logger.Info("WebSocket reply dropped, slow consumer", "session_id", cs.id)
+	}
+}
+
+// readPump handles subscribe/unsubscribe requests from the client until the
+// connection closes.
+func (ws *WebServer) readPump(cs *ClientSession) {
+	defer ws.sessionManager.Unregister(cs.id)
+
+	for {
+		var req jsonRPCRequest
+		if err := cs.conn.ReadJSON(&req); err != nil {
+			return
+		}
+		cs.touch()
+
+		switch req.Method {
+		case "subscribe":
+			ws.handleSubscribe(cs, req)
+		case "unsubscribe", "tx.unsubscribe", "session.unsubscribe", "block.unsubscribe":
+			ws.handleUnsubscribe(cs, req)
+		case "tx.subscribe":
+			ws.handleTxSubscribe(cs, req)
+		case "session.subscribe":
+			ws.handleSessionSubscribe(cs, req)
+		case "block.subscribe":
+			ws.handleBlockSubscribe(cs, req)
+		default:
+			ws.reply(cs, jsonRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   fmt.Sprintf("unknown method: %s", req.Method),
+			})
+		}
+	}
+}
+
+func (ws *WebServer) handleSubscribe(cs *ClientSession, req jsonRPCRequest) {
+	var params subscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params"})
+		return
+	}
+
+	switch params.Topic {
+	case "blocks", "tx", "session":
+	default:
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "unknown topic"})
+		return
+	}
+
+	filter := SubscriptionFilter{
+		Topic:      params.Topic,
+		SessionID:  params.SessionID,
+		TxHash:     params.TxHash,
+		OriginNode: params.OriginNode,
+		PathPrefix: params.PathPrefix,
+	}
+	ws.subscribe(cs, req, filter, ws.currentStatus(params.Topic, params.SessionID, params.TxHash))
+}
+
+// handleTxSubscribe is the tx.subscribe method: it subscribes the client to
+// updates for one transaction hash and, like an Electrum server's
+// blockchain.scripthash.subscribe, immediately returns the current status
+// alongside the subscription ID so the client doesn't have to poll
+// /status/{txID} before the first update arrives.
+func (ws *WebServer) handleTxSubscribe(cs *ClientSession, req jsonRPCRequest) {
+	var params txSubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.TxHash == "" {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params: tx_hash required"})
+		return
+	}
+
+	filter := SubscriptionFilter{Topic: "tx", TxHash: params.TxHash}
+	ws.subscribe(cs, req, filter, ws.currentStatus("tx", "", params.TxHash))
+}
+
+// handleSessionSubscribe is the session.subscribe method: it subscribes the
+// client to lifecycle updates for one session ID and returns its current
+// status immediately.
+func (ws *WebServer) handleSessionSubscribe(cs *ClientSession, req jsonRPCRequest) {
+	var params sessionSubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.SessionID == "" {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params: session_id required"})
+		return
+	}
+
+	filter := SubscriptionFilter{Topic: "session", SessionID: params.SessionID}
+	ws.subscribe(cs, req, filter, ws.currentStatus("session", params.SessionID, ""))
+}
+
+// handleBlockSubscribe is the block.subscribe method: it subscribes the
+// client to every new block and returns the current chain tip immediately.
+func (ws *WebServer) handleBlockSubscribe(cs *ClientSession, req jsonRPCRequest) {
+	filter := SubscriptionFilter{Topic: "blocks"}
+	ws.subscribe(cs, req, filter, ws.currentStatus("blocks", "", ""))
+}
+
+// subscribe enforces the session cap, registers filter under a fresh
+// subscription ID, and acknowledges req with that ID plus status (the
+// topic's current value, computed by the caller) so the client has a
+// baseline before the first streamed update arrives.
+func (ws *WebServer) subscribe(cs *ClientSession, req jsonRPCRequest, filter SubscriptionFilter, status interface{}) {
+	if ws.sessionManager.AtCapacity() {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "session limit reached"})
+		return
+	}
+
+	subID, err := generateRequestID()
+	if err != nil {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "failed to create subscription"})
+		return
+	}
+
+	cs.mu.Lock()
+	cs.subscriptions[subID] = filter
+	cs.mu.Unlock()
+
+	ws.reply(cs, jsonRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"subscription_id": subID,
+			"status":          status,
+		},
+	})
+}
+
+// currentStatus fetches the value a new subscription on topic should report
+// immediately: a transaction or session's current TransactionStatus, or the
+// latest block, so clients get an electrum-style status before streaming
+// begins. It returns nil (rather than an error) on any lookup failure,
+// since an absent baseline just means the client waits for the first
+// streamed update instead.
+func (ws *WebServer) currentStatus(topic, sessionID, txHash string) interface{} {
+	ctx := context.Background()
+
+	switch topic {
+	case "tx":
+		if txHash == "" {
+			return nil
+		}
+		status, err := ws.api.GetTxStatus(ctx, txHash)
+		if err != nil {
+			return nil
+		}
+		return status
+	case "session":
+		if sessionID == "" {
+			return nil
+		}
+		status, err := ws.api.GetSessionStatus(ctx, sessionID)
+		if err != nil {
+			return nil
+		}
+		return status
+	case "blocks":
+		chainStatus, err := ws.engine.Status(ctx)
+		if err != nil {
+			return nil
+		}
+		blockInfo, err := ws.api.GetBlock(ctx, chainStatus.SyncInfo.LatestBlockHeight)
+		if err != nil {
+			return nil
+		}
+		return blockInfo
+	default:
+		return nil
+	}
+}
+
+func (ws *WebServer) handleUnsubscribe(cs *ClientSession, req jsonRPCRequest) {
+	var params unsubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: "invalid params"})
+		return
+	}
+
+	cs.mu.Lock()
+	delete(cs.subscriptions, params.SubscriptionID)
+	cs.mu.Unlock()
+
+	ws.reply(cs, jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: "ok"})
+}
+
+// startEventNotifier subscribes to CometBFT's event bus and fans NewBlock
+// and Tx events out to matching WebSocket sessions; Vote events are not
+// subscribed to. It also exposes
+// NotifySessionUpdate for repository-side session lifecycle hooks.
+func (ws *WebServer) startEventNotifier(ctx context.Context) error {
+	const subscriber = "ws-session-manager"
+
+	blockEvents, err := ws.engine.Subscribe(ctx, subscriber, cmttypes.EventQueryNewBlock.String())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to NewBlock events: %w", err)
+	}
+
+	txEvents, err := ws.engine.Subscribe(ctx, subscriber+"-tx", cmttypes.EventQueryTx.String())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to Tx events: %w", err)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-blockEvents:
+				ws.handleBlockEvent(event)
+			case event := <-txEvents:
+				ws.handleTxEvent(event)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (ws *WebServer) handleBlockEvent(event cmtcoretypes.ResultEvent) {
+	newBlock, ok := event.Data.(cmttypes.EventDataNewBlock)
+	if !ok {
+		return
+	}
+
+	txs := make([][]byte, len(newBlock.Block.Txs))
+	for i, tx := range newBlock.Block.Txs {
+		txs[i] = []byte(tx)
+	}
+	ws.api.IngestBlock(
+		newBlock.Block.Height,
+		fmt.Sprintf("%X", newBlock.Block.Hash()),
+		fmt.Sprintf("%X", newBlock.Block.LastBlockID.Hash),
+		txs,
+	)
+
+	notification := jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "blocks",
+		Params: map[string]interface{}{
+			"height": newBlock.Block.Height,
+			"hash":   fmt.Sprintf("%X", newBlock.Block.Hash()),
+			"time":   newBlock.Block.Time,
+			"num_tx": len(newBlock.Block.Txs),
+		},
+	}
+
+	ws.sessionManager.Broadcast("blocks", func(SubscriptionFilter) bool { return true }, notification)
+}
+
+func (ws *WebServer) handleTxEvent(event cmtcoretypes.ResultEvent) {
+	txEvent, ok := event.Data.(cmttypes.EventDataTx)
+	if !ok {
+		return
+	}
+
+	txHash := fmt.Sprintf("%X", cmttypes.Tx(txEvent.Tx).Hash())
+	notification := jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "tx",
+		Params: map[string]interface{}{
+			"tx_hash": txHash,
+			"height":  txEvent.Height,
+			"code":    txEvent.Result.Code,
+		},
+	}
+
+	ws.sessionManager.Broadcast("tx", func(filter SubscriptionFilter) bool {
+		return filter.TxHash == "" || filter.TxHash == txHash
+	}, notification)
+}
+
+// NotifySessionUpdate pushes a session lifecycle event (e.g. "validated",
+// "qc_passed", "committed") to subscribers watching that session ID.
+// WebServer does not call it itself and does not register it with the
+// repository, so session updates reach subscribers only when outside code
+// calls it.
+func (ws *WebServer) NotifySessionUpdate(sessionID, status string) {
+	notification := jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "session",
+		Params: map[string]interface{}{
+			"session_id": sessionID,
+			"status":     status,
+		},
+	}
+
+	ws.sessionManager.Broadcast("session", func(filter SubscriptionFilter) bool {
+		return filter.SessionID == "" || filter.SessionID == sessionID
+	}, notification)
+}
@@ -0,0 +1,151 @@
+// Code generated by oapi-codegen from ../../../api/layer1-session.yaml.
+// DO NOT EDIT BY HAND - this file exists as hand-transcribed output because
+// this checkout has no go.mod/module cache for `go generate` to run
+// oapi-codegen against. oapi-codegen.yaml generates models only, so a real
+// run emits the request/response types but not ServerInterface.
+package srvreg
+
+import (
+	"context"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/proof"
+)
+
+// CreateSessionRequest is POST /session/start's request body.
+type CreateSessionRequest struct {
+	OperatorID string `json:"operator_id"`
+}
+
+// CreateSessionResponse is POST /session/start's 201 response body.
+type CreateSessionResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+// ScanPackageRequest is POST /session/{id}/scan's request body.
+type ScanPackageRequest struct {
+	PackageID  string `json:"package_id"`
+	OperatorID string `json:"operator_id"`
+}
+
+// ScanPackageItem is one entry of ScanPackageResponse's ExpectedContents.
+type ScanPackageItem struct {
+	ItemID string `json:"item_id"`
+	Item   string `json:"item"`
+	Qty    int    `json:"qty"`
+}
+
+// ScanPackageResponse is POST /session/{id}/scan's 200 response body.
+type ScanPackageResponse struct {
+	Status            int               `json:"status"`
+	Source            string            `json:"source"`
+	PackageID         string            `json:"package_id"`
+	ExpectedContents  []ScanPackageItem `json:"expected_contents"`
+	SupplierSignature string            `json:"supplier_signature"`
+	NextStep          string            `json:"next_step"`
+}
+
+// ValidatePackageRequest is POST /session/{id}/validate's request body.
+type ValidatePackageRequest struct {
+	Signature  string `json:"signature"`
+	PackageID  string `json:"package_id"`
+	OperatorID string `json:"operator_id"`
+}
+
+// ValidatePackageResponse is POST /session/{id}/validate's 202 response
+// body.
+type ValidatePackageResponse struct {
+	Message   string `json:"message"`
+	PackageID string `json:"package_id"`
+	Supplier  string `json:"supplier"`
+	SessionID string `json:"session_id"`
+}
+
+// QualityCheckIssue is one entry of QualityCheckRequest's Issues.
+type QualityCheckIssue struct {
+	Code        string `json:"code"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	PhotoURL    string `json:"photo_url"`
+}
+
+// QualityCheckRequest is POST /session/{id}/qc's request body.
+type QualityCheckRequest struct {
+	Passed bool                `json:"passed"`
+	Issues []QualityCheckIssue `json:"issues"`
+}
+
+// QualityCheckResponse is POST /session/{id}/qc's 202 response body.
+type QualityCheckResponse struct {
+	Message    string `json:"message"`
+	PackageID  string `json:"package_id"`
+	QCRecordID string `json:"qc_record_id"`
+	OperatorID string `json:"operator_id"`
+}
+
+// LabelPackageRequest is POST /session/{id}/label's request body.
+type LabelPackageRequest struct {
+	Label       string `json:"label"`
+	Destination string `json:"destination"`
+	Priority    string `json:"priority"`
+	CourierID   string `json:"courier_id"`
+}
+
+// LabelPackageResponse is POST /session/{id}/label's 202 response body.
+type LabelPackageResponse struct {
+	LabelID string `json:"label_id"`
+}
+
+// CommitSessionRequest is POST /commit/{id}'s request body.
+type CommitSessionRequest struct {
+	OperatorID string `json:"operator_id"`
+}
+
+// CommitSessionResponse is POST /commit/{id}'s 202 response body. Tx holds
+// whatever repository.CommitSession returned - it's marshaled as-is, so
+// this struct's shape on the wire is {"tx": <transaction>, "proof": ...}. Proof is
+// the BFT Merkle inclusion proof for that same transaction, omitted if it
+// couldn't be built in time for the response - a client can always fetch
+// it afterward from GET /sessions/{id}/proof instead.
+type CommitSessionResponse struct {
+	Tx    interface{}             `json:"tx"`
+	Proof *proof.TxInclusionProof `json:"proof,omitempty"`
+}
+
+// ReceiveCommitRequest is POST /replica/commit/{id}'s request body - an L2
+// node's commit replayed onto this L1 replica during BFT replication.
+// Proof, if present, lets this replica verify the commit actually landed
+// in a quorum-signed block via VerifyInclusionProof before replaying it,
+// instead of trusting the submitting L2 node's say-so.
+type ReceiveCommitRequest struct {
+	OperatorID string                  `json:"operator_id"`
+	MerkleRoot string                  `json:"merkle_root"`
+	Timestamp  string                  `json:"timestamp"`
+	Proof      *proof.TxInclusionProof `json:"proof,omitempty"`
+}
+
+// ReceiveCommitResponse echoes the replicated commit back to the caller
+// once ReplicateCommitFromL2 has applied it.
+type ReceiveCommitResponse struct {
+	OperatorID string `json:"operator_id"`
+	MerkleRoot string `json:"merkle_root"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// ServerInterface is the contract ServiceRegistry implements, one method
+// per operation in api/layer1-session.yaml - adding an operation to the
+// spec without implementing it here is a compile error, instead of a
+// silent 404 discovered at runtime.
+type ServerInterface interface {
+	CreateSessionHandler(ctx context.Context, req *Request) (*Response, error)
+	ScanPackageHandler(ctx context.Context, req *Request) (*Response, error)
+	ValidatePackageHandler(ctx context.Context, req *Request) (*Response, error)
+	QualityCheckHandler(ctx context.Context, req *Request) (*Response, error)
+	LabelPackageHandler(ctx context.Context, req *Request) (*Response, error)
+	CommitSessionHandler(ctx context.Context, req *Request) (*Response, error)
+	ReceiveCommitHandler(ctx context.Context, req *Request) (*Response, error)
+	GetSessionProofHandler(ctx context.Context, req *Request) (*Response, error)
+	GetSessionInclusionProofHandler(ctx context.Context, req *Request) (*Response, error)
+}
+
+var _ ServerInterface = (*ServiceRegistry)(nil)
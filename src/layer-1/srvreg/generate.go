@@ -0,0 +1,9 @@
+package srvreg
+
+// go generate regenerates api.gen.go from ../../../api/layer1-session.yaml
+// using oapi-codegen (models only, as oapi-codegen.yaml configures, so the
+// ServerInterface in api.gen.go is not emitted by it; this transport
+// doesn't run net/http directly, so the chi/echo router generators don't
+// apply - see adapter usage in handlers.go).
+//
+//go:generate go run github.com/deepmap/oapi-codegen/v2/cmd/oapi-codegen --config=oapi-codegen.yaml ../../../api/layer1-session.yaml
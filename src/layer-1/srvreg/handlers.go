@@ -1,467 +1,408 @@
 package srvreg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/ahmadzakiakmal/thesis/src/layer-1/repository"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
 )
 
 var defaultHeaders = map[string]string{"Content-Type": "application/json"}
 
-type startSessionHandlerBody struct {
-	OperatorID string `json:"operator_id"`
-}
-
-func (sr *ServiceRegistry) CreateSessionHandler(req *Request) (*Response, error) {
+// CreateSessionHandler implements ServerInterface's createSession
+// operation (POST /session/start).
+func (sr *ServiceRegistry) CreateSessionHandler(ctx context.Context, req *Request) (*Response, error) {
 	sessionID := fmt.Sprintf("SESSION-%s", req.RequestID)
-	var body startSessionHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		sr.logger.Info("Failed to parse body", "error", err.Error())
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"Failed to create session: %s"}`, err.Error()),
-		}, err
-	}
-
-	operatorID := body.OperatorID
-	if operatorID == "" {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"operator ID is required"}`,
-		}, err
-	}
-
-	session, dbErr := sr.repository.CreateSession(sessionID, operatorID)
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	var body CreateSessionRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		log.Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, fmt.Sprintf("Failed to create session: %s", err.Error())), err
+	}
+
+	if body.OperatorID == "" {
+		return writeError(http.StatusBadRequest, "operator ID is required"), nil
+	}
+
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	session, dbErr := sr.repository.CreateSession(ctx, sessionID, body.OperatorID, idempotencyKey)
 	if dbErr != nil {
 		switch dbErr.Code {
 		case repository.PgErrForeignKeyViolation: // PostgreSQL foreign key violation
-			return &Response{
-				StatusCode: http.StatusBadRequest,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("foreign key violation: %s", dbErr.Message)
+			return writeError(http.StatusBadRequest, dbErr.Detail), fmt.Errorf("foreign key violation: %s", dbErr.Message)
 		case repository.PgErrUniqueViolation: // PostgreSQL unique violation
-			return &Response{
-				StatusCode: http.StatusConflict,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("unique violation: %s", dbErr.Message)
+			return writeError(http.StatusConflict, dbErr.Detail), fmt.Errorf("unique violation: %s", dbErr.Message)
+		case "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Detail), fmt.Errorf("idempotency conflict: %s", dbErr.Message)
 		default:
-			return &Response{
-				StatusCode: http.StatusInternalServerError,
-				Headers:    defaultHeaders,
-				Body:       `{"error":"Internal server error"}`,
-			}, nil
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
 		}
 	}
 
-	return &Response{
-		StatusCode: http.StatusCreated, // or http.StatusOK
-		Headers:    defaultHeaders,
-		Body:       fmt.Sprintf(`{"message":"Session generated","id":"%s"}`, session.ID),
-	}, nil
-}
-
-type scanPackageHandlerBody struct {
-	PackageID string `json:"package_id"`
+	log.Info("session created", "operator_id", body.OperatorID)
+	return writeJSON(http.StatusCreated, CreateSessionResponse{Message: "Session generated", ID: session.ID})
 }
 
-func (sr *ServiceRegistry) ScanPackageHandler(req *Request) (*Response, error) {
-	var body scanPackageHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		sr.logger.Info("Failed to parse body", "error", err.Error())
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"Invalid body format: %s"}`, err.Error()),
-		}, fmt.Errorf("invalid body format")
+// ScanPackageHandler implements ServerInterface's scanPackage operation
+// (POST /session/{id}/scan).
+func (sr *ServiceRegistry) ScanPackageHandler(ctx context.Context, req *Request) (*Response, error) {
+	var body ScanPackageRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		logging.FromContext(ctx).Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, fmt.Sprintf("Invalid body format: %s", err.Error())), fmt.Errorf("invalid body format")
 	}
 
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
 	}
-	sessionID := pathParts[2]
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
 
 	if body.PackageID == "" {
 		return nil, fmt.Errorf("package_id is required")
 	}
 
-	pkg, dbErr := sr.repository.ScanPackage(sessionID, body.PackageID)
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	pkg, dbErr := sr.repository.ScanPackage(ctx, sessionID, body.PackageID, body.OperatorID, idempotencyKey)
 	if dbErr != nil {
+		log.Info("scan package failed", "package_id", body.PackageID, "error", dbErr.Message)
 		switch dbErr.Code {
 		case "ENTITY_NOT_FOUND":
-			return &Response{
-				StatusCode: http.StatusNotFound,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Message),
-			}, fmt.Errorf("entity not found: %s", dbErr.Message)
+			return writeError(http.StatusNotFound, dbErr.Message), fmt.Errorf("entity not found: %s", dbErr.Message)
+		case "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Message), fmt.Errorf("idempotency conflict: %s", dbErr.Message)
 		default:
-			return &Response{
-				StatusCode: http.StatusInternalServerError,
-				Headers:    defaultHeaders,
-				Body:       `{"error":"Internal server error"}`,
-			}, nil
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
 		}
 	}
 
-	// Format the items for the response
-	var expectedContents []map[string]interface{}
-	for _, item := range pkg.Items {
-		expectedContents = append(expectedContents, map[string]interface{}{
-			"item_id": item.ID,
-			"item":    item.Description,
-			"qty":     item.Quantity,
-		})
-	}
-
-	// Convert to JSON
-	contentsJSON, err := json.Marshal(expectedContents)
-	if err != nil {
-		return &Response{
-			StatusCode: http.StatusInternalServerError,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Failed to process item data"}`,
-		}, nil
+	expectedContents := make([]ScanPackageItem, len(pkg.Items))
+	for i, item := range pkg.Items {
+		expectedContents[i] = ScanPackageItem{ItemID: item.ID, Item: item.Description, Qty: item.Quantity}
 	}
 
-	// Get supplier name
 	supplierName := "Unknown Supplier"
 	if pkg.Supplier != nil {
 		supplierName = pkg.Supplier.Name
 	}
 
-	// Build the response
-	response := fmt.Sprintf(`{
-			"status": 200,
-			"source": "%s",
-			"package_id": "%s",
-			"expected_contents": %s,
-			"supplier_signature": "%s",
-			"next_step": "validate"
-	}`, supplierName, pkg.ID, string(contentsJSON), pkg.Signature)
-
-	// Remove whitespace for valid JSON
-	response = strings.Replace(strings.Replace(strings.Replace(response, "\n", "", -1), "    ", "", -1), "\t", "", -1)
-
-	return &Response{
-		StatusCode: http.StatusOK,
-		Headers:    defaultHeaders,
-		Body:       response,
-	}, nil
-}
-
-type validatePackageHandlerBody struct {
-	Signature string `json:"signature"`
-	PackageID string `json:"package_id"`
+	return writeJSON(http.StatusOK, ScanPackageResponse{
+		Status:            http.StatusOK,
+		Source:            supplierName,
+		PackageID:         pkg.ID,
+		ExpectedContents:  expectedContents,
+		SupplierSignature: pkg.Signature,
+		NextStep:          "validate",
+	})
 }
 
-func (sr *ServiceRegistry) ValidatePackageHandler(req *Request) (*Response, error) {
-	var body validatePackageHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		sr.logger.Info("Failed to parse body", "error", err.Error())
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"Invalid body format: %s"}`, err.Error()),
-		}, fmt.Errorf("invalid body format")
+// ValidatePackageHandler implements ServerInterface's validatePackage
+// operation (POST /session/{id}/validate).
+func (sr *ServiceRegistry) ValidatePackageHandler(ctx context.Context, req *Request) (*Response, error) {
+	var body ValidatePackageRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		logging.FromContext(ctx).Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, fmt.Sprintf("Invalid body format: %s", err.Error())), fmt.Errorf("invalid body format")
 	}
 	if body.Signature == "" {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"signature is required"}`,
-		}, err
+		return writeError(http.StatusBadRequest, "signature is required"), nil
 	}
 	if body.PackageID == "" {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"package_id is required"}`,
-		}, err
+		return writeError(http.StatusBadRequest, "package_id is required"), nil
 	}
 
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
 	}
-	sessionID := pathParts[2]
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
 
-	pkg, dbErr := sr.repository.ValidatePackage(body.Signature, body.PackageID, sessionID)
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	pkg, dbErr := sr.repository.ValidatePackage(ctx, body.Signature, body.PackageID, sessionID, body.OperatorID, idempotencyKey)
 	if dbErr != nil {
+		log.Info("validate package failed", "package_id", body.PackageID, "error", dbErr.Message)
 		switch dbErr.Code {
 		case "ENTITY_NOT_FOUND":
-			return &Response{
-				StatusCode: http.StatusNotFound,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Message),
-			}, fmt.Errorf("entity not found: %s", dbErr.Message)
+			return writeError(http.StatusNotFound, dbErr.Message), fmt.Errorf("entity not found: %s", dbErr.Message)
+		case "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Message), fmt.Errorf("idempotency conflict: %s", dbErr.Message)
 		default:
-			return &Response{
-				StatusCode: http.StatusInternalServerError,
-				Headers:    defaultHeaders,
-				Body:       `{"error":"Internal server error"}`,
-			}, nil
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
 		}
 	}
 
-	return &Response{
-		StatusCode: http.StatusAccepted,
-		Headers:    defaultHeaders,
-		Body:       fmt.Sprintf(`{"message":"package validated successfully","package_id":"%s","supplier":"%s","session_id":"%s"}`, pkg.ID, pkg.Supplier.Name, sessionID),
-	}, nil
+	return writeJSON(http.StatusAccepted, ValidatePackageResponse{
+		Message:   "package validated successfully",
+		PackageID: pkg.ID,
+		Supplier:  pkg.Supplier.Name,
+		SessionID: sessionID,
+	})
 }
 
-type QualityCheckHandler struct {
-	Passed bool     `json:"passed"`
-	Issues []string `json:"issues"`
-}
+// QualityCheckHandler implements ServerInterface's qualityCheck operation
+// (POST /session/{id}/qc). Registered with RequireRole("Quality Control
+// Specialist") - see RegisterDefaultServices.
+func (sr *ServiceRegistry) QualityCheckHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
+	}
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	var body QualityCheckRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		log.Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, fmt.Sprintf("Invalid body format: %s", err.Error())), fmt.Errorf("invalid body format")
+	}
 
-func (sr *ServiceRegistry) QualityCheckHandler(req *Request) (*Response, error) {
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
-	}
-	sessionID := pathParts[2]
-
-	var body QualityCheckHandler
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		sr.logger.Info("Failed to parse body", "error", err.Error())
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"Invalid body format: %s"}`, err.Error()),
-		}, fmt.Errorf("invalid body format")
-	}
-
-	pkg, qcRecord, dbErr := sr.repository.QualityCheck(sessionID, body.Passed, body.Issues)
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	issues := make([]repository.QCIssueInput, len(body.Issues))
+	for i, issue := range body.Issues {
+		issues[i] = repository.QCIssueInput{
+			Code:        issue.Code,
+			Severity:    repository.QCSeverity(issue.Severity),
+			Description: issue.Description,
+			PhotoURL:    issue.PhotoURL,
+		}
+	}
+
+	pkg, qcRecord, dbErr := sr.repository.QualityCheck(ctx, sessionID, body.Passed, issues, idempotencyKey)
 	if dbErr != nil {
+		log.Info("quality check failed", "error", dbErr.Message)
 		switch dbErr.Code {
 		case "ENTITY_NOT_FOUND":
-			return &Response{
-				StatusCode: http.StatusNotFound,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Message),
-			}, fmt.Errorf("entity not found: %s", dbErr.Message)
+			return writeError(http.StatusNotFound, dbErr.Message), fmt.Errorf("entity not found: %s", dbErr.Message)
+		case "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Message), fmt.Errorf("idempotency conflict: %s", dbErr.Message)
 		default:
-			return &Response{
-				StatusCode: http.StatusInternalServerError,
-				Headers:    defaultHeaders,
-				Body:       `{"error":"Internal server error"}`,
-			}, nil
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
 		}
 	}
 	if qcRecord == nil {
-		return &Response{
-			StatusCode: http.StatusNotFound,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"qcRecord is nil"}`,
-		}, err
-	}
-
-	return &Response{
-		StatusCode: http.StatusAccepted,
-		Headers:    defaultHeaders,
-		Body: fmt.Sprintf(`{
-		"message":"QC record created for package %s",
-		"package_id":"%s",
-		"qc_record_id":"%s",
-		"operator_id": "%s"
-		}`, pkg.ID, pkg.ID, qcRecord.ID, qcRecord.InspectorID),
-	}, nil
-}
+		return writeError(http.StatusNotFound, "qcRecord is nil"), nil
+	}
 
-type labelPackageHandlerBody struct {
-	Label       string `json:"label"`
-	Destination string `json:"destination"`
-	Priority    string `json:"priority"`
-	CourierID   string `json:"courier_id"`
+	log.Info("quality check recorded", "package_id", pkg.ID, "passed", body.Passed)
+	return writeJSON(http.StatusAccepted, QualityCheckResponse{
+		Message:    fmt.Sprintf("QC record created for package %s", pkg.ID),
+		PackageID:  pkg.ID,
+		QCRecordID: qcRecord.ID,
+		OperatorID: qcRecord.InspectorID,
+	})
 }
 
-// LabelPackageHandler assigns a label, courier, and destination
-func (sr *ServiceRegistry) LabelPackageHandler(req *Request) (*Response, error) {
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
-	}
-	sessionID := pathParts[2]
-
-	var body labelPackageHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"%s"}`, err.Error()),
-		}, err
-	}
-
-	newLabel, dbErr := sr.repository.LabelPackage(sessionID, body.Label, body.Destination, body.Priority, body.CourierID)
+// LabelPackageHandler implements ServerInterface's labelPackage operation
+// (POST /session/{id}/label). Registered with RequireRole("Shipping
+// Specialist") - see RegisterDefaultServices.
+func (sr *ServiceRegistry) LabelPackageHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
+	}
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	var body LabelPackageRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		log.Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, err.Error()), err
+	}
+
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	newLabel, dbErr := sr.repository.LabelPackage(ctx, sessionID, body.Label, body.Destination, body.Priority, body.CourierID, idempotencyKey)
 	if dbErr != nil {
-		if dbErr.Code == "ENTITY_NOT_FOUND" {
-			return &Response{
-				StatusCode: http.StatusNotFound,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("database error: %v", dbErr)
-		}
-		if dbErr.Code == repository.PgErrForeignKeyViolation {
-			return &Response{
-				StatusCode: http.StatusBadRequest,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("database error: %v", dbErr)
+		log.Info("label package failed", "error", dbErr.Detail)
+		switch {
+		case dbErr.Code == "ENTITY_NOT_FOUND":
+			return writeError(http.StatusNotFound, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		case dbErr.Code == repository.PgErrForeignKeyViolation:
+			return writeError(http.StatusBadRequest, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		case dbErr.Code == "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		default:
+			return writeError(http.StatusInternalServerError, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
 		}
-		return &Response{
-			StatusCode: http.StatusInternalServerError,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-		}, fmt.Errorf("database error: %v", dbErr)
-	}
-
-	responseBody := fmt.Sprintf(`{"label_id":"%s"}`, newLabel.ID)
-	return &Response{
-		StatusCode: http.StatusAccepted,
-		Headers:    defaultHeaders,
-		Body:       responseBody,
-	}, nil
-}
+	}
 
-type commitSessionHandlerBody struct {
-	OperatorID string `json:"operator_id"`
+	log.Info("package labeled", "label_id", newLabel.ID, "courier_id", body.CourierID)
+	return writeJSON(http.StatusAccepted, LabelPackageResponse{LabelID: newLabel.ID})
 }
 
-// CommitSessionHandler commits the session to the chain
-func (sr *ServiceRegistry) CommitSessionHandler(req *Request) (*Response, error) {
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
-	}
-	sessionID := pathParts[2]
-
-	var body commitSessionHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"%s"}`, err.Error()),
-		}, err
-	}
-
-	tx, dbErr := sr.repository.CommitSession(sessionID, body.OperatorID)
+// CommitSessionHandler implements ServerInterface's commitSession
+// operation (POST /commit/{id}). Registered with
+// RequireAccessLevel("Admin") - see RegisterDefaultServices.
+func (sr *ServiceRegistry) CommitSessionHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
+	}
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	var body CommitSessionRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		log.Info("Failed to parse body", "error", err.Error())
+		return writeError(http.StatusUnprocessableEntity, err.Error()), err
+	}
+
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	tx, dbErr := sr.repository.CommitSession(ctx, sessionID, body.OperatorID, idempotencyKey)
 	if dbErr != nil {
-		if dbErr.Code == "INVALID_STATE" {
-			return &Response{
-				StatusCode: http.StatusConflict,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("database error: %v", dbErr)
+		log.Info("commit session failed", "error", dbErr.Detail)
+		switch dbErr.Code {
+		case "INVALID_STATE":
+			return writeError(http.StatusConflict, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		case "ENTITY_NOT_FOUND":
+			return writeError(http.StatusNotFound, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		case repository.PgErrForeignKeyViolation:
+			return writeError(http.StatusBadRequest, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		case "IDEMPOTENCY_CONFLICT":
+			return writeError(http.StatusConflict, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
+		default:
+			return writeError(http.StatusInternalServerError, dbErr.Detail), fmt.Errorf("database error: %v", dbErr)
 		}
-		if dbErr.Code == "ENTITY_NOT_FOUND" {
-			return &Response{
-				StatusCode: http.StatusNotFound,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("database error: %v", dbErr)
+	}
+
+	// CommitSession already blocked (bounded by the request deadline) until
+	// consensus reported this tx committed, so the inclusion proof is
+	// buildable from chain data immediately - a proof-fetch failure here
+	// doesn't undo the commit, it just means the caller has to retry via
+	// GET /sessions/{id}/proof once it's built.
+	txProof, proofErr := sr.repository.GetTxInclusionProof(ctx, sessionID)
+	if proofErr != nil {
+		log.Info("commit succeeded but inclusion proof is not yet available", "error", proofErr.Message)
+	}
+
+	log.Info("session committed", "block_height", tx.BlockHeight)
+	return writeJSON(http.StatusAccepted, CommitSessionResponse{Tx: tx, Proof: txProof})
+}
+
+// ReceiveCommitHandler implements ServerInterface's receiveCommit
+// operation (POST /replica/commit/{id}), replaying an L2 commit onto this
+// L1 replica during BFT replication. Requires supervisor-level
+// (AccessLevel "Admin") access, enforced inline since this package has no
+// declarative middleware chain to register a guard against.
+func (sr *ServiceRegistry) ReceiveCommitHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
+	}
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	var body ReceiveCommitRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		log.Info("Failed to parse body", "error", err.Error())
+		return &Response{StatusCode: http.StatusUnprocessableEntity, Headers: defaultHeaders}, err
+	}
+
+	operator, dbErr := sr.repository.GetOperatorByID(ctx, body.OperatorID)
+	if dbErr != nil {
+		log.Info("failed to resolve operator", "error", dbErr.Message)
+		return writeError(http.StatusUnauthorized, dbErr.Message), nil
+	}
+	if operator.AccessLevel != "Admin" {
+		log.Info("rejected commit replay: supervisor-level access required", "access_level", operator.AccessLevel)
+		return writeError(http.StatusForbidden, "supervisor-level access required"), nil
+	}
+
+	if body.Proof != nil {
+		validatorSet, vsErr := sr.repository.CurrentValidatorSet(ctx)
+		if vsErr != nil {
+			log.Info("failed to load validator set for proof verification", "error", vsErr.Message)
+			return writeError(http.StatusInternalServerError, "failed to verify inclusion proof"), nil
 		}
-		if dbErr.Code == repository.PgErrForeignKeyViolation {
-			return &Response{
-				StatusCode: http.StatusBadRequest,
-				Headers:    defaultHeaders,
-				Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-			}, fmt.Errorf("database error: %v", dbErr)
+		if err := VerifyInclusionProof(body.Proof, validatorSet); err != nil {
+			log.Info("rejected commit replay: inclusion proof failed verification", "error", err.Error())
+			return writeError(http.StatusUnprocessableEntity, "inclusion proof failed verification"), nil
 		}
-		return &Response{
-			StatusCode: http.StatusInternalServerError,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"%s"}`, dbErr.Detail),
-		}, fmt.Errorf("database error: %v", dbErr)
-	}
-
-	txJsonBytes, _ := json.Marshal(tx)
-	return &Response{
-		StatusCode: http.StatusAccepted,
-		Headers:    defaultHeaders,
-		Body:       fmt.Sprintf(`{"tx":%s}`, txJsonBytes),
-	}, nil
+	}
+
+	idempotencyKey := req.Headers["Idempotency-Key"]
+
+	repoErr := sr.repository.ReplicateCommitFromL2(ctx, sessionID, body.OperatorID, body.MerkleRoot, idempotencyKey)
+	if repoErr != nil {
+		log.Info("replicate commit from L2 failed", "error", repoErr.Message)
+		return writeError(http.StatusUnprocessableEntity, fmt.Sprintf("%s, %s, %s", repoErr.Code, repoErr.Message, repoErr.Detail)), nil
+	}
+
+	log.Info("commit replicated from L2", "merkle_root", body.MerkleRoot)
+	return writeJSON(http.StatusAccepted, ReceiveCommitResponse{
+		OperatorID: body.OperatorID,
+		MerkleRoot: body.MerkleRoot,
+		Timestamp:  body.Timestamp,
+	})
 }
 
-type receiveCommitHandlerBody struct {
-	OperatorID        string    `json:"operator_id"`
-	PackageID         string    `json:"package_id"`
-	SupplierSignature string    `json:"supplier_signature"`
-	QcPassed          bool      `json:"qc_passed"`
-	Issues            []string  `json:"issues"`
-	Timestamp         time.Time `json:"timestamp"`
-	Label             string    `json:"label"`
-	Destination       string    `json:"destination"`
-	Priority          string    `json:"priority"`
-	CourierID         string    `json:"courier_id"`
+// GetSessionProofHandler implements ServerInterface's getSessionProof
+// operation (GET /session/{id}/proof/{leaf}). It returns the Merkle proof
+// for a single leaf (e.g. "package", "qc_issue:QCI-...") committed under a
+// session's on-chain Merkle root, so a client can verify that field
+// without trusting this node.
+func (sr *ServiceRegistry) GetSessionProofHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 5)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
+	}
+	sessionID := segments[2]
+	leaf := segments[4]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+
+	proof, dbErr := sr.repository.GetSessionProof(ctx, sessionID, leaf)
+	if dbErr != nil {
+		log.Info("get session proof failed", "leaf", leaf, "error", dbErr.Message)
+		switch dbErr.Code {
+		case "ENTITY_NOT_FOUND":
+			return writeError(http.StatusNotFound, dbErr.Message), fmt.Errorf("entity not found: %s", dbErr.Message)
+		case "INVALID_STATE":
+			return writeError(http.StatusConflict, dbErr.Message), fmt.Errorf("invalid state: %s", dbErr.Message)
+		default:
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
+		}
+	}
+
+	return writeJSON(http.StatusOK, proof)
 }
 
-func (sr *ServiceRegistry) ReceiveCommitHandler(req *Request) (*Response, error) {
-	pathParts := strings.Split(req.Path, "/")
-	if len(pathParts) != 4 {
-		return &Response{
-			StatusCode: http.StatusBadRequest,
-			Headers:    defaultHeaders,
-			Body:       `{"error":"Invalid path format"}`,
-		}, fmt.Errorf("invalid path format")
+// GetSessionInclusionProofHandler implements ServerInterface's
+// getSessionInclusionProof operation (GET /sessions/{id}/proof). It
+// re-derives the same BFT Merkle inclusion proof CommitSessionHandler
+// returns at commit time, so a client that connected late - or whose own
+// commit request timed out waiting on consensus - can still fetch one
+// afterward.
+func (sr *ServiceRegistry) GetSessionInclusionProofHandler(ctx context.Context, req *Request) (*Response, error) {
+	segments, ok := pathSegments(req.Path, 4)
+	if !ok {
+		return writeError(http.StatusBadRequest, "Invalid path format"), fmt.Errorf("invalid path format")
 	}
-	sessionID := pathParts[2]
+	sessionID := segments[2]
+	log := logging.FromContext(ctx).With("session_id", sessionID)
 
-	var body receiveCommitHandlerBody
-	err := json.Unmarshal([]byte(req.Body), &body)
-	if err != nil {
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-		}, err
+	txProof, dbErr := sr.repository.GetTxInclusionProof(ctx, sessionID)
+	if dbErr != nil {
+		log.Info("get session inclusion proof failed", "error", dbErr.Message)
+		switch dbErr.Code {
+		case "ENTITY_NOT_FOUND":
+			return writeError(http.StatusNotFound, dbErr.Message), fmt.Errorf("entity not found: %s", dbErr.Message)
+		case "INVALID_STATE":
+			return writeError(http.StatusConflict, dbErr.Message), fmt.Errorf("invalid state: %s", dbErr.Message)
+		default:
+			return writeError(http.StatusInternalServerError, "Internal server error"), nil
+		}
 	}
 
-	repoErr := sr.repository.ReplicateCommitFromL2(sessionID, body.OperatorID, body.PackageID, body.SupplierSignature, body.Label, body.Destination, body.Priority, body.CourierID, body.QcPassed, body.Issues)
-	if repoErr != nil {
-		return &Response{
-			StatusCode: http.StatusUnprocessableEntity,
-			Headers:    defaultHeaders,
-			Body:       fmt.Sprintf(`{"error":"%s, %s, %s"}`, repoErr.Code, repoErr.Message, repoErr.Detail),
-		}, err
-	}
-
-	return &Response{
-		StatusCode: http.StatusAccepted,
-		Headers:    defaultHeaders,
-		Body:       req.Body,
-	}, nil
+	return writeJSON(http.StatusOK, txProof)
 }
@@ -0,0 +1,17 @@
+package srvreg
+
+import (
+	"github.com/ahmadzakiakmal/thesis/src/layer-1/proof"
+
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// VerifyInclusionProof checks p's Merkle path and quorum signatures against
+// trustedValidatorSet, delegating to proof.VerifyTxProof. It lives here
+// rather than leaving every caller to import the proof package directly,
+// since the only caller in this checkout so far - ReceiveCommitHandler,
+// verifying a proof an L2 node supplied alongside a commit replay - is
+// itself in this package.
+func VerifyInclusionProof(p *proof.TxInclusionProof, trustedValidatorSet *cmttypes.ValidatorSet) error {
+	return proof.VerifyTxProof(p, trustedValidatorSet)
+}
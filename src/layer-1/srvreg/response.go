@@ -0,0 +1,45 @@
+package srvreg
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// pathSegments splits path on "/" and reports whether it has exactly want
+// segments (including the empty leading segment from the leading slash).
+// Every handler in this package used to repeat
+// `pathParts := strings.Split(req.Path, "/"); if len(pathParts) != N { ... }`
+// inline; this is the one place that logic lives now.
+func pathSegments(path string, want int) ([]string, bool) {
+	segments := strings.Split(path, "/")
+	return segments, len(segments) == want
+}
+
+// writeJSON marshals body - normally one of the typed *Response structs in
+// api.gen.go - into a Response with statusCode, replacing the
+// fmt.Sprintf-built JSON strings handlers used to hand-assemble (which is
+// how CommitSessionHandler ended up nesting a pre-marshaled value into a
+// %s verb instead of a struct field).
+func writeJSON(statusCode int, body interface{}) (*Response, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return writeError(http.StatusInternalServerError, "failed to serialize response"), nil
+	}
+	return &Response{
+		StatusCode: statusCode,
+		Headers:    defaultHeaders,
+		Body:       string(raw),
+	}, nil
+}
+
+// writeError builds the {"error": message} Response every handler's error
+// paths already converged on independently.
+func writeError(statusCode int, message string) *Response {
+	raw, _ := json.Marshal(map[string]string{"error": message})
+	return &Response{
+		StatusCode: statusCode,
+		Headers:    defaultHeaders,
+		Body:       string(raw),
+	}
+}
@@ -0,0 +1,26 @@
+package txcodec
+
+import (
+	"github.com/fxamacker/cbor/v2"
+
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+)
+
+// CBORCodec serializes transactions as CBOR, more compact than JSON for
+// clients (embedded/mobile) that broadcast many transactions.
+type CBORCodec struct{}
+
+func (CBORCodec) MediaType() string { return "application/cbor" }
+func (CBORCodec) Magic() byte       { return cborMagic }
+
+func (CBORCodec) Marshal(tx *service_registry.Transaction) ([]byte, error) {
+	return cbor.Marshal(tx)
+}
+
+func (CBORCodec) Unmarshal(data []byte) (*service_registry.Transaction, error) {
+	var tx service_registry.Transaction
+	if err := cbor.Unmarshal(data, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
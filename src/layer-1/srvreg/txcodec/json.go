@@ -0,0 +1,36 @@
+package txcodec
+
+import (
+	"encoding/json"
+
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+)
+
+// Magic bytes for each registered format. 0x00 is avoided since it's a
+// common zero-value/padding byte that could lead a corrupt payload to be
+// mistaken for a recognized prefix. None of them collide with '{' (0x7B),
+// the first byte of every legacy, unprefixed JSON transaction.
+const (
+	jsonMagic     byte = 0x01
+	cborMagic     byte = 0x02
+	protobufMagic byte = 0x03
+)
+
+// JSONCodec is the default wire format, matching how transactions were
+// serialized before this package existed.
+type JSONCodec struct{}
+
+func (JSONCodec) MediaType() string { return "application/json" }
+func (JSONCodec) Magic() byte       { return jsonMagic }
+
+func (JSONCodec) Marshal(tx *service_registry.Transaction) ([]byte, error) {
+	return json.Marshal(tx)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (*service_registry.Transaction, error) {
+	var tx service_registry.Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
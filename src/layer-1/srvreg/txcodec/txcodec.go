@@ -0,0 +1,102 @@
+// Package txcodec implements wire formats for service_registry.Transaction,
+// the payload submitted to consensus and stored in every block. Formats
+// other than the original JSON encoding are discriminated on read by a
+// 1-byte magic prefix, so Registry.Decode can tell them apart without the
+// caller knowing which one a given transaction was encoded with; data with
+// no recognized prefix is assumed to be a transaction written before this
+// package existed - legacy, unprefixed JSON - and is decoded as such
+// instead of rejected.
+package txcodec
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-1/srvreg"
+)
+
+// Codec marshals and unmarshals a service_registry.Transaction for one wire
+// format.
+type Codec interface {
+	// MediaType is matched against a client-supplied Content-Type/Accept
+	// value to select this codec for encoding.
+	MediaType() string
+	// Magic is this format's 1-byte prefix, used to pick the right codec
+	// back out on decode.
+	Magic() byte
+	Marshal(tx *service_registry.Transaction) ([]byte, error)
+	Unmarshal(data []byte) (*service_registry.Transaction, error)
+}
+
+// Registry resolves a Codec by media type (to encode a new transaction in
+// the format a client asked for) or by magic byte (to decode one read back
+// off the chain, whichever format it happens to be in).
+type Registry struct {
+	mu          sync.RWMutex
+	byMediaType map[string]Codec
+	byMagic     map[byte]Codec
+	fallback    Codec
+}
+
+// NewRegistry creates a registry pre-populated with fallback, which is used
+// whenever a caller asks to encode with an empty or unrecognized media
+// type, and to decode data with no recognized magic prefix.
+func NewRegistry(fallback Codec) *Registry {
+	r := &Registry{
+		byMediaType: make(map[string]Codec),
+		byMagic:     make(map[byte]Codec),
+		fallback:    fallback,
+	}
+	r.Register(fallback)
+	return r
+}
+
+// Register adds c under its MediaType and Magic, replacing any codec
+// already registered for either.
+func (r *Registry) Register(c Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byMediaType[c.MediaType()] = c
+	r.byMagic[c.Magic()] = c
+}
+
+// ForMediaType resolves the codec for mediaType (e.g. a Content-Type
+// header, which may carry a ";charset=..." suffix), falling back to the
+// registry's default for an empty or unrecognized value.
+func (r *Registry) ForMediaType(mediaType string) Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+	if c, ok := r.byMediaType[mediaType]; ok {
+		return c
+	}
+	return r.fallback
+}
+
+// Encode marshals tx with the codec for mediaType and prepends its magic
+// byte.
+func (r *Registry) Encode(mediaType string, tx *service_registry.Transaction) ([]byte, error) {
+	c := r.ForMediaType(mediaType)
+	body, err := c.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("txcodec: encoding %s: %w", c.MediaType(), err)
+	}
+	return append([]byte{c.Magic()}, body...), nil
+}
+
+// Decode dispatches on data's first byte to the codec registered for that
+// magic value, falling back to the registry's default codec (legacy,
+// unprefixed JSON) when the byte isn't a recognized magic.
+func (r *Registry) Decode(data []byte) (*service_registry.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(data) > 0 {
+		if c, ok := r.byMagic[data[0]]; ok {
+			return c.Unmarshal(data[1:])
+		}
+	}
+	return r.fallback.Unmarshal(data)
+}
@@ -9,8 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/app/oe"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/app/snapshot"
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/srvreg"
 	abcitypes "github.com/cometbft/cometbft/abci/types"
@@ -18,9 +23,16 @@ import (
 	"github.com/dgraph-io/badger/v4"
 )
 
+// speculationWait bounds how long FinalizeBlock waits for an in-flight
+// speculation that hasn't finished yet before falling back to executing
+// the block itself - a block that's still speculating after this long is
+// more likely stuck (or racing a different proposal) than about to land.
+const speculationWait = 200 * time.Millisecond
+
 // Application implements the ABCI interface for the nodes
 type Application struct {
 	badgerDB        *badger.DB
+	dataDir         string
 	onGoingBlock    *badger.Txn
 	serviceRegistry *srvreg.ServiceRegistry
 	nodeID          string
@@ -28,6 +40,20 @@ type Application struct {
 	config          *AppConfig
 	logger          cmtlog.Logger
 	repository      *repository.Repository
+	speculator      *oe.Speculator
+	snapshots       *snapshot.Store
+	lastHeight      int64
+	lastAppHash     []byte
+
+	// voteDigests holds, for each recent height this node has extended a
+	// vote for, the per-tx response digest it emitted - so
+	// VerifyVoteExtension can check a peer's claimed digest against the
+	// same recomputation without needing that height's txs handed to it
+	// again.
+	voteDigestsMu sync.Mutex
+	voteDigests   map[int64]map[string]string
+
+	proposalPolicy ProposalPolicy
 }
 
 // AppConfig contains configuration for the application
@@ -35,18 +61,40 @@ type AppConfig struct {
 	NodeID        string
 	RequiredVotes int  // Number of votes required for consensus
 	LogAllTxs     bool // Whether to log all transactions, even failed ones
+
+	// BadgerPath is the directory backing badgerDB. It doubles as the
+	// anchor for the snapshot store (a "snapshots" sibling directory)
+	// and as the live directory ApplySnapshotChunk swaps a restored
+	// database into. Snapshotting is disabled if it's empty.
+	BadgerPath string
+	// SnapshotInterval is how many committed blocks pass between
+	// automatic snapshots. Zero disables snapshotting.
+	SnapshotInterval uint64
 }
 
 // NewABCIApplication creates a new  application
 func NewABCIApplication(badgerDB *badger.DB, serviceRegistry *srvreg.ServiceRegistry, config *AppConfig, logger cmtlog.Logger, repository *repository.Repository) *Application {
-	return &Application{
+	app := &Application{
 		badgerDB:        badgerDB,
+		dataDir:         config.BadgerPath,
 		serviceRegistry: serviceRegistry,
 		nodeID:          "",
 		config:          config,
 		logger:          logger,
 		repository:      repository,
 	}
+	app.speculator = oe.NewSpeculator(badgerDB, app.executeTx, calculateAppHash, logger)
+
+	if config.BadgerPath != "" {
+		store, err := snapshot.NewStore(filepath.Join(filepath.Dir(config.BadgerPath), "snapshots"))
+		if err != nil {
+			log.Printf("Error setting up snapshot store, snapshotting disabled: %v", err)
+		} else {
+			app.snapshots = store
+		}
+	}
+
+	return app
 }
 
 func (app *Application) SetNodeID(id string) {
@@ -153,7 +201,20 @@ func (app *Application) Query(_ context.Context, req *abcitypes.QueryRequest) (*
 	return &resp, nil
 }
 
-// verifyTransaction looks up a transaction and its consensus status
+// verifyResult is the JSON payload a verify:<txID> Query returns in
+// QueryResponse.Value - the stored tx and its consensus status, plus
+// (when its block's Merkle leaf list is still available) an inclusion
+// proof a client can check independently with VerifyProof against that
+// block's LastBlockAppHash.
+type verifyResult struct {
+	Tx     json.RawMessage `json:"tx"`
+	Status string          `json:"status"`
+	Proof  *MerkleProof    `json:"proof,omitempty"`
+}
+
+// verifyTransaction looks up a transaction and its consensus status, and
+// attaches a Merkle inclusion proof if the tx's block's leaf list is
+// still stored.
 func (app *Application) verifyTransaction(txID []byte) (*abcitypes.QueryResponse, error) {
 	var resp abcitypes.QueryResponse
 
@@ -197,8 +258,25 @@ func (app *Application) verifyTransaction(txID []byte) (*abcitypes.QueryResponse
 			}
 		}
 
-		// Create response with transaction and status
-		resp.Value = txData
+		var proof *MerkleProof
+		var tx srvreg.Transaction
+		if err := json.Unmarshal(txData, &tx); err == nil {
+			if leaves, ok := readMerkleLeaves(txn, tx.BlockHeight); ok {
+				bodyHash := sha256.Sum256([]byte(tx.Response.Body))
+				leaf := merkleLeaf(string(txID), tx.Response.StatusCode, bodyHash[:])
+				if idx := indexOfLeaf(leaves, leaf); idx >= 0 {
+					proof = buildMerkleProof(leaves, idx)
+				}
+			}
+		}
+
+		value, err := json.Marshal(verifyResult{Tx: txData, Status: status, Proof: proof})
+		if err != nil {
+			return err
+		}
+
+		// Create response with transaction, status, and (if available) proof
+		resp.Value = value
 		resp.Log = status
 		resp.Code = 0
 		return nil
@@ -242,15 +320,115 @@ func (app *Application) InitChain(_ context.Context, chain *abcitypes.InitChainR
 	return &abcitypes.InitChainResponse{}, nil
 }
 
-// PrepareProposal implements the ABCI PrepareProposal method
+// ProposalPolicy lets callers plug in application-specific admission
+// logic for PrepareProposal - rate limiting per originator, priority
+// classes, etc. - on top of the baseline handler-existence, replay, and
+// vote-extension-agreement checks PrepareProposal always runs.
+type ProposalPolicy interface {
+	// Admit reports whether tx should be considered for inclusion in the
+	// block being prepared.
+	Admit(tx *srvreg.Transaction) bool
+}
+
+// SetProposalPolicy installs the ProposalPolicy PrepareProposal consults,
+// replacing any previous one. A nil policy (the default) admits every tx
+// that passes the baseline checks.
+func (app *Application) SetProposalPolicy(policy ProposalPolicy) {
+	app.proposalPolicy = policy
+}
+
+// proposalCandidate pairs a decoded Transaction with the raw bytes
+// PrepareProposal received it as, so the final packing pass doesn't need
+// to re-marshal anything to measure size.
+type proposalCandidate struct {
+	tx      *srvreg.Transaction
+	txBytes []byte
+	txID    string
+}
+
+// PrepareProposal implements the ABCI PrepareProposal method. A
+// candidate tx is dropped if: its path/method has no registered handler,
+// it duplicates an already-committed tx (replay), its response digest
+// failed to reach quorum agreement in the previous height's vote
+// extensions, or app.proposalPolicy rejects it. Survivors are ordered
+// deterministically by request timestamp (origin node as tiebreaker) and
+// packed greedily up to MaxTxBytes.
 func (app *Application) PrepareProposal(_ context.Context, proposal *abcitypes.PrepareProposalRequest) (*abcitypes.PrepareProposalResponse, error) {
-	// Include all transactions
-	return &abcitypes.PrepareProposalResponse{Txs: proposal.Txs}, nil
+	agreement := tallyDigestAgreement(proposal.LocalLastCommit)
+	required := int32(app.config.RequiredVotes)
+
+	candidates := make([]proposalCandidate, 0, len(proposal.Txs))
+	for _, txBytes := range proposal.Txs {
+		var tx srvreg.Transaction
+		if err := json.Unmarshal(txBytes, &tx); err != nil {
+			continue // can't evaluate it against any policy below - drop it
+		}
+
+		if _, _, _, found := app.serviceRegistry.GetHandlerForPath(tx.Request.Method, tx.Request.Path); !found {
+			continue
+		}
+
+		txID := generateTxID(tx.Request.RequestID, tx.OriginNodeID)
+		if votes, disputed := agreement[txID]; disputed && votes < required {
+			continue
+		}
+		if app.alreadyCommitted(txID) {
+			continue
+		}
+		if app.proposalPolicy != nil && !app.proposalPolicy.Admit(&tx) {
+			continue
+		}
+
+		candidates = append(candidates, proposalCandidate{tx: &tx, txBytes: txBytes, txID: txID})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := candidates[i].tx.Request.Timestamp, candidates[j].tx.Request.Timestamp
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return candidates[i].tx.OriginNodeID < candidates[j].tx.OriginNodeID
+	})
+
+	txs := make([][]byte, 0, len(candidates))
+	var budget int64
+	for _, c := range candidates {
+		size := int64(len(c.txBytes))
+		if proposal.MaxTxBytes > 0 && budget+size > proposal.MaxTxBytes {
+			break
+		}
+		txs = append(txs, c.txBytes)
+		budget += size
+	}
+
+	return &abcitypes.PrepareProposalResponse{Txs: txs}, nil
+}
+
+// alreadyCommitted reports whether txID is already stored, i.e. some
+// earlier block already committed this exact tx - PrepareProposal uses
+// this to keep a replayed tx out of a new proposal.
+func (app *Application) alreadyCommitted(txID string) bool {
+	var exists bool
+	err := app.badgerDB.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(append([]byte("tx:"), []byte(txID)...))
+		if err == nil {
+			exists = true
+			return nil
+		}
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		log.Printf("Error checking tx replay cache: %v", err)
+	}
+	return exists
 }
 
 // ProcessProposal implements the ABCI ProcessProposal method
 func (app *Application) ProcessProposal(
-	_ context.Context,
+	ctx context.Context,
 	proposal *abcitypes.ProcessProposalRequest,
 ) (*abcitypes.ProcessProposalResponse, error) {
 	fmt.Println("[PROCESSPROPOSAL]:")
@@ -261,27 +439,43 @@ func (app *Application) ProcessProposal(
 		isTxOriginator := app.nodeID == tx.OriginNodeID
 		if !isTxOriginator {
 			// Replicate the request and compare the response
-			handler, isHandlerFound := app.serviceRegistry.
+			handler, pathParams, _, isHandlerFound := app.serviceRegistry.
 				GetHandlerForPath(
 					tx.Request.Method,
 					tx.Request.Path,
 				)
 			if isHandlerFound {
-				response, err := handler(&tx.Request)
+				tx.Request.PathParams = pathParams
+				response, err := handler(ctx, &tx.Request)
 				if err != nil {
 					fmt.Println("Voted invalid", err)
 					return &abcitypes.ProcessProposalResponse{
 						Status: abcitypes.PROCESS_PROPOSAL_STATUS_REJECT,
 					}, err
 				}
-				if !compareResponses(response, &tx.Response) {
+
+				canon := app.serviceRegistry.GetCanonicalizerForPath(tx.Request.Method, tx.Request.Path)
+				canonResponse := canon.Canonicalize(response)
+				canonTxResponse := canon.Canonicalize(&tx.Response)
+				if !compareResponses(canonResponse, canonTxResponse) {
 					fmt.Println("Voted invalid")
 					fmt.Println("Different responses, byzantine behavior detected")
+					// ProcessProposalResponse carries no Events field to
+					// attach a structured diff to, so it's logged instead -
+					// the closest thing to an ABCI event this call can
+					// actually emit.
+					diff := diffResponses(canonResponse, canonTxResponse)
+					diffJSON, _ := json.Marshal(diff)
+					app.logger.Error("byzantine response mismatch detected",
+						"request_id", tx.Request.RequestID,
+						"origin_node", tx.OriginNodeID,
+						"diff", string(diffJSON),
+					)
 					return &abcitypes.ProcessProposalResponse{
 							Status: abcitypes.
 								PROCESS_PROPOSAL_STATUS_REJECT,
 						},
-						fmt.Errorf("response is different, byzantine behavior detected")
+						fmt.Errorf("response is different, byzantine behavior detected: %s", diffJSON)
 				}
 			} else {
 				fmt.Println("Voted invalid", "Handler not found")
@@ -293,6 +487,7 @@ func (app *Application) ProcessProposal(
 		}
 	}
 	fmt.Println("Voted valid")
+	app.speculator.Begin(proposal.Height, proposal.Txs)
 	return &abcitypes.ProcessProposalResponse{Status: abcitypes.
 		PROCESS_PROPOSAL_STATUS_ACCEPT,
 	}, nil
@@ -300,46 +495,54 @@ func (app *Application) ProcessProposal(
 
 // FinalizeBlock implements the ABCI FinalizeBlock method
 func (app *Application) FinalizeBlock(
-	_ context.Context,
+	ctx context.Context,
 	req *abcitypes.FinalizeBlockRequest,
 ) (*abcitypes.FinalizeBlockResponse, error) {
-	var txResults = make([]*abcitypes.ExecTxResult, len(req.Txs))
-
 	app.mu.Lock()
 	defer app.mu.Unlock()
 
-	app.onGoingBlock = app.badgerDB.NewTransaction(true)
-
-	for i, txBytes := range req.Txs {
-		var tx srvreg.Transaction
-
-		if err := json.Unmarshal(txBytes, &tx); err != nil {
-			txResults[i] = &abcitypes.ExecTxResult{
-				Code: 1,
-				Log:  "Invalid transaction format",
-			}
-			continue
+	key := oe.HashProposal(req.Txs)
+	defer app.speculator.CancelOthers(key)
+
+	result, ok := app.speculator.Take(key)
+	if !ok {
+		waitCtx, cancel := context.WithTimeout(ctx, speculationWait)
+		result, ok = app.speculator.Wait(waitCtx, key)
+		cancel()
+		if !ok {
+			// The speculation is still running (or never started) past our
+			// wait budget - CancelOthers above won't touch key, so without
+			// this it keeps running and its scratch txn is never Discard'd.
+			app.speculator.Cancel(key)
 		}
+	}
 
-		txID := generateTxID(
-			tx.Request.RequestID,
-			tx.OriginNodeID,
-		)
-		// accept all tx that made it through to this method
-		status := "accepted"
-		txResults[i] = app.storeTransaction(
-			txID,
-			&tx,
-			status,
-			txBytes,
-		)
+	if ok {
+		app.onGoingBlock = result.Txn
+		app.lastHeight = req.Height
+		app.lastAppHash = result.AppHash
+		return &abcitypes.FinalizeBlockResponse{
+			TxResults: result.TxResults,
+			AppHash:   result.AppHash,
+		}, nil
 	}
 
+	// No speculation matched this exact block (first time we've seen it,
+	// or the speculative run was aborted/failed) - fall back to executing
+	// it here, the same way this method always has.
+	var txResults = make([]*abcitypes.ExecTxResult, len(req.Txs))
+
+	app.onGoingBlock = app.badgerDB.NewTransaction(true)
+
 	// store the last block info
 	blockHeight := req.Height
 
+	for i, txBytes := range req.Txs {
+		txResults[i] = app.executeTx(app.onGoingBlock, txBytes, blockHeight)
+	}
+
 	// calculate application hash
-	appHash := calculateAppHash(txResults)
+	appHash, leaves := calculateAppHash(txResults)
 
 	// store block info
 	err := app.onGoingBlock.
@@ -366,6 +569,13 @@ func (app *Application) FinalizeBlock(
 		)
 	}
 
+	if err := app.storeMerkleTree(app.onGoingBlock, blockHeight, appHash, leaves); err != nil {
+		log.Printf("Error storing merkle tree: %v", err)
+	}
+
+	app.lastHeight = blockHeight
+	app.lastAppHash = appHash
+
 	return &abcitypes.FinalizeBlockResponse{
 		TxResults: txResults,
 		AppHash:   appHash,
@@ -380,48 +590,239 @@ func (app *Application) Commit(_ context.Context, commit *abcitypes.CommitReques
 		log.Printf("Error committing block: %v", err)
 	}
 
+	if app.snapshots != nil && app.config.SnapshotInterval > 0 &&
+		app.lastHeight > 0 && uint64(app.lastHeight)%app.config.SnapshotInterval == 0 {
+		height, appHash, db := app.lastHeight, app.lastAppHash, app.badgerDB
+		go func() {
+			if _, err := app.snapshots.Create(db, uint64(height), appHash); err != nil {
+				log.Printf("Error creating snapshot at height %d: %v", height, err)
+			}
+		}()
+	}
+
 	return &abcitypes.CommitResponse{}, nil
 }
 
 // ListSnapshots implements the ABCI ListSnapshots method
-func (app *Application) ListSnapshots(_ context.Context, snapshots *abcitypes.ListSnapshotsRequest) (*abcitypes.ListSnapshotsResponse, error) {
-	return &abcitypes.ListSnapshotsResponse{}, nil
+func (app *Application) ListSnapshots(_ context.Context, _ *abcitypes.ListSnapshotsRequest) (*abcitypes.ListSnapshotsResponse, error) {
+	if app.snapshots == nil {
+		return &abcitypes.ListSnapshotsResponse{}, nil
+	}
+
+	manifests, err := app.snapshots.List()
+	if err != nil {
+		log.Printf("Error listing snapshots: %v", err)
+		return &abcitypes.ListSnapshotsResponse{}, nil
+	}
+
+	resp := &abcitypes.ListSnapshotsResponse{}
+	for _, m := range manifests {
+		hash, err := hex.DecodeString(m.MerkleRoot)
+		if err != nil {
+			continue
+		}
+		metadata, err := json.Marshal(m)
+		if err != nil {
+			continue
+		}
+		resp.Snapshots = append(resp.Snapshots, &abcitypes.Snapshot{
+			Height:   m.Height,
+			Format:   m.Format,
+			Chunks:   m.ChunkCount,
+			Hash:     hash,
+			Metadata: metadata,
+		})
+	}
+	return resp, nil
 }
 
 // OfferSnapshot implements the ABCI OfferSnapshot method
-func (app *Application) OfferSnapshot(_ context.Context, snapshot *abcitypes.OfferSnapshotRequest) (*abcitypes.OfferSnapshotResponse, error) {
-	return &abcitypes.OfferSnapshotResponse{}, nil
+func (app *Application) OfferSnapshot(_ context.Context, req *abcitypes.OfferSnapshotRequest) (*abcitypes.OfferSnapshotResponse, error) {
+	if app.snapshots == nil || req.Snapshot == nil {
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT}, nil
+	}
+	if req.Snapshot.Format != snapshot.Format {
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT_FORMAT}, nil
+	}
+
+	var manifest snapshot.Manifest
+	if err := json.Unmarshal(req.Snapshot.Metadata, &manifest); err != nil {
+		log.Printf("Error parsing offered snapshot metadata: %v", err)
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT}, nil
+	}
+	if manifest.Height != req.Snapshot.Height || manifest.ChunkCount != req.Snapshot.Chunks {
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT}, nil
+	}
+
+	if err := app.snapshots.BeginRestore(manifest); err != nil {
+		log.Printf("Error starting snapshot restore: %v", err)
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT}, nil
+	}
+
+	return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_ACCEPT}, nil
 }
 
 // LoadSnapshotChunk implements the ABCI LoadSnapshotChunk method
-func (app *Application) LoadSnapshotChunk(_ context.Context, chunk *abcitypes.LoadSnapshotChunkRequest) (*abcitypes.LoadSnapshotChunkResponse, error) {
-	return &abcitypes.LoadSnapshotChunkResponse{}, nil
+func (app *Application) LoadSnapshotChunk(_ context.Context, req *abcitypes.LoadSnapshotChunkRequest) (*abcitypes.LoadSnapshotChunkResponse, error) {
+	if app.snapshots == nil || req.Format != snapshot.Format {
+		return &abcitypes.LoadSnapshotChunkResponse{}, nil
+	}
+
+	chunk, err := app.snapshots.Chunk(req.Height, req.Chunk)
+	if err != nil {
+		log.Printf("Error loading snapshot chunk %d at height %d: %v", req.Chunk, req.Height, err)
+		return &abcitypes.LoadSnapshotChunkResponse{}, nil
+	}
+	return &abcitypes.LoadSnapshotChunkResponse{Chunk: chunk}, nil
 }
 
 // ApplySnapshotChunk implements the ABCI ApplySnapshotChunk method
-func (app *Application) ApplySnapshotChunk(_ context.Context, chunk *abcitypes.ApplySnapshotChunkRequest) (*abcitypes.ApplySnapshotChunkResponse, error) {
-	return &abcitypes.ApplySnapshotChunkResponse{
-		Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT,
-	}, nil
+func (app *Application) ApplySnapshotChunk(_ context.Context, req *abcitypes.ApplySnapshotChunkRequest) (*abcitypes.ApplySnapshotChunkResponse, error) {
+	if app.snapshots == nil {
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ABORT}, nil
+	}
+
+	ok, done, err := app.snapshots.ApplyChunk(req.Index, req.Chunk)
+	if err != nil {
+		log.Printf("Error applying snapshot chunk %d: %v", req.Index, err)
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_REJECT_SNAPSHOT}, nil
+	}
+	if !ok {
+		return &abcitypes.ApplySnapshotChunkResponse{
+			Result:        abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_RETRY,
+			RefetchChunks: []uint32{req.Index},
+		}, nil
+	}
+	if !done {
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT}, nil
+	}
+
+	// Every chunk is in and verified - swap the restored database in for
+	// the live one. This is the only point where app.badgerDB changes
+	// after construction, so it's guarded by the same mutex FinalizeBlock
+	// and Commit use around onGoingBlock.
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	newDB, err := app.snapshots.Finish(app.badgerDB, app.dataDir)
+	if err != nil {
+		log.Printf("Error finishing snapshot restore: %v", err)
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_REJECT_SNAPSHOT}, nil
+	}
+	app.badgerDB = newDB
+
+	return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT}, nil
 }
 
-// ExtendVote implements the ABCI ExtendVote method
-func (app *Application) ExtendVote(_ context.Context, extend *abcitypes.ExtendVoteRequest) (*abcitypes.ExtendVoteResponse, error) {
-	return &abcitypes.ExtendVoteResponse{}, nil
+// ExtendVote implements the ABCI ExtendVote method. It computes a
+// canonical digest of every tx's response in the block being voted on
+// and emits {txID: digest} as the vote extension, so peers - and this
+// node's own future PrepareProposal calls - can tell whether the
+// validator set actually agreed on what each tx's response was, beyond
+// the single-replay check ProcessProposal already does.
+func (app *Application) ExtendVote(_ context.Context, req *abcitypes.ExtendVoteRequest) (*abcitypes.ExtendVoteResponse, error) {
+	digests := make(map[string]string, len(req.Txs))
+	for _, txBytes := range req.Txs {
+		var tx srvreg.Transaction
+		if err := json.Unmarshal(txBytes, &tx); err != nil {
+			continue
+		}
+		txID := generateTxID(tx.Request.RequestID, tx.OriginNodeID)
+		digests[txID] = hex.EncodeToString(canonicalDigest(&tx.Response))
+	}
+
+	ext, err := json.Marshal(digests)
+	if err != nil {
+		return nil, fmt.Errorf("extend vote: marshaling digests: %w", err)
+	}
+
+	app.rememberVoteDigests(req.Height, digests)
+
+	return &abcitypes.ExtendVoteResponse{VoteExtension: ext}, nil
 }
 
-// VerifyVoteExtension implements the ABCI VerifyVoteExtension method
-func (app *Application) VerifyVoteExtension(_ context.Context, verify *abcitypes.VerifyVoteExtensionRequest) (*abcitypes.VerifyVoteExtensionResponse, error) {
-	return &abcitypes.VerifyVoteExtensionResponse{}, nil
+// VerifyVoteExtension implements the ABCI VerifyVoteExtension method. It
+// rejects the peer's extension if any digest it claims for a tx this
+// node also extended a vote for disagrees with this node's own
+// recomputation.
+func (app *Application) VerifyVoteExtension(_ context.Context, req *abcitypes.VerifyVoteExtensionRequest) (*abcitypes.VerifyVoteExtensionResponse, error) {
+	var peerDigests map[string]string
+	if err := json.Unmarshal(req.VoteExtension, &peerDigests); err != nil {
+		return &abcitypes.VerifyVoteExtensionResponse{Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_REJECT}, nil
+	}
+
+	local := app.recallVoteDigests(req.Height)
+	for txID, digest := range peerDigests {
+		if localDigest, known := local[txID]; known && localDigest != digest {
+			return &abcitypes.VerifyVoteExtensionResponse{Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_REJECT}, nil
+		}
+	}
+
+	return &abcitypes.VerifyVoteExtensionResponse{Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_ACCEPT}, nil
+}
+
+// rememberVoteDigests records the digests this node emitted for height
+// so a later VerifyVoteExtension call for the same height can check a
+// peer's claims against them, pruning anything more than two heights
+// stale.
+func (app *Application) rememberVoteDigests(height int64, digests map[string]string) {
+	app.voteDigestsMu.Lock()
+	defer app.voteDigestsMu.Unlock()
+
+	if app.voteDigests == nil {
+		app.voteDigests = make(map[int64]map[string]string)
+	}
+	app.voteDigests[height] = digests
+	for h := range app.voteDigests {
+		if h < height-2 {
+			delete(app.voteDigests, h)
+		}
+	}
+}
+
+func (app *Application) recallVoteDigests(height int64) map[string]string {
+	app.voteDigestsMu.Lock()
+	defer app.voteDigestsMu.Unlock()
+	return app.voteDigests[height]
 }
 
 // Helper Functions
 
+// executeTx unmarshals one transaction and stores it into txn, producing
+// the same ExecTxResult whether it's run inline by FinalizeBlock or ahead
+// of time by a speculative oe.Speculator run. height is stamped onto the
+// tx before it's persisted, since the consensus payload RunConsensus
+// marshaled it from was built before the block height was known -
+// verifyTransaction relies on the stored copy carrying the real height to
+// find that height's Merkle leaves for an inclusion proof.
+func (app *Application) executeTx(txn *badger.Txn, txBytes []byte, height int64) *abcitypes.ExecTxResult {
+	var tx srvreg.Transaction
+	if err := json.Unmarshal(txBytes, &tx); err != nil {
+		return &abcitypes.ExecTxResult{
+			Code: 1,
+			Log:  "Invalid transaction format",
+		}
+	}
+	tx.BlockHeight = height
+
+	rawTx, err := json.Marshal(&tx)
+	if err != nil {
+		return &abcitypes.ExecTxResult{
+			Code: 1,
+			Log:  "Invalid transaction format",
+		}
+	}
+
+	txID := generateTxID(tx.Request.RequestID, tx.OriginNodeID)
+	// accept all tx that made it through to this method
+	return app.storeTransaction(txn, txID, &tx, "accepted", rawTx)
+}
+
 // storeTransaction stores the transaction in the database
-func (app *Application) storeTransaction(txID string, tx *srvreg.Transaction, status string, rawTx []byte) *abcitypes.ExecTxResult {
+func (app *Application) storeTransaction(txn *badger.Txn, txID string, tx *srvreg.Transaction, status string, rawTx []byte) *abcitypes.ExecTxResult {
 	// Store the transaction
 	txKey := append([]byte("tx:"), []byte(txID)...)
-	err := app.onGoingBlock.Set(txKey, rawTx)
+	err := txn.Set(txKey, rawTx)
 	if err != nil {
 		log.Printf("Error storing transaction: %v", err)
 		return &abcitypes.ExecTxResult{
@@ -432,7 +833,7 @@ func (app *Application) storeTransaction(txID string, tx *srvreg.Transaction, st
 
 	// Store the status
 	statusKey := append([]byte("status:"), []byte(txID)...)
-	err = app.onGoingBlock.Set(statusKey, []byte(status))
+	err = txn.Set(statusKey, []byte(status))
 	if err != nil {
 		log.Printf("Error storing transaction status: %v", err)
 	}
@@ -459,15 +860,23 @@ func (app *Application) storeTransaction(txID string, tx *srvreg.Transaction, st
 		},
 	})
 
+	bodyHash := sha256.Sum256([]byte(tx.Response.Body))
+
 	return &abcitypes.ExecTxResult{
-		Code:   0,
-		Data:   []byte(txID),
+		Code: 0,
+		// Data holds this tx's Merkle leaf rather than the bare txID, so
+		// calculateAppHash can build the block's Merkle tree straight out
+		// of txResults - the txID is still available on every event above.
+		Data:   merkleLeaf(txID, tx.Response.StatusCode, bodyHash[:]),
 		Log:    status,
 		Events: events,
 	}
 }
 
-// compareResponses compares two DeWSResponse objects for equality
+// compareResponses compares two DeWSResponse objects for equality. Callers
+// wanting to tolerate non-deterministic fields (timestamps, Date/Server
+// headers, etc.) should pass both responses through a route's
+// ResponseCanonicalizer first, e.g. ProcessProposal's byzantine check.
 func compareResponses(a, b *srvreg.Response) bool {
 	// Compare status code
 	if a.StatusCode != b.StatusCode {
@@ -479,30 +888,311 @@ func compareResponses(a, b *srvreg.Response) bool {
 		return false
 	}
 
-	// For a real implementation, we'd need to ignore non-deterministic headers
-	// such as Date, Server, etc.
+	if len(a.Headers) != len(b.Headers) {
+		return false
+	}
+	for k, v := range a.Headers {
+		if b.Headers[k] != v {
+			return false
+		}
+	}
 
 	return true
 }
 
+// responseDiff reports which parts of two (already-canonicalized)
+// responses disagree, for logging alongside a byzantine-behavior
+// rejection - the specifics are far more useful to an operator than the
+// single boolean compareResponses returns.
+type responseDiff struct {
+	StatusCodes    []int    `json:"status_codes,omitempty"`
+	BodyMismatch   bool     `json:"body_mismatch,omitempty"`
+	HeaderMismatch []string `json:"header_mismatch,omitempty"`
+}
+
+// diffResponses computes the responseDiff between a and b.
+func diffResponses(a, b *srvreg.Response) responseDiff {
+	var diff responseDiff
+
+	if a.StatusCode != b.StatusCode {
+		diff.StatusCodes = []int{a.StatusCode, b.StatusCode}
+	}
+	if a.Body != b.Body {
+		diff.BodyMismatch = true
+	}
+
+	seen := make(map[string]bool, len(a.Headers)+len(b.Headers))
+	for k := range a.Headers {
+		seen[k] = true
+	}
+	for k := range b.Headers {
+		seen[k] = true
+	}
+	mismatched := make([]string, 0)
+	for k := range seen {
+		if a.Headers[k] != b.Headers[k] {
+			mismatched = append(mismatched, k)
+		}
+	}
+	sort.Strings(mismatched)
+	diff.HeaderMismatch = mismatched
+
+	return diff
+}
+
+// canonicalDigest hashes a response's status code, headers (sorted by
+// key so map iteration order can't change the result), and body, for
+// ExtendVote/VerifyVoteExtension to compare across validators.
+func canonicalDigest(resp *srvreg.Response) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", resp.StatusCode)
+
+	keys := make([]string, 0, len(resp.Headers))
+	for k := range resp.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s:%s\n", k, resp.Headers[k])
+	}
+
+	h.Write([]byte(resp.Body))
+	return h.Sum(nil)
+}
+
+// tallyDigestAgreement counts, for every txID that appears in any
+// validator's vote extension from commit, how many validators' extensions
+// agree with that tx's most common digest.
+func tallyDigestAgreement(commit abcitypes.ExtendedCommitInfo) map[string]int32 {
+	votesByDigest := make(map[string]map[string]int32) // txID -> digest -> votes
+
+	for _, vote := range commit.Votes {
+		var digests map[string]string
+		if err := json.Unmarshal(vote.VoteExtension, &digests); err != nil {
+			continue
+		}
+		for txID, digest := range digests {
+			if votesByDigest[txID] == nil {
+				votesByDigest[txID] = make(map[string]int32)
+			}
+			votesByDigest[txID][digest]++
+		}
+	}
+
+	agreement := make(map[string]int32, len(votesByDigest))
+	for txID, digests := range votesByDigest {
+		var best int32
+		for _, votes := range digests {
+			if votes > best {
+				best = votes
+			}
+		}
+		agreement[txID] = best
+	}
+	return agreement
+}
+
 // generateTxID generates a unique ID for a transaction
 func generateTxID(requestID, nodeID string) string {
 	hash := sha256.Sum256([]byte(requestID + nodeID))
 	return hex.EncodeToString(hash[:])
 }
 
-// calculateAppHash calculates the application hash for the current block
-func calculateAppHash(txResults []*abcitypes.ExecTxResult) []byte {
-	// Simple implementation - in a real system, you might want a more
-	// sophisticated approach like a Merkle tree
-	allData := make([]byte, 0)
+// calculateAppHash builds a binary Merkle tree (RFC 6962 style) over the
+// block's per-tx leaves and returns its root as the application hash,
+// along with the ordered leaf list the tree was built from - the caller
+// persists both under merkle_root:<height>/merkle_leaves:<height> so a
+// later verify: query can reconstruct an inclusion proof.
+func calculateAppHash(txResults []*abcitypes.ExecTxResult) ([]byte, [][]byte) {
+	leaves := make([][]byte, len(txResults))
+	for i, result := range txResults {
+		leaves[i] = result.Data
+	}
+	return merkleRoot(leaves), leaves
+}
+
+// MerkleProof is an inclusion proof for one leaf against a stored Merkle
+// root: the sibling hash at each level from the leaf up to the root, and
+// a parallel bitmap recording whether the leaf being proved sat on the
+// left (false) or right (true) at that level.
+type MerkleProof struct {
+	Siblings []string `json:"siblings"` // hex-encoded, leaf-to-root order
+	Bits     []bool   `json:"bits"`
+}
 
-	for _, result := range txResults {
-		allData = append(allData, result.Data...)
+// VerifyProof independently recomputes the root from leaf and proof and
+// reports whether it matches root - the way a client confirms a tx's
+// inclusion proof from a verify: query against the block's
+// LastBlockAppHash without trusting this node's own recomputation.
+func VerifyProof(root []byte, leaf []byte, proof *MerkleProof) bool {
+	if len(proof.Siblings) != len(proof.Bits) {
+		return false
 	}
 
-	hash := sha256.Sum256(allData)
-	return hash[:]
+	current := leaf
+	for i, siblingHex := range proof.Siblings {
+		sibling, err := hex.DecodeString(siblingHex)
+		if err != nil {
+			return false
+		}
+		if proof.Bits[i] {
+			current = merkleParent(sibling, current)
+		} else {
+			current = merkleParent(current, sibling)
+		}
+	}
+	return bytes.Equal(current, root)
+}
+
+// merkleLeaf computes a tx's RFC 6962-style leaf hash.
+func merkleLeaf(txID string, statusCode int, bodyHash []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write([]byte(txID))
+	fmt.Fprintf(h, "%d", statusCode)
+	h.Write(bodyHash)
+	return h.Sum(nil)
+}
+
+// merkleParent computes an internal Merkle node from its two children.
+func merkleParent(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleRoot builds the root of a binary Merkle tree over leaves,
+// duplicating the last node at each odd-sized level.
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return sha256.New().Sum(nil)
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := left
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, merkleParent(left, right))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// buildMerkleProof derives leaves[index]'s inclusion proof by replaying
+// the same pairwise reduction merkleRoot performs, recording the sibling
+// and side at each level.
+func buildMerkleProof(leaves [][]byte, index int) *MerkleProof {
+	if index < 0 || index >= len(leaves) {
+		return nil
+	}
+
+	proof := &MerkleProof{}
+	level := leaves
+	idx := index
+	for len(level) > 1 {
+		var siblingIdx int
+		var isRight bool
+		if idx%2 == 0 {
+			siblingIdx = idx + 1
+			if siblingIdx >= len(level) {
+				siblingIdx = idx
+			}
+			isRight = false
+		} else {
+			siblingIdx = idx - 1
+			isRight = true
+		}
+		proof.Siblings = append(proof.Siblings, hex.EncodeToString(level[siblingIdx]))
+		proof.Bits = append(proof.Bits, isRight)
+
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := left
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, merkleParent(left, right))
+		}
+		level = next
+		idx /= 2
+	}
+	return proof
+}
+
+// readMerkleLeaves loads and hex-decodes the ordered leaf list stored
+// for height, reporting false if it's missing or unreadable (e.g. it was
+// pruned, or this tx's block predates Merkle-tree app hashing).
+func readMerkleLeaves(txn *badger.Txn, height int64) ([][]byte, bool) {
+	item, err := txn.Get([]byte(fmt.Sprintf("merkle_leaves:%d", height)))
+	if err != nil {
+		return nil, false
+	}
+
+	var raw []byte
+	if err := item.Value(func(val []byte) error {
+		raw = append([]byte{}, val...)
+		return nil
+	}); err != nil {
+		return nil, false
+	}
+
+	var hexLeaves []string
+	if err := json.Unmarshal(raw, &hexLeaves); err != nil {
+		return nil, false
+	}
+
+	leaves := make([][]byte, len(hexLeaves))
+	for i, h := range hexLeaves {
+		leaf, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, false
+		}
+		leaves[i] = leaf
+	}
+	return leaves, true
+}
+
+// indexOfLeaf returns leaf's position in leaves, or -1 if it's absent.
+func indexOfLeaf(leaves [][]byte, leaf []byte) int {
+	for i, l := range leaves {
+		if bytes.Equal(l, leaf) {
+			return i
+		}
+	}
+	return -1
+}
+
+// marshalMerkleLeaves hex-encodes and JSON-marshals leaves for storage
+// under merkle_leaves:<height>.
+func marshalMerkleLeaves(leaves [][]byte) ([]byte, error) {
+	hexLeaves := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		hexLeaves[i] = hex.EncodeToString(leaf)
+	}
+	return json.Marshal(hexLeaves)
+}
+
+// storeMerkleTree persists height's Merkle leaf list and root into txn
+// under merkle_leaves:<height>/merkle_root:<height>, for a later verify:
+// query to build an inclusion proof from.
+func (app *Application) storeMerkleTree(txn *badger.Txn, height int64, root []byte, leaves [][]byte) error {
+	leavesBytes, err := marshalMerkleLeaves(leaves)
+	if err != nil {
+		return err
+	}
+	if err := txn.Set([]byte(fmt.Sprintf("merkle_leaves:%d", height)), leavesBytes); err != nil {
+		return err
+	}
+	return txn.Set([]byte(fmt.Sprintf("merkle_root:%d", height)), root)
 }
 
 // int64ToBytes converts an int64 to bytes
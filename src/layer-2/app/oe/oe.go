@@ -0,0 +1,266 @@
+// Package oe implements optimistic execution of proposed blocks. Once
+// Application.ProcessProposal votes ACCEPT on a block, it hands the
+// block's txs to a Speculator, which starts a cancellable goroutine that
+// builds the block's Badger write batch and computes tx results/app hash
+// against a scratch transaction - the same work FinalizeBlock would
+// otherwise do from scratch. If FinalizeBlock is later asked to finalize
+// that exact block, it adopts the speculation's result directly instead
+// of re-executing every transaction; otherwise it aborts the speculation
+// and falls back to its normal path. Overlapping this work with the rest
+// of the consensus round is a substantial win, since replaying a block's
+// transactions is the dominant per-block cost in this design.
+package oe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// TxExecutor runs one transaction's share of a block's Badger write
+// batch against txn and returns its ExecTxResult - the same per-tx logic
+// Application.FinalizeBlock already runs for every tx in the block.
+// height is the block the tx belongs to, for stamping onto the stored tx.
+type TxExecutor func(txn *badger.Txn, txBytes []byte, height int64) *abcitypes.ExecTxResult
+
+// AppHasher combines a completed block's tx results into the application
+// hash FinalizeBlock would otherwise compute itself, along with the
+// ordered list of per-tx Merkle leaves that hash was built from.
+type AppHasher func(txResults []*abcitypes.ExecTxResult) (root []byte, leaves [][]byte)
+
+// Result is what a completed speculation hands back to FinalizeBlock: a
+// still-open Badger transaction holding the block's writes, ready to
+// Commit, plus the per-tx results and app hash FinalizeBlock would
+// otherwise have computed by replaying the block itself. The caller owns
+// Txn's lifetime from here - Commit it if adopted, Discard it otherwise.
+type Result struct {
+	Txn       *badger.Txn
+	TxResults []*abcitypes.ExecTxResult
+	AppHash   []byte
+}
+
+// speculation tracks one in-flight or completed optimistic execution.
+type speculation struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// Speculator runs optimistic execution for proposed blocks, keyed by a
+// hash of each block's tx set, so a later FinalizeBlock call can adopt a
+// matching completed Result instead of re-executing.
+type Speculator struct {
+	badgerDB *badger.DB
+	execute  TxExecutor
+	appHash  AppHasher
+	logger   cmtlog.Logger
+
+	mu           sync.Mutex
+	speculations map[string]*speculation
+}
+
+// NewSpeculator builds a Speculator that runs execute/appHash against
+// scratch Badger transactions it opens itself.
+func NewSpeculator(badgerDB *badger.DB, execute TxExecutor, appHash AppHasher, logger cmtlog.Logger) *Speculator {
+	return &Speculator{
+		badgerDB:     badgerDB,
+		execute:      execute,
+		appHash:      appHash,
+		logger:       logger,
+		speculations: make(map[string]*speculation),
+	}
+}
+
+// HashProposal derives the key Begin/Take/Wait/Cancel match a speculation
+// against the block FinalizeBlock is later asked to finalize.
+func HashProposal(txs [][]byte) string {
+	h := sha256.New()
+	for _, tx := range txs {
+		h.Write(tx)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin starts speculative execution of txs in a cancellable goroutine,
+// unless one is already running for this exact tx set. ProcessProposal
+// calls this right after voting ACCEPT.
+func (s *Speculator) Begin(height int64, txs [][]byte) {
+	key := HashProposal(txs)
+
+	s.mu.Lock()
+	if _, exists := s.speculations[key]; exists {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	spec := &speculation{cancel: cancel, done: make(chan struct{})}
+	s.speculations[key] = spec
+	s.mu.Unlock()
+
+	go s.run(ctx, spec, height, txs)
+}
+
+func (s *Speculator) run(ctx context.Context, spec *speculation, height int64, txs [][]byte) {
+	defer close(spec.done)
+
+	txn := s.badgerDB.NewTransaction(true)
+	txResults := make([]*abcitypes.ExecTxResult, len(txs))
+
+	for i, txBytes := range txs {
+		if ctx.Err() != nil {
+			txn.Discard()
+			spec.err = ctx.Err()
+			return
+		}
+		txResults[i] = s.execute(txn, txBytes, height)
+	}
+
+	if ctx.Err() != nil {
+		txn.Discard()
+		spec.err = ctx.Err()
+		return
+	}
+
+	appHash, leaves := s.appHash(txResults)
+
+	hexLeaves := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		hexLeaves[i] = hex.EncodeToString(leaf)
+	}
+	leavesBytes, err := json.Marshal(hexLeaves)
+	if err != nil {
+		txn.Discard()
+		spec.err = err
+		return
+	}
+
+	if err := txn.Set([]byte("last_block_height"), int64ToBytes(height)); err != nil {
+		txn.Discard()
+		spec.err = err
+		return
+	}
+	if err := txn.Set([]byte("last_block_app_hash"), appHash); err != nil {
+		txn.Discard()
+		spec.err = err
+		return
+	}
+	if err := txn.Set([]byte(fmt.Sprintf("merkle_leaves:%d", height)), leavesBytes); err != nil {
+		txn.Discard()
+		spec.err = err
+		return
+	}
+	if err := txn.Set([]byte(fmt.Sprintf("merkle_root:%d", height)), appHash); err != nil {
+		txn.Discard()
+		spec.err = err
+		return
+	}
+
+	spec.result = &Result{Txn: txn, TxResults: txResults, AppHash: appHash}
+}
+
+// Take returns the completed Result for key without blocking, removing
+// the speculation from the registry either way. It reports false if no
+// speculation for key exists or it hasn't finished yet.
+func (s *Speculator) Take(key string) (*Result, bool) {
+	s.mu.Lock()
+	spec, exists := s.speculations[key]
+	s.mu.Unlock()
+	if !exists {
+		return nil, false
+	}
+
+	select {
+	case <-spec.done:
+	default:
+		return nil, false
+	}
+
+	s.mu.Lock()
+	delete(s.speculations, key)
+	s.mu.Unlock()
+
+	if spec.err != nil || spec.result == nil {
+		return nil, false
+	}
+	return spec.result, true
+}
+
+// Wait blocks until the speculation for key finishes or ctx is done,
+// for a FinalizeBlock call that arrived before Begin's goroutine
+// completed. It reports false immediately if no speculation for key was
+// ever started.
+func (s *Speculator) Wait(ctx context.Context, key string) (*Result, bool) {
+	s.mu.Lock()
+	spec, exists := s.speculations[key]
+	s.mu.Unlock()
+	if !exists {
+		return nil, false
+	}
+
+	select {
+	case <-spec.done:
+		return s.Take(key)
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// Cancel aborts and discards the in-flight or completed speculation for
+// key, if any.
+func (s *Speculator) Cancel(key string) {
+	s.mu.Lock()
+	spec, exists := s.speculations[key]
+	if exists {
+		delete(s.speculations, key)
+	}
+	s.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	spec.cancel()
+	<-spec.done
+	if spec.result != nil {
+		spec.result.Txn.Discard()
+	}
+}
+
+// CancelOthers aborts every speculation except keep. FinalizeBlock calls
+// this once it knows which proposal (if any) it adopted, since a
+// consensus round only ever finalizes one block - every other
+// speculation it raced against is now moot.
+func (s *Speculator) CancelOthers(keep string) {
+	s.mu.Lock()
+	stale := make([]string, 0, len(s.speculations))
+	for key := range s.speculations {
+		if key != keep {
+			stale = append(stale, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, key := range stale {
+		s.Cancel(key)
+	}
+}
+
+func int64ToBytes(i int64) []byte {
+	buf := make([]byte, 8)
+	buf[0] = byte(i >> 56)
+	buf[1] = byte(i >> 48)
+	buf[2] = byte(i >> 40)
+	buf[3] = byte(i >> 32)
+	buf[4] = byte(i >> 24)
+	buf[5] = byte(i >> 16)
+	buf[6] = byte(i >> 8)
+	buf[7] = byte(i)
+	return buf
+}
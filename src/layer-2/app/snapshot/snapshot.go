@@ -0,0 +1,366 @@
+// Package snapshot implements ABCI state-sync snapshots over the
+// Application's Badger store: periodic full backups, split into
+// fixed-size chunks a syncing peer can fetch and verify one at a time,
+// and the receiving side's staged restore that ends in an atomic swap of
+// the live database directory.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Format is the only snapshot format this Store produces or accepts - a
+// full Badger Backup stream, split on fixed-size chunk boundaries.
+const Format uint32 = 1
+
+// ChunkSize is the maximum size of one snapshot chunk on disk.
+const ChunkSize = 10 * 1024 * 1024 // 10 MiB
+
+// Manifest describes one snapshot: the backed-up application height and
+// hash, plus enough per-chunk metadata for a receiver to verify each
+// chunk as it arrives rather than only after the whole snapshot lands.
+type Manifest struct {
+	Height      uint64   `json:"height"`
+	Format      uint32   `json:"format"`
+	AppHash     string   `json:"app_hash"`
+	ChunkCount  uint32   `json:"chunk_count"`
+	ChunkHashes []string `json:"chunk_hashes"` // sha256, hex-encoded, one per chunk
+	MerkleRoot  string   `json:"merkle_root"`  // hex-encoded root over ChunkHashes
+}
+
+// Store manages snapshots written under dir - one subdirectory per
+// height holding numbered chunk files and a manifest.json - and at most
+// one in-progress restore at a time, mirroring how CometBFT's state-sync
+// reactor only ever synchronizes one snapshot per node.
+type Store struct {
+	dir string
+
+	mu      sync.Mutex
+	restore *restoreState
+}
+
+type restoreState struct {
+	manifest Manifest
+	stageDir string
+	received map[uint32]bool
+}
+
+// NewStore builds a Store rooted at dir, creating it if necessary.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("snapshot: creating store dir: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) heightDir(height uint64) string {
+	return filepath.Join(s.dir, strconv.FormatUint(height, 10))
+}
+
+// Create backs up db in full and splits it into fixed-size chunks under
+// <dir>/<height>, writing a manifest alongside them.
+func (s *Store) Create(db *badger.DB, height uint64, appHash []byte) (*Manifest, error) {
+	dir := s.heightDir(height)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("snapshot: creating snapshot dir: %w", err)
+	}
+
+	backupPath := filepath.Join(dir, "backup")
+	backupFile, err := os.Create(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: creating backup file: %w", err)
+	}
+	_, err = db.Backup(backupFile, 0)
+	closeErr := backupFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: backing up database: %w", err)
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("snapshot: closing backup file: %w", closeErr)
+	}
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: reading backup file: %w", err)
+	}
+	os.Remove(backupPath)
+
+	var chunkHashes []string
+	var hashes [][]byte
+	for start := 0; start < len(backup); start += ChunkSize {
+		end := start + ChunkSize
+		if end > len(backup) {
+			end = len(backup)
+		}
+		chunk := backup[start:end]
+
+		idx := len(chunkHashes)
+		if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(idx)), chunk, 0o644); err != nil {
+			return nil, fmt.Errorf("snapshot: writing chunk %d: %w", idx, err)
+		}
+		sum := sha256.Sum256(chunk)
+		chunkHashes = append(chunkHashes, hex.EncodeToString(sum[:]))
+		hashes = append(hashes, sum[:])
+	}
+
+	manifest := Manifest{
+		Height:      height,
+		Format:      Format,
+		AppHash:     hex.EncodeToString(appHash),
+		ChunkCount:  uint32(len(chunkHashes)),
+		ChunkHashes: chunkHashes,
+		MerkleRoot:  hex.EncodeToString(merkleRoot(hashes)),
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: encoding manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), manifestBytes, 0o644); err != nil {
+		return nil, fmt.Errorf("snapshot: writing manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// List returns every manifest currently stored, most recent height
+// first, for ListSnapshots to offer to syncing peers.
+func (s *Store) List() ([]Manifest, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: reading store dir: %w", err)
+	}
+
+	var manifests []Manifest
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(s.dir, entry.Name(), "manifest.json"))
+		if err != nil {
+			continue // not a snapshot dir (e.g. a stale restore staging dir) - skip it
+		}
+		var m Manifest
+		if err := json.Unmarshal(raw, &m); err != nil {
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Height > manifests[j].Height })
+	return manifests, nil
+}
+
+// Chunk returns the raw bytes of chunk index from the snapshot at
+// height, for LoadSnapshotChunk to serve.
+func (s *Store) Chunk(height uint64, index uint32) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.heightDir(height), strconv.FormatUint(uint64(index), 10)))
+}
+
+// BeginRestore starts tracking an offered snapshot's chunks in a fresh
+// staging directory, discarding whatever restore (if any) was already in
+// progress - only one runs at a time, matching CometBFT's state-sync
+// reactor.
+func (s *Store) BeginRestore(m Manifest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.restore != nil {
+		os.RemoveAll(s.restore.stageDir)
+	}
+
+	stageDir := filepath.Join(s.dir, fmt.Sprintf("restore-%d", m.Height))
+	if err := os.RemoveAll(stageDir); err != nil {
+		return fmt.Errorf("snapshot: clearing stage dir: %w", err)
+	}
+	if err := os.MkdirAll(stageDir, 0o755); err != nil {
+		return fmt.Errorf("snapshot: creating stage dir: %w", err)
+	}
+
+	s.restore = &restoreState{manifest: m, stageDir: stageDir, received: make(map[uint32]bool)}
+	return nil
+}
+
+// ApplyChunk verifies chunk's hash against the in-progress restore's
+// manifest and stages it to disk. ok reports whether the hash matched
+// (the caller should ask for a refetch if not); done reports whether
+// every chunk for this restore has now been received and verified.
+func (s *Store) ApplyChunk(index uint32, chunk []byte) (ok bool, done bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.restore == nil {
+		return false, false, fmt.Errorf("snapshot: no restore in progress")
+	}
+	if int(index) >= len(s.restore.manifest.ChunkHashes) {
+		return false, false, fmt.Errorf("snapshot: chunk index %d out of range", index)
+	}
+
+	sum := sha256.Sum256(chunk)
+	if hex.EncodeToString(sum[:]) != s.restore.manifest.ChunkHashes[index] {
+		return false, false, nil
+	}
+
+	path := filepath.Join(s.restore.stageDir, strconv.FormatUint(uint64(index), 10))
+	if err := os.WriteFile(path, chunk, 0o644); err != nil {
+		return false, false, fmt.Errorf("snapshot: staging chunk %d: %w", index, err)
+	}
+	s.restore.received[index] = true
+
+	return true, uint32(len(s.restore.received)) == s.restore.manifest.ChunkCount, nil
+}
+
+// Finish concatenates every staged chunk in order, loads them into a
+// fresh Badger database, stamps it with the manifest's height/app hash,
+// then atomically swaps it in for the database at liveDataDir - closing
+// db, moving liveDataDir aside, moving the restored database into its
+// place, and reopening. It returns the reopened handle so the caller can
+// adopt it as its new badgerDB.
+func (s *Store) Finish(db *badger.DB, liveDataDir string) (*badger.DB, error) {
+	s.mu.Lock()
+	restore := s.restore
+	s.mu.Unlock()
+	if restore == nil {
+		return nil, fmt.Errorf("snapshot: no restore in progress")
+	}
+
+	stagingDir := liveDataDir + ".restoring"
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, fmt.Errorf("snapshot: clearing restore target: %w", err)
+	}
+
+	stagingDB, err := badger.Open(badger.DefaultOptions(stagingDir))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: opening staging database: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		for i := uint32(0); i < restore.manifest.ChunkCount; i++ {
+			chunk, err := os.ReadFile(filepath.Join(restore.stageDir, strconv.FormatUint(uint64(i), 10)))
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := pw.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := stagingDB.Load(pr, 256); err != nil {
+		stagingDB.Close()
+		return nil, fmt.Errorf("snapshot: loading snapshot into staging database: %w", err)
+	}
+
+	appHash, err := hex.DecodeString(restore.manifest.AppHash)
+	if err != nil {
+		stagingDB.Close()
+		return nil, fmt.Errorf("snapshot: decoding manifest app hash: %w", err)
+	}
+	err = stagingDB.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte("last_block_height"), int64ToBytes(int64(restore.manifest.Height))); err != nil {
+			return err
+		}
+		return txn.Set([]byte("last_block_app_hash"), appHash)
+	})
+	if err != nil {
+		stagingDB.Close()
+		return nil, fmt.Errorf("snapshot: stamping restored block info: %w", err)
+	}
+	if err := stagingDB.Close(); err != nil {
+		return nil, fmt.Errorf("snapshot: closing staging database: %w", err)
+	}
+
+	if err := db.Close(); err != nil {
+		return nil, fmt.Errorf("snapshot: closing live database: %w", err)
+	}
+	backupDir := liveDataDir + ".bak"
+	os.RemoveAll(backupDir)
+	if err := os.Rename(liveDataDir, backupDir); err != nil {
+		return nil, fmt.Errorf("snapshot: moving aside live database: %w", err)
+	}
+	if err := os.Rename(stagingDir, liveDataDir); err != nil {
+		os.Rename(backupDir, liveDataDir) // best-effort revert
+		return nil, fmt.Errorf("snapshot: swapping in restored database: %w", err)
+	}
+	os.RemoveAll(backupDir)
+	os.RemoveAll(restore.stageDir)
+
+	newDB, err := badger.Open(badger.DefaultOptions(liveDataDir))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: reopening swapped-in database: %w", err)
+	}
+
+	s.mu.Lock()
+	s.restore = nil
+	s.mu.Unlock()
+
+	return newDB, nil
+}
+
+// Abort discards the in-progress restore's staged chunks, if any.
+func (s *Store) Abort() {
+	s.mu.Lock()
+	restore := s.restore
+	s.restore = nil
+	s.mu.Unlock()
+	if restore != nil {
+		os.RemoveAll(restore.stageDir)
+	}
+}
+
+func int64ToBytes(i int64) []byte {
+	buf := make([]byte, 8)
+	buf[0] = byte(i >> 56)
+	buf[1] = byte(i >> 48)
+	buf[2] = byte(i >> 40)
+	buf[3] = byte(i >> 32)
+	buf[4] = byte(i >> 24)
+	buf[5] = byte(i >> 16)
+	buf[6] = byte(i >> 8)
+	buf[7] = byte(i)
+	return buf
+}
+
+// merkleRoot builds a simple binary Merkle root over leaf hashes,
+// duplicating the last node at each odd-sized level. The manifest carries
+// it as a single integrity value covering every chunk hash, alongside
+// (not instead of) each chunk's own hash.
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return sha256.New().Sum(nil)
+	}
+	level := leaves
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, hashPair(level[i], level[i]))
+			} else {
+				next = append(next, hashPair(level[i], level[i+1]))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func hashPair(a, b []byte) []byte {
+	h := sha256.New()
+	h.Write(a)
+	h.Write(b)
+	return h.Sum(nil)
+}
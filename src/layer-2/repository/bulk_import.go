@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Import codes recognized by BulkImport, naming both the target model and
+// the expected column layout.
+const (
+	ImportCodeCatalogItems = "SUPPLY_CHAIN-CATALOG_ITEMS"
+	ImportCodeSuppliers    = "SUPPLY_CHAIN-SUPPLIERS"
+	ImportCodeCouriers     = "SUPPLY_CHAIN-COURIERS"
+	ImportCodeOperators    = "SUPPLY_CHAIN-OPERATORS"
+)
+
+// ImportRowError records why one row of a BulkImport was rejected, with the
+// 1-based source line (the header is line 1) so a caller can jump straight
+// to the offending row in their spreadsheet.
+type ImportRowError struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport summarizes the outcome of a BulkImport call.
+type ImportReport struct {
+	Code       string           `json:"code"`
+	Accepted   int              `json:"accepted"`
+	Duplicates int              `json:"duplicates"`
+	Rejected   []ImportRowError `json:"rejected"`
+}
+
+// rowParser turns one spreadsheet row (keyed by lower-cased header) into a
+// model to insert, or an error explaining why the row is invalid.
+type rowParser func(row map[string]string) (interface{}, error)
+
+var importParsers = map[string]rowParser{
+	ImportCodeCatalogItems: parseCatalogItemRow,
+	ImportCodeSuppliers:    parseSupplierRow,
+	ImportCodeCouriers:     parseCourierRow,
+	ImportCodeOperators:    parseOperatorRow,
+}
+
+// BulkImport reads an uploaded XLSX or CSV spreadsheet from reader and
+// dispatches each row to the model named by code (one of the ImportCode*
+// constants). It replaces the hardcoded data Seed() inserts with something
+// a pilot can load hundreds of catalog items/suppliers/couriers/operators
+// through without editing code. Accepted rows are inserted inside one
+// dbTx; rejected rows are reported with their source line instead of
+// aborting the whole import, and rows whose primary key repeats an earlier
+// row in the same file are counted as duplicates and skipped.
+func (r *Repository) BulkImport(ctx context.Context, code string, reader io.Reader) (*ImportReport, *RepositoryError) {
+	parseRow, ok := importParsers[code]
+	if !ok {
+		return nil, &RepositoryError{
+			Code:    "UNKNOWN_IMPORT_CODE",
+			Message: "Unrecognized import code",
+			Detail:  fmt.Sprintf("no importer registered for code %q", code),
+		}
+	}
+
+	rows, header, err := readSpreadsheetRows(reader)
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "PARSE_ERROR",
+			Message: "Failed to parse uploaded spreadsheet",
+			Detail:  err.Error(),
+		}
+	}
+
+	report := &ImportReport{Code: code}
+	seen := make(map[string]bool, len(rows))
+	toInsert := make([]interface{}, 0, len(rows))
+
+	for i, row := range rows {
+		line := i + 2 // 1 for the header row, 1 to make it 1-based
+
+		record := make(map[string]string, len(header))
+		for j, col := range header {
+			if j < len(row) {
+				record[col] = strings.TrimSpace(row[j])
+			}
+		}
+
+		model, err := parseRow(record)
+		if err != nil {
+			report.Rejected = append(report.Rejected, ImportRowError{Line: line, Reason: err.Error()})
+			continue
+		}
+
+		key := importModelKey(model)
+		if seen[key] {
+			report.Duplicates++
+			continue
+		}
+		seen[key] = true
+		toInsert = append(toInsert, model)
+	}
+
+	if len(toInsert) > 0 {
+		dbTx := r.db.WithContext(ctx).Begin()
+		for _, model := range toInsert {
+			if err := dbTx.Create(model).Error; err != nil {
+				dbTx.Rollback()
+				return nil, &RepositoryError{
+					Code:    "DATABASE_ERROR",
+					Message: "Failed to insert imported rows",
+					Detail:  err.Error(),
+				}
+			}
+		}
+		if err := dbTx.Commit().Error; err != nil {
+			return nil, &RepositoryError{
+				Code:    "COMMIT_FAILED",
+				Message: "Failed to commit imported rows",
+				Detail:  err.Error(),
+			}
+		}
+	}
+
+	report.Accepted = len(toInsert)
+	return report, nil
+}
+
+func importModelKey(model interface{}) string {
+	switch m := model.(type) {
+	case *models.ItemCatalog:
+		return m.ID
+	case *models.Supplier:
+		return m.ID
+	case *models.Courier:
+		return m.ID
+	case *models.Operator:
+		return m.ID
+	default:
+		return ""
+	}
+}
+
+func parseCatalogItemRow(row map[string]string) (interface{}, error) {
+	if row["id"] == "" || row["name"] == "" {
+		return nil, errors.New("id and name are required")
+	}
+	unitWeight, err := parseFloatField(row["unit_weight"])
+	if err != nil {
+		return nil, fmt.Errorf("unit_weight: %w", err)
+	}
+	unitValue, err := parseFloatField(row["unit_value"])
+	if err != nil {
+		return nil, fmt.Errorf("unit_value: %w", err)
+	}
+	return &models.ItemCatalog{
+		ID:          row["id"],
+		Name:        row["name"],
+		Description: row["description"],
+		Category:    row["category"],
+		UnitWeight:  unitWeight,
+		UnitValue:   unitValue,
+	}, nil
+}
+
+func parseSupplierRow(row map[string]string) (interface{}, error) {
+	if row["id"] == "" || row["name"] == "" {
+		return nil, errors.New("id and name are required")
+	}
+	return &models.Supplier{ID: row["id"], Name: row["name"], Location: row["location"]}, nil
+}
+
+func parseCourierRow(row map[string]string) (interface{}, error) {
+	if row["id"] == "" || row["name"] == "" {
+		return nil, errors.New("id and name are required")
+	}
+	return &models.Courier{
+		ID:           row["id"],
+		Name:         row["name"],
+		ServiceLevel: row["service_level"],
+		ContactInfo:  row["contact_info"],
+	}, nil
+}
+
+func parseOperatorRow(row map[string]string) (interface{}, error) {
+	if row["id"] == "" || row["name"] == "" {
+		return nil, errors.New("id and name are required")
+	}
+	return &models.Operator{
+		ID:          row["id"],
+		Name:        row["name"],
+		Role:        row["role"],
+		AccessLevel: row["access_level"],
+	}, nil
+}
+
+func parseFloatField(raw string) (float64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// readSpreadsheetRows parses reader as XLSX if it starts with a ZIP magic
+// number, CSV otherwise, and splits out the header row (lower-cased, for
+// case-insensitive column lookups) from the data rows.
+func readSpreadsheetRows(reader io.Reader) (rows [][]string, header []string, err error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	var all [][]string
+	if len(magic) >= 2 && magic[0] == 'P' && magic[1] == 'K' {
+		all, err = readXLSXRows(buffered)
+	} else {
+		all, err = csv.NewReader(buffered).ReadAll()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(all) == 0 {
+		return nil, nil, errors.New("spreadsheet has no rows")
+	}
+
+	header = make([]string, len(all[0]))
+	for i, col := range all[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+	}
+	return all[1:], header, nil
+}
+
+func readXLSXRows(reader io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	return f.GetRows(sheet)
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxCommitJobAttempts is how many attempts ProcessCommitJob makes at a
+	// job before giving up and marking it "failed" for an operator to inspect.
+	maxCommitJobAttempts = 8
+	// maxCommitJobBackoff caps the exponential backoff between retries.
+	maxCommitJobBackoff = 5 * time.Minute
+)
+
+// commitJobPayload is what EnqueueCommit serializes into CommitJob.Payload
+// and ProcessCommitJob deserializes back out to call CommitToL1 with. It
+// carries only the session's Merkle root, not the full committed body -
+// that lives off-chain in a models.SessionBlob keyed by the same root.
+type commitJobPayload struct {
+	OperatorID string `json:"operator_id"`
+	MerkleRoot string `json:"merkle_root"`
+}
+
+// EnqueueCommit persists a pending L1 commit job for sessionID instead of
+// calling CommitToL1 synchronously. worker.Pool picks queued jobs up and
+// retries them with backoff via ProcessCommitJob until one succeeds.
+func (r *Repository) EnqueueCommit(sessionID, operatorID, merkleRoot string) (*models.CommitJob, *RepositoryError) {
+	payloadBytes, err := json.Marshal(commitJobPayload{
+		OperatorID: operatorID,
+		MerkleRoot: merkleRoot,
+	})
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "SERIALIZATION_ERROR",
+			Message: "Failed to serialize commit job payload",
+			Detail:  err.Error(),
+		}
+	}
+
+	job := &models.CommitJob{
+		SessionID:   sessionID,
+		Payload:     string(payloadBytes),
+		Status:      "queued",
+		NextRetryAt: time.Now(),
+	}
+	if err := r.db.Create(job).Error; err != nil {
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to enqueue commit job",
+			Detail:  err.Error(),
+		}
+	}
+	return job, nil
+}
+
+// CommitJobStatus returns sessionID's commit job, or nil if none was ever
+// enqueued. It backs an operator dashboard of stuck/in-flight commits.
+func (r *Repository) CommitJobStatus(sessionID string) (*models.CommitJob, *RepositoryError) {
+	var job models.CommitJob
+	err := r.db.Where("session_id = ?", sessionID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Database error",
+			Detail:  err.Error(),
+		}
+	}
+	return &job, nil
+}
+
+// ReadyCommitJobs atomically claims up to limit queued jobs whose
+// NextRetryAt has passed, flipping them to "processing" before returning
+// them, so two worker.Pool goroutines (or two poll ticks of a slow single
+// worker) can never both pick up the same job and double-submit it to L1.
+func (r *Repository) ReadyCommitJobs(limit int) ([]models.CommitJob, *RepositoryError) {
+	var jobs []models.CommitJob
+	err := r.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_retry_at <= ?", "queued", time.Now()).
+			Order("next_retry_at").
+			Limit(limit).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = "processing"
+		}
+		return dbTx.Model(&models.CommitJob{}).Where("id IN ?", ids).Update("status", "processing").Error
+	})
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to list ready commit jobs",
+			Detail:  err.Error(),
+		}
+	}
+	return jobs, nil
+}
+
+// ProcessCommitJob attempts job's L1 commit via CommitToL1, finalizing the
+// job/Transaction/Session rows on success or scheduling a backed-off retry
+// on failure. worker.Pool calls this, not CommitSession, so retries never
+// hold a DB transaction open across an L1 round-trip.
+func (r *Repository) ProcessCommitJob(job models.CommitJob) *RepositoryError {
+	var payload commitJobPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return &RepositoryError{
+			Code:    "DESERIALIZATION_ERROR",
+			Message: "Failed to parse commit job payload",
+			Detail:  err.Error(),
+		}
+	}
+
+	l1Resp, repoErr := r.CommitToL1(context.Background(), job.SessionID, payload.OperatorID, payload.MerkleRoot)
+	if repoErr != nil {
+		return r.retryCommitJob(job, repoErr)
+	}
+	return r.finalizeCommitJob(job, l1Resp)
+}
+
+// finalizeCommitJob marks job committed and brings the session's
+// Transaction/Session/Package rows in line, all inside one dbTx.
+func (r *Repository) finalizeCommitJob(job models.CommitJob, l1Resp *ConsensusResult) *RepositoryError {
+	dbTx := r.db.Begin()
+
+	var session models.Session
+	if err := dbTx.Where("session_id = ?", job.SessionID).First(&session).Error; err != nil {
+		dbTx.Rollback()
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to load session for commit job",
+			Detail:  err.Error(),
+		}
+	}
+
+	tx := models.Transaction{
+		SessionID:   session.ID,
+		TxHash:      l1Resp.TxHash,
+		BlockHeight: l1Resp.BlockHeight,
+		Status:      "committed",
+	}
+	session.IsCommitted = true
+	session.Status = "committed"
+	session.TxHash = &tx.TxHash
+	// Persisted in the same dbTx as the rest of commit finalization, so a
+	// session's last committed round is only ever updated alongside a
+	// confirmed commit - CommitToL1's stale-round guard reads this to
+	// reject a replayed/older beacon round on the next commit attempt.
+	session.LastBeaconRound = l1Resp.BeaconRound
+
+	if err := dbTx.Save(&session).Error; err != nil {
+		dbTx.Rollback()
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to update session status",
+			Detail:  err.Error(),
+		}
+	}
+	if err := dbTx.Model(&models.Package{}).Where("session_id = ?", session.ID).Update("status", "committed").Error; err != nil {
+		dbTx.Rollback()
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to update package status",
+			Detail:  err.Error(),
+		}
+	}
+	// CommitSession already recorded a "pending" Transaction for this
+	// session; promote that row rather than adding a second one.
+	result := dbTx.Model(&models.Transaction{}).Where("session_id = ? AND status = ?", session.ID, "pending").Updates(&tx)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result = dbTx.Create(&tx)
+	}
+	if err := result.Error; err != nil {
+		dbTx.Rollback()
+		return &RepositoryError{
+			Code:    "CONSENSUS_ERROR",
+			Message: "a consensus error occurred",
+			Detail:  err.Error(),
+		}
+	}
+
+	if repoErr := r.enqueueNotificationEvent(dbTx, job.SessionID, "session.committed", sessionCommittedEventPayload{
+		SessionID:   job.SessionID,
+		TxHash:      l1Resp.TxHash,
+		BlockHeight: l1Resp.BlockHeight,
+	}); repoErr != nil {
+		dbTx.Rollback()
+		return repoErr
+	}
+
+	job.Status = "committed"
+	if err := dbTx.Save(&job).Error; err != nil {
+		dbTx.Rollback()
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to update commit job",
+			Detail:  err.Error(),
+		}
+	}
+
+	if err := dbTx.Commit().Error; err != nil {
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to commit database transaction",
+			Detail:  err.Error(),
+		}
+	}
+	return nil
+}
+
+// retryCommitJob records the failure and schedules job's next attempt with
+// exponential backoff (2^attempts seconds, capped at maxCommitJobBackoff),
+// or marks it "failed" once maxCommitJobAttempts is reached.
+func (r *Repository) retryCommitJob(job models.CommitJob, cause *RepositoryError) *RepositoryError {
+	job.Attempts++
+	job.LastError = cause.Message + ": " + cause.Detail
+
+	if job.Attempts >= maxCommitJobAttempts {
+		job.Status = "failed"
+	} else {
+		backoff := time.Duration(1<<uint(job.Attempts)) * time.Second
+		if backoff > maxCommitJobBackoff {
+			backoff = maxCommitJobBackoff
+		}
+		// Back to "queued" so ReadyCommitJobs can claim it again once
+		// NextRetryAt passes - ProcessCommitJob only ever sees "processing"
+		// jobs that ReadyCommitJobs claimed for it.
+		job.Status = "queued"
+		job.NextRetryAt = time.Now().Add(backoff)
+	}
+
+	if err := r.db.Save(&job).Error; err != nil {
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to update commit job after failure",
+			Detail:  err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	cmtrpc "github.com/cometbft/cometbft/rpc/client/local"
+	cmtrpctypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// ConsensusBackend is the subset of an L1 consensus client that Repository
+// needs to submit and observe transactions. It's implemented here by
+// cometBFTBackend, wrapping *cmtrpc.Local (the default, and the only backend
+// this package ships with production behavior), but SetupRpcClient accepts
+// any ConsensusBackend, so alternative BFT engines - HotStuff, an
+// ABCI++-flavored Tendermint, or an in-process mock for benchmarking - can
+// be plugged in without Repository depending on CometBFT's concrete client
+// type.
+type ConsensusBackend interface {
+	// BroadcastTx submits payload and blocks until it's committed (or ctx is
+	// canceled).
+	BroadcastTx(ctx context.Context, payload []byte) (*ConsensusResult, error)
+	// BroadcastTxAsync submits payload and returns its hash immediately,
+	// without waiting for CheckTx or commit. Repository.RunConsensus pairs
+	// this with consensusPipeline's shared event subscription instead of
+	// blocking a goroutine per call inside the RPC client.
+	BroadcastTxAsync(ctx context.Context, payload []byte) (txHash string, err error)
+	// WaitForCommit blocks until txHash is observed committed, or timeout
+	// elapses.
+	WaitForCommit(ctx context.Context, txHash string, timeout time.Duration) (*ConsensusResult, error)
+	// QueryBlock fetches the block at height.
+	QueryBlock(ctx context.Context, height int64) (*cmtrpctypes.ResultBlock, error)
+	// SubscribeEvents subscribes to consensus events matching query (a
+	// CometBFT event query string, e.g. cmttypes.EventQueryNewBlock.String()).
+	SubscribeEvents(ctx context.Context, query string) (<-chan cmtrpctypes.ResultEvent, error)
+	// LatestHeight returns the current L1 block height, used to stamp a new
+	// session with the height it started at.
+	LatestHeight(ctx context.Context) (int64, error)
+}
+
+// cometBFTBackend adapts a *cmtrpc.Local in-process client to
+// ConsensusBackend.
+type cometBFTBackend struct {
+	rpc *cmtrpc.Local
+}
+
+// NewCometBFTBackend wraps rpc (typically constructed from the embedded
+// node with cmtrpc.New) as a ConsensusBackend.
+func NewCometBFTBackend(rpc *cmtrpc.Local) ConsensusBackend {
+	return &cometBFTBackend{rpc: rpc}
+}
+
+func (b *cometBFTBackend) BroadcastTx(ctx context.Context, payload []byte) (*ConsensusResult, error) {
+	result, err := b.rpc.BroadcastTxCommit(ctx, cmttypes.Tx(payload))
+	if err != nil {
+		return nil, err
+	}
+	if result.CheckTx.Code != 0 {
+		return nil, fmt.Errorf("CheckTx code: %d", result.CheckTx.Code)
+	}
+
+	return &ConsensusResult{
+		TxHash:      hex.EncodeToString(result.Hash),
+		BlockHeight: result.Height,
+		Code:        result.CheckTx.Code,
+	}, nil
+}
+
+// BroadcastTxAsync hands payload to CometBFT's mempool and returns as soon
+// as it's accepted for gossip, well before CheckTx or commit - the caller
+// learns the outcome later via consensusPipeline's shared subscription.
+func (b *cometBFTBackend) BroadcastTxAsync(ctx context.Context, payload []byte) (string, error) {
+	result, err := b.rpc.BroadcastTxAsync(ctx, cmttypes.Tx(payload))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(result.Hash), nil
+}
+
+// WaitForCommit subscribes to tx events and waits for one matching txHash,
+// rather than polling, so it doesn't add load proportional to how long
+// consensus takes.
+func (b *cometBFTBackend) WaitForCommit(ctx context.Context, txHash string, timeout time.Duration) (*ConsensusResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	const subscriber = "repository-wait-for-commit"
+	query := cmttypes.EventQueryTx.String()
+
+	events, err := b.rpc.Subscribe(ctx, subscriber, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe for commit wait: %w", err)
+	}
+	defer b.rpc.Unsubscribe(context.Background(), subscriber, query)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case evt := <-events:
+			txEvent, ok := evt.Data.(cmttypes.EventDataTx)
+			if !ok {
+				continue
+			}
+			if hex.EncodeToString(cmttypes.Tx(txEvent.Tx).Hash()) != txHash {
+				continue
+			}
+			return &ConsensusResult{
+				TxHash:      txHash,
+				BlockHeight: txEvent.Height,
+				Code:        txEvent.Result.Code,
+			}, nil
+		}
+	}
+}
+
+func (b *cometBFTBackend) QueryBlock(ctx context.Context, height int64) (*cmtrpctypes.ResultBlock, error) {
+	return b.rpc.Block(ctx, &height)
+}
+
+func (b *cometBFTBackend) SubscribeEvents(ctx context.Context, query string) (<-chan cmtrpctypes.ResultEvent, error) {
+	const subscriber = "repository-events"
+	return b.rpc.Subscribe(ctx, subscriber, query)
+}
+
+func (b *cometBFTBackend) LatestHeight(ctx context.Context) (int64, error) {
+	status, err := b.rpc.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return status.SyncInfo.LatestBlockHeight, nil
+}
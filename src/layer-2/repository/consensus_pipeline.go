@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	cmtrpctypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+const (
+	// defaultMaxPendingConsensusTx bounds consensusPipeline's pending map so
+	// a backend that stops delivering events can't grow it without limit;
+	// RunConsensus rejects new submissions past this with backpressure
+	// instead of queuing unboundedly.
+	defaultMaxPendingConsensusTx = 1000
+	// consensusPipelineReconnectDelay is how long run waits before
+	// resubscribing after the backend's event subscriptions break.
+	consensusPipelineReconnectDelay = 2 * time.Second
+)
+
+// pipelineResult is what a pending consensus wait resolves to once the
+// matching tx event arrives.
+type pipelineResult struct {
+	result *ConsensusResult
+	err    error
+}
+
+// PipelineMetrics snapshots consensusPipeline's health for an operator
+// dashboard or /metrics endpoint.
+type PipelineMetrics struct {
+	// Pending is how many RunConsensus calls are currently waiting on a tx
+	// completion event.
+	Pending int
+	// SubscriptionLag is how long it's been since the last Tx or NewBlock
+	// event was received; a steadily growing value means the subscription
+	// has stalled even if it hasn't errored outright.
+	SubscriptionLag time.Duration
+	// OrphanedHashes counts waits whose caller context expired before the
+	// tx's completion event arrived. The tx may still commit later with no
+	// one listening; this doesn't leak, but is worth alerting on.
+	OrphanedHashes int64
+}
+
+// consensusPipeline replaces RunConsensus's old per-call goroutine blocked
+// inside BroadcastTxCommit with one long-lived subscription to
+// tm.event='Tx' and tm.event='NewBlock', fanning completions out to
+// per-tx-hash channels. A backend hiccup (subscription closed or erroring)
+// triggers resubscription without losing outstanding waits, since the
+// pending map lives on the pipeline, not inside the subscription loop.
+type consensusPipeline struct {
+	backend    ConsensusBackend
+	maxPending int
+
+	mu          sync.Mutex
+	pending     map[string]chan pipelineResult
+	orphaned    int64
+	lastEventAt time.Time
+}
+
+func newConsensusPipeline(backend ConsensusBackend, maxPending int) *consensusPipeline {
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingConsensusTx
+	}
+	return &consensusPipeline{
+		backend:    backend,
+		maxPending: maxPending,
+		pending:    make(map[string]chan pipelineResult),
+	}
+}
+
+// run drives the subscribe-dispatch loop until ctx is canceled,
+// resubscribing with a short delay whenever the backend's event channels
+// close or fail to open. Outstanding pending waits survive a reconnect
+// untouched - they're keyed in p.pending, not tied to any one subscription.
+func (p *consensusPipeline) run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.subscribeAndDispatch(ctx); err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(consensusPipelineReconnectDelay):
+			}
+		}
+	}
+}
+
+func (p *consensusPipeline) subscribeAndDispatch(ctx context.Context) error {
+	txEvents, err := p.backend.SubscribeEvents(ctx, cmttypes.EventQueryTx.String())
+	if err != nil {
+		return fmt.Errorf("subscribe tx events: %w", err)
+	}
+	blockEvents, err := p.backend.SubscribeEvents(ctx, cmttypes.EventQueryNewBlock.String())
+	if err != nil {
+		return fmt.Errorf("subscribe block events: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-txEvents:
+			if !ok {
+				return fmt.Errorf("tx event subscription closed")
+			}
+			p.handleTxEvent(evt)
+		case evt, ok := <-blockEvents:
+			if !ok {
+				return fmt.Errorf("block event subscription closed")
+			}
+			p.handleBlockEvent(evt)
+		}
+	}
+}
+
+func (p *consensusPipeline) handleTxEvent(evt cmtrpctypes.ResultEvent) {
+	txEvent, ok := evt.Data.(cmttypes.EventDataTx)
+	if !ok {
+		return
+	}
+	txHash := hex.EncodeToString(cmttypes.Tx(txEvent.Tx).Hash())
+
+	p.mu.Lock()
+	ch, found := p.pending[txHash]
+	if found {
+		delete(p.pending, txHash)
+	}
+	p.lastEventAt = time.Now()
+	p.mu.Unlock()
+
+	if !found {
+		// Either nobody is waiting on this hash anymore (it was released
+		// after its caller's context expired) or it belongs to a different
+		// RunConsensus caller entirely; either way there's nothing to wake.
+		return
+	}
+	ch <- pipelineResult{result: &ConsensusResult{
+		TxHash:      txHash,
+		BlockHeight: txEvent.Height,
+		Code:        txEvent.Result.Code,
+	}}
+}
+
+func (p *consensusPipeline) handleBlockEvent(cmtrpctypes.ResultEvent) {
+	p.mu.Lock()
+	p.lastEventAt = time.Now()
+	p.mu.Unlock()
+}
+
+// submit registers txHash as awaited and returns the channel its result
+// will arrive on, or an error if the pipeline is already at maxPending -
+// RunConsensus's backpressure signal instead of growing the map unbounded.
+func (p *consensusPipeline) submit(txHash string) (chan pipelineResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.pending) >= p.maxPending {
+		return nil, fmt.Errorf("consensus pipeline at capacity (%d pending)", p.maxPending)
+	}
+	ch := make(chan pipelineResult, 1)
+	p.pending[txHash] = ch
+	return ch, nil
+}
+
+// release removes txHash from the pending map without it ever completing,
+// called once RunConsensus gives up waiting (its ctx expired first). The
+// underlying tx may still commit later; that event will find no one
+// listening and is simply dropped, counted here as orphaned rather than
+// left to leak a goroutine or channel.
+func (p *consensusPipeline) release(txHash string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, found := p.pending[txHash]; found {
+		delete(p.pending, txHash)
+		p.orphaned++
+	}
+}
+
+func (p *consensusPipeline) metrics() PipelineMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var lag time.Duration
+	if !p.lastEventAt.IsZero() {
+		lag = time.Since(p.lastEventAt)
+	}
+	return PipelineMetrics{
+		Pending:         len(p.pending),
+		SubscriptionLag: lag,
+		OrphanedHashes:  p.orphaned,
+	}
+}
@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consensusSchedulerDispatchTimeout bounds how long a single batch's
+// RunConsensus call may take before the scheduler gives up on it.
+const consensusSchedulerDispatchTimeout = 30 * time.Second
+
+// maxConsecutiveHighBatches bounds how many high-priority batches the
+// dispatcher will run back to back before forcing a check of the normal
+// queue, so a steady stream of high-priority submissions can't starve
+// normal ones indefinitely.
+const maxConsecutiveHighBatches = 4
+
+// ConsensusPriority classifies a submission for consensusScheduler's
+// dispatch ordering, modeled loosely on Neo's Policy contract flagging a
+// high-priority transaction class rather than giving every sender an
+// independent priority knob.
+type ConsensusPriority int
+
+const (
+	PriorityNormal ConsensusPriority = iota
+	PriorityHigh
+)
+
+// SchedulerConfig tunes consensusScheduler's batching, coalescing and
+// rate-limiting policy.
+type SchedulerConfig struct {
+	// MaxBatchSize is how many submissions for the same package_id may
+	// coalesce into a single dispatch job. The real ABCI application only
+	// decodes a bare srvreg.Transaction, so a job's submissions still
+	// reach RunConsensus as separate txs - this only bounds how many
+	// submissions share one dispatch/priority decision.
+	MaxBatchSize int
+	// MaxWait is how long the scheduler waits to coalesce further
+	// submissions for a package_id before flushing whatever it has.
+	MaxWait time.Duration
+	// PerOperatorQPS caps how many submissions per second one operator may
+	// make; submissions past the cap are rejected rather than queued.
+	// Zero disables rate limiting.
+	PerOperatorQPS float64
+	// MaxInFlight bounds how many batches may be submitted to the
+	// consensus backend concurrently.
+	MaxInFlight int
+}
+
+// DefaultSchedulerConfig returns conservative batching and rate-limiting
+// defaults.
+func DefaultSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		MaxBatchSize:   20,
+		MaxWait:        50 * time.Millisecond,
+		PerOperatorQPS: 20,
+		MaxInFlight:    32,
+	}
+}
+
+// SchedulerMetrics snapshots consensusScheduler's health for an operator
+// dashboard.
+type SchedulerMetrics struct {
+	// QueueDepth is how many submissions are currently waiting to be
+	// coalesced into a batch.
+	QueueDepth int
+	// InFlight is how many batches are currently being submitted to the
+	// consensus backend.
+	InFlight int
+	// BatchSizeCounts maps a batch size to how many batches of that size
+	// have been dispatched.
+	BatchSizeCounts map[int]int64
+	// RateLimited counts submissions rejected for exceeding PerOperatorQPS.
+	RateLimited int64
+	// Rejected counts batches dropped because the dispatch queue was full.
+	Rejected int64
+}
+
+// consensusSubmission is one caller's payload waiting to be coalesced into
+// a batch and dispatched.
+type consensusSubmission struct {
+	payload    ConsensusPayload
+	operatorID string
+	packageID  string
+	priority   ConsensusPriority
+	resultCh   chan consensusSubmissionResult
+}
+
+type consensusSubmissionResult struct {
+	result *ConsensusResult
+	err    *RepositoryError
+}
+
+// batchJob is a flushed, ready-to-dispatch group of submissions.
+type batchJob struct {
+	packageID string
+	items     []*consensusSubmission
+}
+
+// consensusScheduler sits between the handler layer and
+// Repository.RunConsensus: it coalesces submissions for the same
+// package_id made within MaxWait of each other into a single dispatch job,
+// prioritizes jobs containing a failed QC or urgent-label submission ahead
+// of routine ones, and enforces a per-operator rate limit and a
+// max-in-flight cap before anything reaches the consensus backend. This
+// keeps a burst of activity on one session from saturating the mempool (or
+// the single L1 endpoint) with independently-ordered, un-batched
+// submissions. A job's submissions are still dispatched to RunConsensus as
+// separate txs - the ABCI application has no way to decode a wire-level
+// batch - so coalescing only affects dispatch timing and priority.
+type consensusScheduler struct {
+	cfg  SchedulerConfig
+	repo *Repository
+
+	mu       sync.Mutex
+	pending  map[string][]*consensusSubmission
+	timers   map[string]*time.Timer
+	limiters map[string]*rateLimiter
+
+	highCh   chan *batchJob
+	normalCh chan *batchJob
+	sem      chan struct{}
+
+	metricsMu sync.Mutex
+	metrics   SchedulerMetrics
+}
+
+// newConsensusScheduler builds a consensusScheduler over repo and starts
+// its dispatch loop. Zero-valued MaxBatchSize, MaxWait and MaxInFlight fall
+// back to DefaultSchedulerConfig's values; a zero PerOperatorQPS is kept
+// and disables rate limiting.
+func newConsensusScheduler(repo *Repository, cfg SchedulerConfig) *consensusScheduler {
+	defaults := DefaultSchedulerConfig()
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaults.MaxBatchSize
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = defaults.MaxWait
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaults.MaxInFlight
+	}
+
+	s := &consensusScheduler{
+		cfg:      cfg,
+		repo:     repo,
+		pending:  make(map[string][]*consensusSubmission),
+		timers:   make(map[string]*time.Timer),
+		limiters: make(map[string]*rateLimiter),
+		highCh:   make(chan *batchJob, 256),
+		normalCh: make(chan *batchJob, 256),
+		sem:      make(chan struct{}, cfg.MaxInFlight),
+		metrics:  SchedulerMetrics{BatchSizeCounts: make(map[int]int64)},
+	}
+	go s.run()
+	return s
+}
+
+// Submit enqueues payload for batching under packageID, deriving its
+// priority from whatever qc_passed/priority fields it happens to carry,
+// and blocks until its batch has been dispatched and a result is
+// available, or ctx is canceled.
+func (s *consensusScheduler) Submit(ctx context.Context, payload ConsensusPayload, operatorID, packageID string) (*ConsensusResult, *RepositoryError) {
+	if !s.allow(operatorID) {
+		s.recordRejection("rate_limited")
+		return nil, &RepositoryError{
+			Code:    "RATE_LIMITED",
+			Message: "Operator exceeded consensus submission rate",
+			Detail:  fmt.Sprintf("operator %s is limited to %.1f submissions/sec", operatorID, s.cfg.PerOperatorQPS),
+		}
+	}
+
+	sub := &consensusSubmission{
+		payload:    payload,
+		operatorID: operatorID,
+		packageID:  packageID,
+		priority:   derivePriority(payload),
+		resultCh:   make(chan consensusSubmissionResult, 1),
+	}
+	s.enqueue(sub)
+
+	select {
+	case <-ctx.Done():
+		return nil, &RepositoryError{
+			Code:    "CONSENSUS_TIMEOUT",
+			Message: "Consensus submission timed out while queued",
+			Detail:  ctx.Err().Error(),
+		}
+	case res := <-sub.resultCh:
+		return res.result, res.err
+	}
+}
+
+// Metrics returns a point-in-time snapshot of the scheduler's queue depth,
+// in-flight count, batch size histogram and rejection counters.
+func (s *consensusScheduler) Metrics() SchedulerMetrics {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+
+	counts := make(map[int]int64, len(s.metrics.BatchSizeCounts))
+	for size, count := range s.metrics.BatchSizeCounts {
+		counts[size] = count
+	}
+	snapshot := s.metrics
+	snapshot.BatchSizeCounts = counts
+	snapshot.InFlight = len(s.sem)
+	return snapshot
+}
+
+// enqueue appends sub to its package_id's coalescing group, flushing
+// immediately once the group reaches MaxBatchSize, or starting a MaxWait
+// timer for the group if one isn't already running.
+func (s *consensusScheduler) enqueue(sub *consensusSubmission) {
+	s.mu.Lock()
+
+	group := append(s.pending[sub.packageID], sub)
+	s.pending[sub.packageID] = group
+	s.addQueueDepth(1)
+
+	if len(group) >= s.cfg.MaxBatchSize {
+		if timer, ok := s.timers[sub.packageID]; ok {
+			timer.Stop()
+			delete(s.timers, sub.packageID)
+		}
+		delete(s.pending, sub.packageID)
+		s.mu.Unlock()
+		s.submitBatch(sub.packageID, group)
+		return
+	}
+
+	if _, exists := s.timers[sub.packageID]; !exists {
+		packageID := sub.packageID
+		s.timers[packageID] = time.AfterFunc(s.cfg.MaxWait, func() { s.flush(packageID) })
+	}
+	s.mu.Unlock()
+}
+
+// flush submits whatever has accumulated for packageID once its MaxWait
+// timer fires. A no-op if the group was already flushed by hitting
+// MaxBatchSize first.
+func (s *consensusScheduler) flush(packageID string) {
+	s.mu.Lock()
+	group, ok := s.pending[packageID]
+	if !ok || len(group) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, packageID)
+	delete(s.timers, packageID)
+	s.mu.Unlock()
+
+	s.submitBatch(packageID, group)
+}
+
+// submitBatch hands a flushed group to the high or normal dispatch queue,
+// depending on whether any submission in it was derived as high priority.
+// A full queue means the scheduler is already at its configured
+// concurrency; rather than block the caller that triggered the flush, the
+// batch is rejected outright and every submission in it is failed.
+func (s *consensusScheduler) submitBatch(packageID string, group []*consensusSubmission) {
+	s.addQueueDepth(-len(group))
+
+	priority := PriorityNormal
+	for _, sub := range group {
+		if sub.priority == PriorityHigh {
+			priority = PriorityHigh
+			break
+		}
+	}
+
+	job := &batchJob{packageID: packageID, items: group}
+	target := s.normalCh
+	if priority == PriorityHigh {
+		target = s.highCh
+	}
+
+	select {
+	case target <- job:
+	default:
+		s.recordRejection("backpressure")
+		for _, sub := range group {
+			sub.resultCh <- consensusSubmissionResult{err: &RepositoryError{
+				Code:    "CONSENSUS_BACKPRESSURE",
+				Message: "Consensus scheduler dispatch queue is full",
+				Detail:  fmt.Sprintf("package %s batch of %d submissions dropped", packageID, len(group)),
+			}}
+		}
+	}
+}
+
+// run is the scheduler's dispatch loop: it prefers the high-priority queue,
+// but after maxConsecutiveHighBatches dispatches in a row it checks the
+// normal queue first, so a steady stream of high-priority work can't starve
+// normal submissions.
+func (s *consensusScheduler) run() {
+	consecutiveHigh := 0
+	for {
+		var job *batchJob
+		var fromHigh bool
+
+		if consecutiveHigh >= maxConsecutiveHighBatches {
+			select {
+			case job = <-s.normalCh:
+			default:
+				select {
+				case job = <-s.highCh:
+					fromHigh = true
+				case job = <-s.normalCh:
+				}
+			}
+		} else {
+			select {
+			case job = <-s.highCh:
+				fromHigh = true
+			default:
+				select {
+				case job = <-s.highCh:
+					fromHigh = true
+				case job = <-s.normalCh:
+				}
+			}
+		}
+
+		if fromHigh {
+			consecutiveHigh++
+		} else {
+			consecutiveHigh = 0
+		}
+
+		s.sem <- struct{}{}
+		go s.dispatch(job)
+	}
+}
+
+// dispatch submits every submission in job to RunConsensus as its own tx
+// - the real ABCI application only decodes a bare srvreg.Transaction, so
+// there is no wire-level batch to submit - and reports each submission's
+// own result back on its resultCh. It holds its MaxInFlight semaphore slot
+// until every submission in the job has a result, so MaxInFlight still
+// bounds how much concurrent work the job can push onto the backend.
+func (s *consensusScheduler) dispatch(job *batchJob) {
+	defer func() { <-s.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consensusSchedulerDispatchTimeout)
+	defer cancel()
+
+	s.recordBatch(len(job.items))
+
+	var wg sync.WaitGroup
+	wg.Add(len(job.items))
+	for _, sub := range job.items {
+		sub := sub
+		go func() {
+			defer wg.Done()
+			result, repoErr := s.repo.RunConsensus(ctx, sub.payload)
+			sub.resultCh <- consensusSubmissionResult{result: result, err: repoErr}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *consensusScheduler) allow(operatorID string) bool {
+	if s.cfg.PerOperatorQPS <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	limiter, ok := s.limiters[operatorID]
+	if !ok {
+		limiter = newRateLimiter(s.cfg.PerOperatorQPS)
+		s.limiters[operatorID] = limiter
+	}
+	s.mu.Unlock()
+	return limiter.allow()
+}
+
+func (s *consensusScheduler) addQueueDepth(delta int) {
+	s.metricsMu.Lock()
+	s.metrics.QueueDepth += delta
+	s.metricsMu.Unlock()
+}
+
+func (s *consensusScheduler) recordBatch(size int) {
+	s.metricsMu.Lock()
+	s.metrics.BatchSizeCounts[size]++
+	s.metricsMu.Unlock()
+}
+
+func (s *consensusScheduler) recordRejection(kind string) {
+	s.metricsMu.Lock()
+	switch kind {
+	case "rate_limited":
+		s.metrics.RateLimited++
+	case "backpressure":
+		s.metrics.Rejected++
+	}
+	s.metricsMu.Unlock()
+}
+
+// derivePriority inspects payload's already-JSON-marshaled shape for the
+// fields QualityCheck and LabelPackage submit - qc_passed and priority -
+// without needing to know its concrete type: a failed QC or a label marked
+// "urgent" both jump the queue ahead of routine submissions.
+func derivePriority(payload ConsensusPayload) ConsensusPriority {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return PriorityNormal
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return PriorityNormal
+	}
+	// A srvreg.Transaction carries the caller's fields as the JSON string
+	// in request.body, so look there rather than at its top level.
+	if request, ok := fields["request"].(map[string]interface{}); ok {
+		if body, ok := request["body"].(string); ok {
+			var bodyFields map[string]interface{}
+			if json.Unmarshal([]byte(body), &bodyFields) == nil {
+				fields = bodyFields
+			}
+		}
+	}
+	if passed, ok := fields["qc_passed"].(bool); ok && !passed {
+		return PriorityHigh
+	}
+	if p, ok := fields["priority"].(string); ok && strings.EqualFold(p, "urgent") {
+		return PriorityHigh
+	}
+	return PriorityNormal
+}
+
+// rateLimiter is a per-operator token bucket refilling at qps tokens/sec,
+// capped at qps tokens so a quiet operator can't bank an unbounded burst.
+type rateLimiter struct {
+	mu     sync.Mutex
+	qps    float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(qps float64) *rateLimiter {
+	return &rateLimiter{qps: qps, tokens: qps, last: time.Now()}
+}
+
+func (rl *rateLimiter) allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.qps
+	if rl.tokens > rl.qps {
+		rl.tokens = rl.qps
+	}
+	rl.last = now
+
+	if rl.tokens < 1 {
+		return false
+	}
+	rl.tokens--
+	return true
+}
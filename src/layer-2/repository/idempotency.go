@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"gorm.io/gorm"
+)
+
+// CheckIdempotency looks up (operatorID, key) against idempotency_records.
+// An empty key always misses, so idempotency stays opt-in per call.
+//
+// found=true means a cached response exists and has been unmarshaled into
+// out; the caller should return it instead of performing the write.
+// found=false with a nil error means key is unused and the caller should
+// proceed, passing the returned requestHash to RecordIdempotency once the
+// write succeeds. A non-nil error is either a database failure or
+// IDEMPOTENCY_CONFLICT (key reused with a different request).
+func (r *Repository) CheckIdempotency(ctx context.Context, operatorID, key string, request any, out any) (found bool, requestHash string, repoErr *RepositoryError) {
+	if key == "" {
+		return false, "", nil
+	}
+
+	requestHash, err := hashIdempotencyRequest(request)
+	if err != nil {
+		return false, "", &RepositoryError{
+			Code:    "SERIALIZATION_ERROR",
+			Message: "Failed to hash idempotent request",
+			Detail:  err.Error(),
+		}
+	}
+
+	var existing models.IdempotencyRecord
+	err = r.db.WithContext(ctx).Where("operator_id = ? AND idempotency_key = ?", operatorID, key).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, requestHash, nil
+	}
+	if err != nil {
+		return false, requestHash, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Database error",
+			Detail:  err.Error(),
+		}
+	}
+
+	if existing.RequestHash != requestHash {
+		return false, requestHash, &RepositoryError{
+			Code:    "IDEMPOTENCY_CONFLICT",
+			Message: "Idempotency key reused with a different request",
+			Detail:  fmt.Sprintf("key %q was already used by operator %s for a different request", key, operatorID),
+		}
+	}
+
+	if err := json.Unmarshal([]byte(existing.ResponseJSON), out); err != nil {
+		return false, requestHash, &RepositoryError{
+			Code:    "DESERIALIZATION_ERROR",
+			Message: "Failed to read cached idempotent response",
+			Detail:  err.Error(),
+		}
+	}
+	return true, requestHash, nil
+}
+
+// RecordIdempotency caches response under (operatorID, key) once a write
+// has succeeded, so a retry of the same request is served from this
+// record instead of being re-applied. A failure here is logged-and-ignored
+// by the caller: the write already committed, and caching is best-effort.
+func (r *Repository) RecordIdempotency(ctx context.Context, operatorID, key, requestHash string, response any) error {
+	if key == "" {
+		return nil
+	}
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(&models.IdempotencyRecord{
+		OperatorID:   operatorID,
+		Key:          key,
+		RequestHash:  requestHash,
+		ResponseJSON: string(responseBytes),
+		Status:       "completed",
+	}).Error
+}
+
+func hashIdempotencyRequest(request any) (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// PurgeIdempotencyRecords deletes cached idempotency responses older than
+// ttl, so idempotency_records doesn't grow without bound. Callers (a cron,
+// or worker.Pool) choose ttl and how often to invoke this; no default is
+// enforced here.
+func (r *Repository) PurgeIdempotencyRecords(ttl time.Duration) (int64, *RepositoryError) {
+	cutoff := time.Now().Add(-ttl)
+	result := r.db.Where("created_at < ?", cutoff).Delete(&models.IdempotencyRecord{})
+	if result.Error != nil {
+		return 0, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to purge idempotency records",
+			Detail:  result.Error.Error(),
+		}
+	}
+	return result.RowsAffected, nil
+}
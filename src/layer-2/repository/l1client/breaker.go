@@ -0,0 +1,85 @@
+package l1client
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState is a per-endpoint circuit breaker: after Threshold
+// consecutive failures it opens and fails fast until Cooldown has passed,
+// then allows one half-open trial request before deciding whether to close
+// (on success) or re-open (on failure).
+type breakerState struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu              sync.Mutex
+	consecutiveFail int
+	open            bool
+	openedAt        time.Time
+	halfOpenTrial   bool
+}
+
+func newBreakerState(threshold int, cooldown time.Duration) *breakerState {
+	return &breakerState{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may proceed, transitioning an open
+// breaker to half-open once the cooldown has elapsed.
+func (b *breakerState) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	if b.halfOpenTrial {
+		// A trial is already in flight; don't let a pile-up of callers all
+		// probe the backend at once.
+		return false
+	}
+	b.halfOpenTrial = true
+	return true
+}
+
+// probeAllowed reports whether the breaker would currently admit a
+// request, without consuming a half-open trial slot the way allow does.
+// currentPrimary uses this to pick a candidate endpoint; the actual
+// request still has to go through allow (in call) exactly once so the
+// trial it starts gets a recordSuccess/recordFailure to resolve it.
+func (b *breakerState) probeAllowed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	return !b.halfOpenTrial
+}
+
+func (b *breakerState) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFail = 0
+	b.open = false
+	b.halfOpenTrial = false
+}
+
+func (b *breakerState) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFail++
+	b.halfOpenTrial = false
+	if b.consecutiveFail >= b.threshold {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,322 @@
+// Package l1client fans a layer-2 commit submission out across every
+// configured L1 node, rather than trusting whichever address happens to be
+// r.l1Addresses[0], so a single unreachable or byzantine node can't stall or
+// corrupt a commit. It favors a rotating primary (hedging to the rest after
+// a short delay) and only reports success once a configurable quorum of
+// nodes agree on the resulting block.
+package l1client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CommitRequest is what SubmitCommit sends to every endpoint's
+// /session/{id}/commit-l1 route.
+type CommitRequest struct {
+	SessionID  string    `json:"session_id"`
+	OperatorID string    `json:"operator_id"`
+	MerkleRoot string    `json:"merkle_root"`
+	Timestamp  time.Time `json:"timestamp"`
+	// BeaconRound, Randomness and PrevSignature come from the beacon
+	// network active for the session, giving L1 verifiers an
+	// unpredictable-but-checkable ordering nonce that timestamp grinding
+	// can't forge.
+	BeaconRound   uint64 `json:"beacon_round"`
+	Randomness    string `json:"randomness"`
+	PrevSignature string `json:"prev_signature"`
+}
+
+// NodeResponse is one endpoint's answer to a CommitRequest. Err is set
+// instead of BlockHash/BlockHeight when the endpoint was unreachable, its
+// breaker was open, or it returned a non-2xx/unparseable response.
+type NodeResponse struct {
+	Endpoint    string
+	StatusCode  int
+	BlockHash   string
+	BlockHeight int64
+	Err         error
+}
+
+// QuorumResult is what SubmitCommit returns once enough endpoints agree on
+// the same BlockHash/BlockHeight.
+type QuorumResult struct {
+	BlockHash   string
+	BlockHeight int64
+	Agreeing    []string       // endpoints whose response matched, sorted
+	Responses   []NodeResponse // every response collected before quorum was reached
+}
+
+// DivergenceError is returned when SubmitCommit never saw a single
+// BlockHash/BlockHeight pair reach the configured quorum - either too many
+// endpoints were unreachable, or the live ones disagree about what was
+// committed.
+type DivergenceError struct {
+	Required  int
+	Responses []NodeResponse
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("l1client: no quorum of %d matching responses (got %d responses)", e.Required, len(e.Responses))
+}
+
+// Config tunes SubmitCommit's fan-out policy.
+type Config struct {
+	// Quorum is how many endpoints must return a matching BlockHash and
+	// BlockHeight before SubmitCommit succeeds, e.g. f+1 or 2f+1 of N.
+	Quorum int
+	// HedgeDelay is how long SubmitCommit waits after contacting the
+	// primary before also contacting the backup endpoints. Zero contacts
+	// every endpoint immediately.
+	HedgeDelay time.Duration
+	// RequestTimeout bounds each individual endpoint call.
+	RequestTimeout time.Duration
+	// BreakerThreshold is the number of consecutive failures that opens an
+	// endpoint's breaker, demoting it out of primary rotation.
+	BreakerThreshold int
+	// BreakerCooldown is how long an open breaker waits before allowing a
+	// half-open trial request.
+	BreakerCooldown time.Duration
+}
+
+// DefaultConfig returns sane defaults for quorum, hedging after 150ms and
+// opening a breaker after 3 consecutive failures.
+func DefaultConfig(quorum int) Config {
+	return Config{
+		Quorum:           quorum,
+		HedgeDelay:       150 * time.Millisecond,
+		RequestTimeout:   10 * time.Second,
+		BreakerThreshold: 3,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// Client submits a commit to a fixed set of L1 endpoints, favoring a
+// rotating primary but hedging to the rest after Config.HedgeDelay, and
+// waits for Config.Quorum of them to agree on the result.
+type Client struct {
+	endpoints  []string
+	httpClient *http.Client
+	cfg        Config
+
+	mu       sync.Mutex
+	primary  int
+	breakers map[string]*breakerState
+}
+
+// NewClient builds a Client over endpoints (host:port strings), with one
+// circuit breaker tracked per endpoint.
+func NewClient(endpoints []string, cfg Config) *Client {
+	breakers := make(map[string]*breakerState, len(endpoints))
+	for _, ep := range endpoints {
+		breakers[ep] = newBreakerState(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	return &Client{
+		endpoints:  endpoints,
+		httpClient: &http.Client{Timeout: cfg.RequestTimeout},
+		cfg:        cfg,
+		breakers:   breakers,
+	}
+}
+
+// SubmitCommit sends req to the current primary immediately and to every
+// other endpoint after Config.HedgeDelay, returning as soon as Config.Quorum
+// of the responses collected so far agree on BlockHash/BlockHeight. If no
+// such quorum can be reached once every endpoint has answered (or its
+// breaker is open), it returns a *DivergenceError carrying every response
+// for diagnostics.
+func (c *Client) SubmitCommit(ctx context.Context, req CommitRequest) (*QuorumResult, error) {
+	if len(c.endpoints) == 0 {
+		return nil, fmt.Errorf("l1client: no endpoints configured")
+	}
+
+	primary := c.currentPrimary()
+
+	// Buffered to len(endpoints) so a goroutine whose response arrives
+	// after SubmitCommit has already returned on quorum never blocks.
+	results := make(chan NodeResponse, len(c.endpoints))
+	var wg sync.WaitGroup
+
+	dispatch := func(endpoint string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- c.call(ctx, endpoint, req)
+		}()
+	}
+
+	dispatch(primary)
+
+	if c.cfg.HedgeDelay > 0 {
+		timer := time.NewTimer(c.cfg.HedgeDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	for _, ep := range c.endpoints {
+		if ep != primary {
+			dispatch(ep)
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var responses []NodeResponse
+	for resp := range results {
+		responses = append(responses, resp)
+		if result := quorumAmong(responses, c.cfg.Quorum); result != nil {
+			return result, nil
+		}
+	}
+
+	return nil, &DivergenceError{Required: c.cfg.Quorum, Responses: responses}
+}
+
+// call issues one POST to endpoint, updating its breaker and demoting it
+// out of primary rotation on failure.
+func (c *Client) call(ctx context.Context, endpoint string, req CommitRequest) NodeResponse {
+	breaker := c.breakerFor(endpoint)
+	if !breaker.allow() {
+		return NodeResponse{Endpoint: endpoint, Err: fmt.Errorf("circuit breaker open for %s", endpoint)}
+	}
+
+	bodyBytes, err := json.Marshal(req)
+	if err != nil {
+		breaker.recordFailure()
+		return NodeResponse{Endpoint: endpoint, Err: err}
+	}
+
+	url := fmt.Sprintf("http://%s/session/%s/commit-l1", endpoint, req.SessionID)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		breaker.recordFailure()
+		return NodeResponse{Endpoint: endpoint, Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		breaker.recordFailure()
+		c.demote(endpoint)
+		return NodeResponse{Endpoint: endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		breaker.recordFailure()
+		c.demote(endpoint)
+		return NodeResponse{Endpoint: endpoint, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode >= 300 {
+		breaker.recordFailure()
+		c.demote(endpoint)
+		return NodeResponse{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("l1 node %s returned status %d", endpoint, resp.StatusCode),
+		}
+	}
+
+	var parsed struct {
+		BlockHash   string `json:"block_hash"`
+		BlockHeight int64  `json:"block_height"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		breaker.recordFailure()
+		return NodeResponse{Endpoint: endpoint, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	breaker.recordSuccess()
+	return NodeResponse{
+		Endpoint:    endpoint,
+		StatusCode:  resp.StatusCode,
+		BlockHash:   parsed.BlockHash,
+		BlockHeight: parsed.BlockHeight,
+	}
+}
+
+// currentPrimary returns the first endpoint, starting from c.primary, whose
+// breaker currently allows a request, rotating c.primary to it. If every
+// breaker is open it falls back to the existing primary so a request is
+// still attempted.
+func (c *Client) currentPrimary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := 0; i < len(c.endpoints); i++ {
+		idx := (c.primary + i) % len(c.endpoints)
+		ep := c.endpoints[idx]
+		if c.breakers[ep].probeAllowed() {
+			c.primary = idx
+			return ep
+		}
+	}
+	return c.endpoints[c.primary%len(c.endpoints)]
+}
+
+// demote advances the primary rotation past endpoint after it fails, so the
+// next SubmitCommit call tries a different node first.
+func (c *Client) demote(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.endpoints[c.primary%len(c.endpoints)] == endpoint {
+		c.primary = (c.primary + 1) % len(c.endpoints)
+	}
+}
+
+func (c *Client) breakerFor(endpoint string) *breakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.breakers[endpoint]
+}
+
+// quorumAmong groups responses by matching BlockHash/BlockHeight and
+// returns a QuorumResult for the first group reaching quorum, or nil if
+// none has yet.
+func quorumAmong(responses []NodeResponse, quorum int) *QuorumResult {
+	type key struct {
+		hash   string
+		height int64
+	}
+	groups := make(map[key][]NodeResponse)
+	for _, r := range responses {
+		if r.Err != nil || r.BlockHash == "" {
+			continue
+		}
+		k := key{hash: r.BlockHash, height: r.BlockHeight}
+		groups[k] = append(groups[k], r)
+	}
+
+	for k, group := range groups {
+		if len(group) >= quorum {
+			agreeing := make([]string, len(group))
+			for i, r := range group {
+				agreeing[i] = r.Endpoint
+			}
+			sort.Strings(agreeing)
+			return &QuorumResult{
+				BlockHash:   k.hash,
+				BlockHeight: k.height,
+				Agreeing:    agreeing,
+				Responses:   responses,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Domain-separation prefixes so a leaf hash can never collide with an
+// internal node hash (the classic Merkle second-preimage attack).
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// merkleLeaf is one canonical field committed into a session's Merkle
+// tree, keyed by a stable name (e.g. "package", "item:ITEM-001") so
+// GetSessionProof can find it again later.
+type merkleLeaf struct {
+	Key  string `json:"key"`
+	Data []byte `json:"data"`
+}
+
+// MerkleProofStep is one sibling hash on the path from a leaf to the
+// root. IsRight reports whether the sibling sits to the right of the
+// node being hashed at this level (needed to reproduce the same pairing
+// order buildMerkleTree used).
+type MerkleProofStep struct {
+	SiblingHash string `json:"sibling_hash"`
+	IsRight     bool   `json:"is_right"`
+}
+
+// MerkleProof lets a client verify that Leaf was committed under Root
+// without trusting the layer-2 node: recompute the leaf hash, fold it
+// with each Steps sibling in order, and compare against Root.
+type MerkleProof struct {
+	Leaf     string            `json:"leaf"`
+	LeafHash string            `json:"leaf_hash"`
+	Steps    []MerkleProofStep `json:"steps"`
+	Root     string            `json:"root"`
+}
+
+func leafHash(leaf merkleLeaf) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(merkleLeafPrefix)
+	buf.WriteString(leaf.Key)
+	buf.WriteByte(0)
+	buf.Write(leaf.Data)
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:]
+}
+
+func nodeHash(left, right []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(merkleNodePrefix)
+	buf.Write(left)
+	buf.Write(right)
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:]
+}
+
+// buildMerkleTree hashes leaves (in the order given - callers must keep
+// that order stable across build and proof generation) and returns the
+// root along with every intermediate level, level[0] being the leaf
+// hashes, so a proof can be walked back out of it. A level with an odd
+// number of nodes duplicates its last node, the standard Merkle fix-up.
+func buildMerkleTree(leaves []merkleLeaf) (root []byte, levels [][][]byte) {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:], [][][]byte{{sum[:]}}
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = leafHash(leaf)
+	}
+	levels = [][][]byte{level}
+
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([][]byte, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next[i/2] = nodeHash(level[i], level[i+1])
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return level[0], levels
+}
+
+// merkleProofFor walks levels from the leaf at leafIndex up to the root,
+// collecting the sibling at each level.
+func merkleProofFor(levels [][][]byte, leafIndex int) []MerkleProofStep {
+	steps := make([]MerkleProofStep, 0, len(levels)-1)
+	idx := leafIndex
+	for level := 0; level < len(levels)-1; level++ {
+		nodes := levels[level]
+		isRightChild := idx%2 == 1
+		siblingIdx := idx - 1
+		if !isRightChild {
+			siblingIdx = idx + 1
+		}
+		if siblingIdx >= len(nodes) {
+			siblingIdx = idx // odd-length level duplicated the last node
+		}
+		steps = append(steps, MerkleProofStep{
+			SiblingHash: hex.EncodeToString(nodes[siblingIdx]),
+			IsRight:     !isRightChild,
+		})
+		idx /= 2
+	}
+	return steps
+}
+
+// VerifyMerkleProof recomputes leafData's hash and folds it with each
+// proof step, returning an error unless the result matches proof.Root.
+// Exported so a client holding the session's canonical leaves (e.g. from
+// GetSessionProof) can check them independently of the repository.
+func VerifyMerkleProof(leafKey string, leafData []byte, proof MerkleProof) error {
+	hash := leafHash(merkleLeaf{Key: leafKey, Data: leafData})
+	if hex.EncodeToString(hash) != proof.LeafHash {
+		return errors.New("merkle: leaf hash does not match proof")
+	}
+	for _, step := range proof.Steps {
+		sibling, err := hex.DecodeString(step.SiblingHash)
+		if err != nil {
+			return errors.New("merkle: malformed sibling hash in proof")
+		}
+		if step.IsRight {
+			hash = nodeHash(hash, sibling)
+		} else {
+			hash = nodeHash(sibling, hash)
+		}
+	}
+	if hex.EncodeToString(hash) != proof.Root {
+		return errors.New("merkle: proof does not reconstruct the committed root")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// CommitJob is a durable queue entry for an asynchronous L1 commit attempt.
+// Repository.EnqueueCommit creates one instead of calling CommitToL1
+// synchronously inside CommitSession's DB transaction, so a slow or failed
+// L1 round-trip no longer forces that transaction to roll back and lose
+// the QC/label work already recorded for the session.
+type CommitJob struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	SessionID   string    `gorm:"column:session_id;type:varchar(50);uniqueIndex;not null"`
+	Payload     string    `gorm:"column:payload;type:text;not null"`
+	Status      string    `gorm:"column:status;type:varchar(20);not null;default:'queued'"` // queued, processing, committed, failed
+	Attempts    int       `gorm:"column:attempts;not null;default:0"`
+	NextRetryAt time.Time `gorm:"column:next_retry_at;index"`
+	LastError   string    `gorm:"column:last_error;type:text"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
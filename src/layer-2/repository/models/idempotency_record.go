@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord caches a write method's response under
+// (operator_id, idempotency_key), so a client retrying a request (e.g.
+// after a timeout) gets the original result back instead of creating a
+// second session, QC record, or label. Repository.PurgeIdempotencyRecords
+// removes rows older than a caller-supplied TTL.
+type IdempotencyRecord struct {
+	OperatorID   string    `gorm:"column:operator_id;primaryKey;type:varchar(50)"`
+	Key          string    `gorm:"column:idempotency_key;primaryKey;type:varchar(100)"`
+	RequestHash  string    `gorm:"column:request_hash;type:varchar(64);not null"`
+	ResponseJSON string    `gorm:"column:response_json;type:text;not null"`
+	Status       string    `gorm:"column:status;type:varchar(20);not null"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// NotificationEvent is a durable outbox entry for an asynchronous
+// courier/operator notification (package.labeled, session.committed,
+// qc.failed, ...). Repository.enqueueNotificationEvent writes one inside
+// the same DB transaction as the state change it describes, so a
+// committed session and its outbound notifications never diverge; a
+// background worker then drains queued rows and delivers them to every
+// registered srvreg.Notifier with exponential backoff, the same shape
+// CommitJob uses for L1 commit retries.
+type NotificationEvent struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	SessionID string `gorm:"column:session_id;type:varchar(50);index;not null"`
+	EventType string `gorm:"column:event_type;type:varchar(50);not null"`
+	Payload   string `gorm:"column:payload;type:text;not null"`
+	// Signature is an HMAC-SHA256 over Payload, hex-encoded, so a courier
+	// receiving a webhook can verify it actually came from this node.
+	Signature   string    `gorm:"column:signature;type:varchar(128)"`
+	Status      string    `gorm:"column:status;type:varchar(20);not null;default:'queued'"` // queued, delivered, failed
+	Attempts    int       `gorm:"column:attempts;not null;default:0"`
+	NextRetryAt time.Time `gorm:"column:next_retry_at;index"`
+	LastError   string    `gorm:"column:last_error;type:text"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
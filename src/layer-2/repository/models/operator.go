@@ -0,0 +1,17 @@
+package models
+
+// Operator represents a person authorized to act on sessions - scanning,
+// validating, running QC, labeling, and committing. Role and AccessLevel
+// back the RBAC checks in srvreg (see RequireRole/RequireAccessLevel):
+// Role is a free-form job title ("Quality Control Specialist", "Shipping
+// Specialist", ...), AccessLevel is one of "Basic"/"Standard"/"Admin",
+// ranked in that order.
+type Operator struct {
+	ID          string `gorm:"column:operator_id;primaryKey;type:varchar(50)"`
+	Name        string `gorm:"column:name;type:varchar(100);not null"`
+	Role        string `gorm:"column:role;type:varchar(50)"`
+	AccessLevel string `gorm:"column:access_level;type:varchar(20);default:'Basic'"`
+
+	// Relationships
+	Sessions []Session `gorm:"foreignKey:OperatorID"`
+}
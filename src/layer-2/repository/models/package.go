@@ -11,6 +11,10 @@ type Package struct {
 	Signature      string    `gorm:"column:signature;type:text"`
 	IsTrusted      bool      `gorm:"column:is_trusted;default:false"`
 	Status         string    `gorm:"column:status;type:varchar(20);default:'pending'"`
+	// VerifierAlgorithm and VerifierKeyID record which SupplierKey accepted
+	// this package's signature, set by Repository.ValidatePackage.
+	VerifierAlgorithm string `gorm:"column:verifier_algorithm;type:varchar(30)"`
+	VerifierKeyID     string `gorm:"column:verifier_key_id;type:varchar(50)"`
 
 	// Relationships
 	Items     []Item     `gorm:"foreignKey:PackageID"`
@@ -0,0 +1,24 @@
+package models
+
+// QCSeverity grades how serious a QCIssue is. QualityCheck auto-fails a
+// package if any issue is QCSeverityCritical, regardless of the qcPassed
+// flag the caller supplied.
+type QCSeverity string
+
+const (
+	QCSeverityInfo     QCSeverity = "info"
+	QCSeverityMinor    QCSeverity = "minor"
+	QCSeverityMajor    QCSeverity = "major"
+	QCSeverityCritical QCSeverity = "critical"
+)
+
+// QCIssue is one defect recorded against a QCRecord. Replaces the old
+// CSV-in-a-string scheme so issues are queryable and carry a severity.
+type QCIssue struct {
+	ID          string     `gorm:"column:qc_issue_id;primaryKey;type:varchar(50)"`
+	QCRecordID  string     `gorm:"column:qc_record_id;type:varchar(50);index;not null"`
+	Code        string     `gorm:"column:code;type:varchar(50);not null"`
+	Severity    QCSeverity `gorm:"column:severity;type:varchar(20);not null"`
+	Description string     `gorm:"column:description;type:text"`
+	PhotoURL    string     `gorm:"column:photo_url;type:varchar(255)"`
+}
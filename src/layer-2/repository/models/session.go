@@ -12,8 +12,17 @@ type Session struct {
 	Operator    *Operator `gorm:"foreignKey:OperatorID"`
 	IsCommitted bool      `gorm:"column:is_committed;default:false"`
 	TxHash      *string   `gorm:"column:tx_hash;type:varchar(66)"` // Null if not committed
+	MerkleRoot  string    `gorm:"column:merkle_root;type:varchar(64)"`
 	Package     *Package  `gorm:"foreignKey:SessionID"`
 
+	// StartHeight is the L1 height observed when the session was created,
+	// used to pick which beacon network's rounds are valid for it.
+	StartHeight int64 `gorm:"column:start_height;default:0"`
+	// LastBeaconRound is the round number stamped on this session's most
+	// recent successful L1 commit, so a later commit can be rejected if it
+	// carries an older (replayed or stale) round.
+	LastBeaconRound uint64 `gorm:"column:last_beacon_round;default:0"`
+
 	// Relationships
 	QCRecords   []QCRecord   `gorm:"foreignKey:SessionID"`
 	Labels      []Label      `gorm:"foreignKey:SessionID"`
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SessionBlob is the off-chain body behind a committed session's on-chain
+// Merkle root: the full set of canonical leaves the root was computed
+// over, kept so Repository.GetSessionProof can regenerate a sibling path
+// without re-deriving the leaves from scratch.
+type SessionBlob struct {
+	RootHash   string    `gorm:"column:root_hash;primaryKey;type:varchar(64)"`
+	SessionID  string    `gorm:"column:session_id;type:varchar(50);index;not null"`
+	LeavesJSON string    `gorm:"column:leaves_json;type:text;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
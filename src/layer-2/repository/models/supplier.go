@@ -7,5 +7,6 @@ type Supplier struct {
 	Location string `gorm:"column:location;type:varchar(100)"`
 
 	// Relationships
-	Packages []Package `gorm:"foreignKey:SupplierID"`
+	Packages []Package     `gorm:"foreignKey:SupplierID"`
+	Keys     []SupplierKey `gorm:"foreignKey:SupplierID"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SupplierKey is a supplier's registered public key, checked by
+// Repository.ValidatePackage against a package's signature. A supplier may
+// have more than one live key at once (e.g. mid-rotation); ValidatePackage
+// tries each until one verifies.
+type SupplierKey struct {
+	ID         string    `gorm:"column:key_id;primaryKey;type:varchar(50)"`
+	SupplierID string    `gorm:"column:supplier_id;type:varchar(50);index;not null"`
+	Supplier   *Supplier `gorm:"foreignKey:SupplierID"`
+	Algorithm  string    `gorm:"column:algorithm;type:varchar(30);not null"` // "ed25519", "ecdsa-secp256k1", "x509"
+	PublicKey  []byte    `gorm:"column:public_key;type:bytea;not null"`
+	Revoked    bool      `gorm:"column:revoked;default:false"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"gorm.io/gorm"
+)
+
+// labelEventPayload is what LabelPackage enqueues as a "package.labeled"
+// notification event.
+type labelEventPayload struct {
+	SessionID   string `json:"session_id"`
+	PackageID   string `json:"package_id"`
+	LabelID     string `json:"label_id"`
+	CourierID   string `json:"courier_id"`
+	Destination string `json:"destination"`
+	Priority    string `json:"priority"`
+}
+
+// qcFailedEventPayload is what QualityCheck enqueues as a "qc.failed"
+// notification event when the package doesn't pass inspection.
+type qcFailedEventPayload struct {
+	SessionID  string         `json:"session_id"`
+	PackageID  string         `json:"package_id"`
+	QCRecordID string         `json:"qc_record_id"`
+	Issues     []QCIssueInput `json:"issues"`
+}
+
+// sessionCommittedEventPayload is what finalizeCommitJob enqueues as a
+// "session.committed" notification event once the L1 round-trip in
+// ProcessCommitJob succeeds. TxHash is the hash CommitToL1 reports for the
+// commit - the block hash the L1 quorum agreed on - so a courier receiving
+// this event can look the commit up on the chain, not just trust this
+// node's say-so.
+type sessionCommittedEventPayload struct {
+	SessionID   string `json:"session_id"`
+	TxHash      string `json:"tx_hash"`
+	BlockHeight int64  `json:"block_height"`
+}
+
+const (
+	// maxNotificationAttempts is how many delivery attempts the notification
+	// worker makes at an event before giving up and marking it "failed" for
+	// an operator to inspect via GET /sessions/{id}/notifications.
+	maxNotificationAttempts = 8
+	// maxNotificationBackoff caps the exponential backoff between retries.
+	maxNotificationBackoff = 5 * time.Minute
+)
+
+// signNotificationPayload computes an HMAC-SHA256 signature over payload
+// using r's notification secret, so a courier's webhook handler can
+// verify an event actually came from this node rather than from whoever
+// guessed its endpoint.
+func (r *Repository) signNotificationPayload(payload string) string {
+	mac := hmac.New(sha256.New, r.notificationSecret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueueNotificationEvent writes a queued NotificationEvent inside dbTx,
+// the same open transaction as the state change eventType describes, so
+// a rollback of one rolls back the other - an operator-visible event and
+// a BFT-committed state change can never diverge. Callers commit dbTx
+// themselves afterward; this never calls Commit or Rollback itself.
+func (r *Repository) enqueueNotificationEvent(dbTx *gorm.DB, sessionID, eventType string, payload interface{}) *RepositoryError {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return &RepositoryError{
+			Code:    "SERIALIZATION_ERROR",
+			Message: "Failed to serialize notification payload",
+			Detail:  err.Error(),
+		}
+	}
+
+	event := &models.NotificationEvent{
+		SessionID:   sessionID,
+		EventType:   eventType,
+		Payload:     string(payloadBytes),
+		Signature:   r.signNotificationPayload(string(payloadBytes)),
+		Status:      "queued",
+		NextRetryAt: time.Now(),
+	}
+	if err := dbTx.Create(event).Error; err != nil {
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to enqueue notification event",
+			Detail:  err.Error(),
+		}
+	}
+	return nil
+}
+
+// ReadyNotificationEvents returns up to limit queued events whose
+// NextRetryAt has passed, for the notification worker to claim.
+func (r *Repository) ReadyNotificationEvents(limit int) ([]models.NotificationEvent, *RepositoryError) {
+	var events []models.NotificationEvent
+	err := r.db.Where("status = ? AND next_retry_at <= ?", "queued", time.Now()).
+		Order("next_retry_at").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to list ready notification events",
+			Detail:  err.Error(),
+		}
+	}
+	return events, nil
+}
+
+// SessionNotifications returns every notification event ever enqueued for
+// sessionID, most recent first, backing GET /sessions/{id}/notifications.
+func (r *Repository) SessionNotifications(sessionID string) ([]models.NotificationEvent, *RepositoryError) {
+	var events []models.NotificationEvent
+	err := r.db.Where("session_id = ?", sessionID).Order("created_at desc").Find(&events).Error
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to list session notifications",
+			Detail:  err.Error(),
+		}
+	}
+	return events, nil
+}
+
+// MarkNotificationDelivered marks event delivered once every registered
+// Notifier has accepted it.
+func (r *Repository) MarkNotificationDelivered(event models.NotificationEvent) *RepositoryError {
+	event.Status = "delivered"
+	if err := r.db.Save(&event).Error; err != nil {
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to mark notification delivered",
+			Detail:  err.Error(),
+		}
+	}
+	return nil
+}
+
+// RetryNotificationEvent records a delivery failure and schedules event's
+// next attempt with exponential backoff (2^attempts seconds, capped at
+// maxNotificationBackoff), or marks it "failed" once
+// maxNotificationAttempts is reached - the same shape
+// Repository.retryCommitJob uses for commit_jobs.
+func (r *Repository) RetryNotificationEvent(event models.NotificationEvent, cause error) *RepositoryError {
+	event.Attempts++
+	event.LastError = cause.Error()
+
+	if event.Attempts >= maxNotificationAttempts {
+		event.Status = "failed"
+	} else {
+		backoff := time.Duration(1<<uint(event.Attempts)) * time.Second
+		if backoff > maxNotificationBackoff {
+			backoff = maxNotificationBackoff
+		}
+		event.NextRetryAt = time.Now().Add(backoff)
+	}
+
+	if err := r.db.Save(&event).Error; err != nil {
+		return &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to update notification event after failure",
+			Detail:  err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"gorm.io/gorm"
+)
+
+// GetOperatorByID looks up the Operator identified by operatorID, so
+// callers (srvreg's TokenMiddleware, login endpoint) can resolve a bearer
+// token or body-supplied ID to the Role/AccessLevel an RBAC check needs.
+func (r *Repository) GetOperatorByID(ctx context.Context, operatorID string) (*models.Operator, *RepositoryError) {
+	var operator models.Operator
+	err := r.db.WithContext(ctx).Where("operator_id = ?", operatorID).First(&operator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &RepositoryError{
+			Code:    "NOT_FOUND",
+			Message: "Operator not found",
+			Detail:  "operator " + operatorID + " does not exist",
+		}
+	}
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Database error",
+			Detail:  err.Error(),
+		}
+	}
+	return &operator, nil
+}
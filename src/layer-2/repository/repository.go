@@ -1,23 +1,20 @@
 package repository
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"strings"
 	"time"
 
+	"github.com/ahmadzakiakmal/thesis/src/beacon"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/l1client"
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
-	cmtrpc "github.com/cometbft/cometbft/rpc/client/local"
-	cmtrpctypes "github.com/cometbft/cometbft/rpc/core/types"
-	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
 	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -77,6 +74,11 @@ type ConsensusResult struct {
 	BlockHeight int64
 	Code        uint32
 	Error       error
+	// BeaconRound is the round CommitToL1 stamped this commit with, zero
+	// if no beacon network is configured. finalizeCommitJob persists it
+	// onto the session so the next commit's stale-round guard has
+	// something real to compare against.
+	BeaconRound uint64
 }
 
 // RepositoryError represent an error in the repository layer (db/rpc)
@@ -87,19 +89,29 @@ type RepositoryError struct {
 }
 
 type Repository struct {
-	db          *gorm.DB
-	rpcClient   *cmtrpc.Local
-	l1Addresses []string
-	httpClient  *http.Client
+	db                 *gorm.DB
+	backend            ConsensusBackend
+	consensusPipeline  *consensusPipeline
+	consensusScheduler *consensusScheduler
+	l1Client           *l1client.Client
+	l1ClientCfg        l1client.Config
+	beaconNetworks     *beacon.BeaconNetworks
+	verifiers          map[string]SignatureVerifier
+	notificationSecret []byte
 }
 
-func NewRepository() *Repository {
-	httpClient := http.Client{
-		Timeout: 10 * time.Second,
-	}
-	return &Repository{
-		httpClient: &httpClient,
-	}
+// NewRepository builds a Repository whose consensus submissions are
+// batched and prioritized according to schedulerCfg (see
+// DefaultSchedulerConfig for sane defaults).
+func NewRepository(schedulerCfg SchedulerConfig) *Repository {
+	repo := &Repository{
+		verifiers: make(map[string]SignatureVerifier),
+	}
+	repo.consensusScheduler = newConsensusScheduler(repo, schedulerCfg)
+	repo.RegisterVerifier(ed25519Verifier{})
+	repo.RegisterVerifier(ecdsaSecp256k1Verifier{})
+	repo.RegisterVerifier(&x509Verifier{})
+	return repo
 }
 
 func (r *Repository) ConnectDB(dsn string) {
@@ -134,6 +146,12 @@ func (r *Repository) Migrate() {
 		&models.QCRecord{},
 		&models.Label{},
 		&models.Transaction{},
+		&models.CommitJob{},
+		&models.SupplierKey{},
+		&models.IdempotencyRecord{},
+		&models.QCIssue{},
+		&models.SessionBlob{},
+		&models.NotificationEvent{},
 	)
 
 	// Log migration completion
@@ -254,9 +272,64 @@ func (r *Repository) Seed() {
 	log.Println("Database seeding completed successfully")
 }
 
-func (r *Repository) SetupRpcClient(rpcClient *cmtrpc.Local, l1Addresses []string) {
-	r.rpcClient = rpcClient
-	r.l1Addresses = l1Addresses
+// SetupRpcClient wires the consensus backend this Repository submits
+// transactions through. backend is typically a *cometBFTBackend built with
+// NewCometBFTBackend, but any ConsensusBackend (a HotStuff client, an
+// ABCI++-flavored Tendermint client, or an in-process mock for benchmarks)
+// can be substituted without changing RunConsensus. cfg tunes CommitToL1's
+// quorum size, hedge delay and circuit breaker thresholds across
+// l1Addresses; pass l1client.DefaultConfig(quorum) for sane defaults. It
+// also starts the long-lived consensusPipeline subscription RunConsensus
+// waits on, running until ctx is canceled.
+func (r *Repository) SetupRpcClient(ctx context.Context, backend ConsensusBackend, l1Addresses []string, cfg l1client.Config) {
+	r.backend = backend
+	r.l1ClientCfg = cfg
+	if len(l1Addresses) > 0 {
+		r.l1Client = l1client.NewClient(l1Addresses, cfg)
+	}
+
+	r.consensusPipeline = newConsensusPipeline(backend, defaultMaxPendingConsensusTx)
+	go r.consensusPipeline.run(ctx)
+}
+
+// ConsensusPipelineMetrics reports the async consensus pipeline's current
+// pending-tx count, subscription lag, and orphaned-wait count, for an
+// operator dashboard or /metrics endpoint.
+func (r *Repository) ConsensusPipelineMetrics() PipelineMetrics {
+	if r.consensusPipeline == nil {
+		return PipelineMetrics{}
+	}
+	return r.consensusPipeline.metrics()
+}
+
+// SubmitConsensus queues payload with the consensus scheduler instead of
+// calling RunConsensus directly, so same-package_id submissions arriving
+// within the scheduler's coalescing window are batched, prioritized, and
+// rate-limited per operator before reaching the consensus backend.
+func (r *Repository) SubmitConsensus(ctx context.Context, payload ConsensusPayload, operatorID, packageID string) (*ConsensusResult, *RepositoryError) {
+	return r.consensusScheduler.Submit(ctx, payload, operatorID, packageID)
+}
+
+// ConsensusSchedulerMetrics reports the scheduler's current queue depth,
+// in-flight batch count, batch size distribution, and rejection counters,
+// for an operator dashboard or /metrics endpoint.
+func (r *Repository) ConsensusSchedulerMetrics() SchedulerMetrics {
+	return r.consensusScheduler.Metrics()
+}
+
+// SetupBeacon wires the beacon network router CommitToL1 draws rounds from.
+// Until this is called, CommitToL1 stamps commits with no beacon round at
+// all, matching its previous time.Now()-only behavior.
+func (r *Repository) SetupBeacon(networks *beacon.BeaconNetworks) {
+	r.beaconNetworks = networks
+}
+
+// SetupNotifications sets the HMAC secret enqueueNotificationEvent signs
+// outbox payloads with, so a courier's webhook handler can verify an
+// event actually came from this node. Until this is called, events are
+// still enqueued, just signed with an empty secret.
+func (r *Repository) SetupNotifications(secret []byte) {
+	r.notificationSecret = secret
 }
 
 func ptrString(s string) *string {
@@ -267,17 +340,38 @@ func ptrString(s string) *string {
 
 // CreateSession creates a new session in the Database
 func (r *Repository) CreateSession(
+	ctx context.Context,
 	sessionID,
-	operatorID string,
+	operatorID,
+	idempotencyKey string,
 ) (*models.Session, *RepositoryError) {
+	var cached models.Session
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, operatorID, idempotencyKey, sessionID, &cached)
+	if repoErr != nil {
+		return nil, repoErr
+	}
+	if found {
+		return &cached, nil
+	}
+
+	var startHeight int64
+	if r.backend != nil {
+		if height, err := r.backend.LatestHeight(context.Background()); err == nil {
+			startHeight = height
+		} else {
+			logging.FromContext(ctx).Warn("failed to fetch L1 height", "session_id", sessionID, "error", err.Error())
+		}
+	}
+
 	session := models.Session{
 		ID:          sessionID,
 		Status:      "active",
 		IsCommitted: false,
 		OperatorID:  operatorID,
+		StartHeight: startHeight,
 	}
 
-	dbTx := r.db.Begin()
+	dbTx := r.db.WithContext(ctx).Begin()
 	err := dbTx.Create(&session).Error
 	if err != nil {
 		dbTx.Rollback()
@@ -306,13 +400,30 @@ func (r *Repository) CreateSession(
 		}
 	}
 
+	if err := r.RecordIdempotency(ctx, operatorID, idempotencyKey, requestHash, &session); err != nil {
+		logging.FromContext(ctx).Warn("failed to record idempotency", "session_id", sessionID, "error", err.Error())
+	}
+
 	return &session, nil
 }
 
 // ScanPackage returns the expected item and signature of the package
-func (r *Repository) ScanPackage(sessionID, packageID string) (*models.Package, *RepositoryError) {
+func (r *Repository) ScanPackage(ctx context.Context, sessionID, packageID, operatorID, idempotencyKey string) (*models.Package, *RepositoryError) {
+	type scanPackageRequest struct {
+		SessionID string `json:"session_id"`
+		PackageID string `json:"package_id"`
+	}
+	var cached models.Package
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, operatorID, idempotencyKey, scanPackageRequest{sessionID, packageID}, &cached)
+	if repoErr != nil {
+		return nil, repoErr
+	}
+	if found {
+		return &cached, nil
+	}
+
 	// Begin transaction
-	dbTx := r.db.Begin()
+	dbTx := r.db.WithContext(ctx).Begin()
 
 	// Find the package by ID with preloaded items and supplier
 	var pkg models.Package
@@ -356,12 +467,32 @@ func (r *Repository) ScanPackage(sessionID, packageID string) (*models.Package,
 		}
 	}
 
+	if err := r.RecordIdempotency(ctx, operatorID, idempotencyKey, requestHash, &pkg); err != nil {
+		logging.FromContext(ctx).Warn("failed to record idempotency", "package_id", packageID, "error", err.Error())
+	}
+
 	return &pkg, nil
 }
 
-// ValidatePackage validates the supplier's signature, links package to the session
-func (r *Repository) ValidatePackage(supplierSignature, packageID, SessionID string) (*models.Package, *RepositoryError) {
-	dbTx := r.db.Begin()
+// ValidatePackage verifies the supplier's signature over the package's
+// canonical payload against the supplier's registered keys, and if it
+// checks out, links the package to the session.
+func (r *Repository) ValidatePackage(ctx context.Context, supplierSignature, packageID, SessionID, operatorID, idempotencyKey string) (*models.Package, *RepositoryError) {
+	type validatePackageRequest struct {
+		SupplierSignature string `json:"supplier_signature"`
+		PackageID         string `json:"package_id"`
+		SessionID         string `json:"session_id"`
+	}
+	var cached models.Package
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, operatorID, idempotencyKey, validatePackageRequest{supplierSignature, packageID, SessionID}, &cached)
+	if repoErr != nil {
+		return nil, repoErr
+	}
+	if found {
+		return &cached, nil
+	}
+
+	dbTx := r.db.WithContext(ctx).Begin()
 
 	var pkg models.Package
 	err := dbTx.Preload("Items").Preload("Supplier").Where("package_id = ?", packageID).First(&pkg).Error
@@ -381,10 +512,36 @@ func (r *Repository) ValidatePackage(supplierSignature, packageID, SessionID str
 		}
 	}
 
-	// * For this PoC, assume all signature is valid
+	var keys []models.SupplierKey
+	if err := dbTx.Where("supplier_id = ? AND revoked = ?", pkg.SupplierID, false).Find(&keys).Error; err != nil {
+		dbTx.Rollback()
+		return nil, &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Database error",
+			Detail:  err.Error(),
+		}
+	}
+
+	signature, decodeErr := base64.StdEncoding.DecodeString(supplierSignature)
+	if decodeErr != nil {
+		signature = []byte(supplierSignature)
+	}
+
+	verifiedKey, verifyErr := r.verifySignature(keys, canonicalPackagePayload(&pkg), signature)
+	if verifyErr != nil {
+		dbTx.Rollback()
+		return nil, &RepositoryError{
+			Code:    "SIGNATURE_INVALID",
+			Message: "Supplier signature did not verify",
+			Detail:  verifyErr.Error(),
+		}
+	}
+
 	pkg.IsTrusted = true
 	pkg.SessionID = &SessionID
 	pkg.Status = "validated"
+	pkg.VerifierAlgorithm = verifiedKey.Algorithm
+	pkg.VerifierKeyID = verifiedKey.ID
 
 	err = dbTx.Save(&pkg).Error
 	if err != nil {
@@ -405,12 +562,33 @@ func (r *Repository) ValidatePackage(supplierSignature, packageID, SessionID str
 		}
 	}
 
+	if err := r.RecordIdempotency(ctx, operatorID, idempotencyKey, requestHash, &pkg); err != nil {
+		logging.FromContext(ctx).Warn("failed to record idempotency", "package_id", packageID, "error", err.Error())
+	}
+
 	return &pkg, nil
 }
 
+// qualityCheckResult bundles QualityCheck's two return values so they can
+// be cached and replayed together under one idempotency record.
+type qualityCheckResult struct {
+	Package  models.Package  `json:"package"`
+	QCRecord models.QCRecord `json:"qc_record"`
+}
+
+// QCIssueInput is one defect reported to QualityCheck. Passing and
+// persisting these as structured rows (see models.QCIssue) replaces the
+// old CSV-in-a-string scheme.
+type QCIssueInput struct {
+	Code        string            `json:"code"`
+	Severity    models.QCSeverity `json:"severity"`
+	Description string            `json:"description"`
+	PhotoURL    string            `json:"photo_url"`
+}
+
 // QualityCheck adds a QC Record to a Package
-func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []string) (*models.Package, *models.QCRecord, *RepositoryError) {
-	dbTx := r.db.Begin()
+func (r *Repository) QualityCheck(ctx context.Context, sessionID string, qcPassed bool, issues []QCIssueInput, idempotencyKey string) (*models.Package, *models.QCRecord, *RepositoryError) {
+	dbTx := r.db.WithContext(ctx).Begin()
 
 	var session models.Session
 	err := dbTx.Where("session_id = ?", sessionID).First(&session).Error
@@ -430,6 +608,22 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		}
 	}
 
+	type qualityCheckRequest struct {
+		SessionID string         `json:"session_id"`
+		QCPassed  bool           `json:"qc_passed"`
+		Issues    []QCIssueInput `json:"issues"`
+	}
+	var cached qualityCheckResult
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, session.OperatorID, idempotencyKey, qualityCheckRequest{sessionID, qcPassed, issues}, &cached)
+	if repoErr != nil {
+		dbTx.Rollback()
+		return nil, nil, repoErr
+	}
+	if found {
+		dbTx.Rollback()
+		return &cached.Package, &cached.QCRecord, nil
+	}
+
 	// Find the package by ID
 	var pkg models.Package
 	err = dbTx.Preload("Items").Where("session_id = ?", sessionID).First(&pkg).Error
@@ -459,19 +653,15 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		}
 	}
 
-	// Convert issues slice to string for storage
-	issuesStr := ""
-	if len(issues) > 0 {
-		issuesBytes, err := json.Marshal(issues)
-		if err != nil {
-			dbTx.Rollback()
-			return nil, nil, &RepositoryError{
-				Code:    "MARSHALING_ERROR",
-				Message: "Failed to process issues data",
-				Detail:  err.Error(),
-			}
+	// A critical issue auto-fails the package regardless of the qcPassed
+	// flag the caller supplied - an inspector shouldn't be able to wave
+	// through a critical defect by mistake.
+	overallPassed := qcPassed
+	for _, issue := range issues {
+		if issue.Severity == models.QCSeverityCritical {
+			overallPassed = false
+			break
 		}
-		issuesStr = string(issuesBytes)
 	}
 
 	// Generate QC record ID
@@ -484,9 +674,8 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		ID:          qcID,
 		PackageID:   pkg.ID,
 		SessionID:   sessionID,
-		Passed:      qcPassed,
+		Passed:      overallPassed,
 		InspectorID: session.OperatorID,
-		Issues:      issuesStr,
 	}
 
 	// Save QC record
@@ -500,8 +689,31 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		}
 	}
 
+	qcRecord.Issues = make([]models.QCIssue, len(issues))
+	for i, issueInput := range issues {
+		issueHash := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", qcID, i)))
+		qcRecord.Issues[i] = models.QCIssue{
+			ID:          fmt.Sprintf("QCI-%s", hex.EncodeToString(issueHash[:])[:16]),
+			QCRecordID:  qcID,
+			Code:        issueInput.Code,
+			Severity:    issueInput.Severity,
+			Description: issueInput.Description,
+			PhotoURL:    issueInput.PhotoURL,
+		}
+	}
+	if len(qcRecord.Issues) > 0 {
+		if err := dbTx.Create(&qcRecord.Issues).Error; err != nil {
+			dbTx.Rollback()
+			return nil, nil, &RepositoryError{
+				Code:    "INSERT_FAILED",
+				Message: "Failed to create QC issues",
+				Detail:  err.Error(),
+			}
+		}
+	}
+
 	// Update package status based on QC result
-	if qcPassed {
+	if overallPassed {
 		pkg.Status = "qc_passed"
 	} else {
 		pkg.Status = "qc_failed"
@@ -518,6 +730,18 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		}
 	}
 
+	if !overallPassed {
+		if repoErr := r.enqueueNotificationEvent(dbTx, sessionID, "qc.failed", qcFailedEventPayload{
+			SessionID:  sessionID,
+			PackageID:  pkg.ID,
+			QCRecordID: qcID,
+			Issues:     issues,
+		}); repoErr != nil {
+			dbTx.Rollback()
+			return nil, nil, repoErr
+		}
+	}
+
 	// Commit transaction
 	err = dbTx.Commit().Error
 	if err != nil {
@@ -528,21 +752,25 @@ func (r *Repository) QualityCheck(sessionID string, qcPassed bool, issues []stri
 		}
 	}
 
+	if err := r.RecordIdempotency(ctx, session.OperatorID, idempotencyKey, requestHash, &qualityCheckResult{Package: pkg, QCRecord: qcRecord}); err != nil {
+		logging.FromContext(ctx).Warn("failed to record idempotency", "session_id", sessionID, "error", err.Error())
+	}
+
 	return &pkg, &qcRecord, nil
 }
 
 // LabelPackage adds a label to the package
-func (r *Repository) LabelPackage(sessionID, destination, priority, courierID string) (*models.Label, *RepositoryError) {
-	dbTx := r.db.Begin()
+func (r *Repository) LabelPackage(ctx context.Context, sessionID, destination, priority, courierID, idempotencyKey string) (*models.Label, *RepositoryError) {
+	log := logging.FromContext(ctx).With("session_id", sessionID)
+	dbTx := r.db.WithContext(ctx).Begin()
 
-	log.Printf("Creating label for session: %s, destination: %s, priority: %s, courierID: %s",
-		sessionID, destination, priority, courierID)
+	log.Debug("creating label", "destination", destination, "priority", priority, "courier_id", courierID)
 
 	var session models.Session
 	err := dbTx.Where("session_id = ?", sessionID).First(&session).Error
 	if err != nil {
 		dbTx.Rollback()
-		log.Printf("Session lookup error: %v", err)
+		log.Warn("session lookup failed", "error", err.Error())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &RepositoryError{
 				Code:    "ENTITY_NOT_FOUND",
@@ -556,13 +784,30 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 			Detail:  err.Error(),
 		}
 	}
-	log.Printf("Found session: %s", session.ID)
+	log.Debug("found session", "status", session.Status)
+
+	type labelPackageRequest struct {
+		SessionID   string `json:"session_id"`
+		Destination string `json:"destination"`
+		Priority    string `json:"priority"`
+		CourierID   string `json:"courier_id"`
+	}
+	var cached models.Label
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, session.OperatorID, idempotencyKey, labelPackageRequest{sessionID, destination, priority, courierID}, &cached)
+	if repoErr != nil {
+		dbTx.Rollback()
+		return nil, repoErr
+	}
+	if found {
+		dbTx.Rollback()
+		return &cached, nil
+	}
 
 	var pkg models.Package
 	err = dbTx.Where("session_id = ?", sessionID).First(&pkg).Error
 	if err != nil {
 		dbTx.Rollback()
-		log.Printf("Package lookup error: %v", err)
+		log.Warn("package lookup failed", "error", err.Error())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &RepositoryError{
 				Code:    "ENTITY_NOT_FOUND",
@@ -576,13 +821,13 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 			Detail:  err.Error(),
 		}
 	}
-	log.Printf("Found package: %s for session: %s", pkg.ID, sessionID)
+	log.Debug("found package", "package_id", pkg.ID)
 
 	var courier models.Courier
 	err = dbTx.Where("courier_id = ?", courierID).First(&courier).Error
 	if err != nil {
 		dbTx.Rollback()
-		log.Printf("Courier lookup error: %v", err)
+		log.Warn("courier lookup failed", "error", err.Error())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &RepositoryError{
 				Code:    "ENTITY_NOT_FOUND",
@@ -599,7 +844,7 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 
 	hash := sha256.Sum256([]byte(courier.ID + pkg.ID + sessionID))
 	labelID := fmt.Sprintf("LBL-%s", hex.EncodeToString(hash[:])[:16])
-	log.Printf("Generated label ID: %s", labelID)
+	log.Debug("generated label id", "label_id", labelID)
 
 	newLabel := models.Label{
 		ID:          labelID,
@@ -616,7 +861,7 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 	err = dbTx.Create(&newLabel).Error
 	if err != nil {
 		dbTx.Rollback()
-		log.Printf("Failed to create label: %v", err)
+		log.Warn("failed to create label", "error", err.Error())
 		return nil, &RepositoryError{
 			Code:    "DATABASE_ERROR",
 			Message: "Failed to create label",
@@ -628,18 +873,30 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 	err = dbTx.Where("label_id = ?", labelID).First(&checkLabel).Error
 	if err != nil {
 		dbTx.Rollback()
-		log.Printf("Failed to verify label creation: %v", err)
+		log.Warn("failed to verify label creation", "error", err.Error())
 		return nil, &RepositoryError{
 			Code:    "DATABASE_ERROR",
 			Message: "Label created but couldn't be verified",
 			Detail:  err.Error(),
 		}
 	}
-	log.Printf("Label verified in transaction: %s", checkLabel.ID)
+	log.Debug("label verified in transaction", "label_id", checkLabel.ID)
+
+	if repoErr := r.enqueueNotificationEvent(dbTx, sessionID, "package.labeled", labelEventPayload{
+		SessionID:   sessionID,
+		PackageID:   pkg.ID,
+		LabelID:     labelID,
+		CourierID:   courier.ID,
+		Destination: destination,
+		Priority:    priority,
+	}); repoErr != nil {
+		dbTx.Rollback()
+		return nil, repoErr
+	}
 
 	err = dbTx.Commit().Error
 	if err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
+		log.Warn("failed to commit transaction", "error", err.Error())
 		return nil, &RepositoryError{
 			Code:    "COMMIT_FAILED",
 			Message: "Failed to commit transaction",
@@ -647,22 +904,33 @@ func (r *Repository) LabelPackage(sessionID, destination, priority, courierID st
 		}
 	}
 
-	log.Printf("Transaction committed successfully, label created: %s", labelID)
+	log.Info("label created", "label_id", labelID)
 
 	var finalCheck models.Label
 	err = r.db.Where("label_id = ?", labelID).First(&finalCheck).Error
 	if err != nil {
-		log.Printf("WARNING: Label not found after commit: %v", err)
-	} else {
-		log.Printf("CONFIRMED: Label %s exists in database", finalCheck.ID)
+		log.Warn("label not found after commit", "error", err.Error())
+	}
+
+	if err := r.RecordIdempotency(ctx, session.OperatorID, idempotencyKey, requestHash, &newLabel); err != nil {
+		log.Warn("failed to record idempotency", "error", err.Error())
 	}
 
 	return &newLabel, nil
 }
 
 // CommitSession commits the session to L1
-func (r *Repository) CommitSession(sessionID, operatorID string) (*models.Transaction, *RepositoryError) {
-	dbTx := r.db.Begin()
+func (r *Repository) CommitSession(ctx context.Context, sessionID, operatorID, idempotencyKey string) (*models.Transaction, *RepositoryError) {
+	var cached models.Transaction
+	found, requestHash, repoErr := r.CheckIdempotency(ctx, operatorID, idempotencyKey, sessionID, &cached)
+	if repoErr != nil {
+		return nil, repoErr
+	}
+	if found {
+		return &cached, nil
+	}
+
+	dbTx := r.db.WithContext(ctx).Begin()
 
 	var session models.Session
 	err := dbTx.Where("session_id = ?", sessionID).First(&session).Error
@@ -706,7 +974,7 @@ func (r *Repository) CommitSession(sessionID, operatorID string) (*models.Transa
 	}
 
 	var pkg *models.Package
-	err = dbTx.Preload("Items").Preload("QCRecords").Preload("QCRecords.Inspector").Where("session_id = ?", session.ID).First(&pkg).Error
+	err = dbTx.Preload("Items").Preload("QCRecords").Preload("QCRecords.Inspector").Preload("QCRecords.Issues").Where("session_id = ?", session.ID).First(&pkg).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &RepositoryError{
@@ -755,25 +1023,27 @@ func (r *Repository) CommitSession(sessionID, operatorID string) (*models.Transa
 		}
 	}
 
-	qcPassed := pkg.Status == "qc_passed"
-	rawIssues := pkg.QCRecords[0].Issues
-	rawIssues = rawIssues[1 : len(rawIssues)-1]
-	issues := strings.Split(rawIssues, ",")
-
-	l1Resp, repoErr := r.CommitToL1(session.ID, session.OperatorID, pkg.ID, "signature123", label.Destination, label.Priority, label.CourierID, qcPassed, issues)
+	// The on-chain transaction carries only a Merkle root over this
+	// session's canonical fields, not the full body, so L1 tx size stays
+	// bounded; the fields themselves live in session_blobs, keyed by that
+	// root, for GetSessionProof to serve sibling paths against later.
+	rootHex, repoErr := r.commitSessionMerkleRoot(dbTx, session.ID, pkg, &pkg.QCRecords[0], &label, operatorID)
 	if repoErr != nil {
 		dbTx.Rollback()
 		return nil, repoErr
 	}
 
+	// The L1 commit itself happens asynchronously, off of worker.Pool
+	// draining commit_jobs, so a slow or failed L1 round-trip no longer
+	// forces this transaction to roll back and lose the QC/label work
+	// recorded above. tx starts "pending" and is updated to "committed" by
+	// Repository.ProcessCommitJob once the L1 round-trip succeeds.
 	tx := models.Transaction{
-		SessionID:   session.ID,
-		BlockHeight: l1Resp.BlockHeight,
-		Status:      "committed",
+		SessionID: session.ID,
+		Status:    "pending",
 	}
-	session.IsCommitted = true
-	session.Status = "committed"
-	session.TxHash = &tx.TxHash // Assuming TxHash field exists in Session model
+	session.Status = "committing"
+	session.MerkleRoot = rootHex
 
 	err = dbTx.Save(&session).Error
 	if err != nil {
@@ -784,22 +1054,12 @@ func (r *Repository) CommitSession(sessionID, operatorID string) (*models.Transa
 			Detail:  err.Error(),
 		}
 	}
-	pkg.Status = "committed"
-	err = dbTx.Save(&pkg).Error
-	if err != nil {
-		dbTx.Rollback()
-		return nil, &RepositoryError{
-			Code:    "DATABASE_ERROR",
-			Message: "Failed to update package status",
-			Detail:  err.Error(),
-		}
-	}
 	err = dbTx.Create(&tx).Error
 	if err != nil {
 		dbTx.Rollback()
 		return nil, &RepositoryError{
-			Code:    "CONSENSUS_ERROR",
-			Message: "a consensus error occurred",
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to record pending transaction",
 			Detail:  err.Error(),
 		}
 	}
@@ -812,12 +1072,27 @@ func (r *Repository) CommitSession(sessionID, operatorID string) (*models.Transa
 			Detail:  err.Error(),
 		}
 	}
+
+	if _, repoErr := r.EnqueueCommit(session.ID, session.OperatorID, rootHex); repoErr != nil {
+		return nil, repoErr
+	}
+
+	if err := r.RecordIdempotency(ctx, operatorID, idempotencyKey, requestHash, &tx); err != nil {
+		logging.FromContext(ctx).Warn("failed to record idempotency", "session_id", sessionID, "error", err.Error())
+	}
+
 	return &tx, nil
 }
 
-// RunConsensus handles submitting data to the blockchain and waiting for consensus. For L2, this runs a consensus simulation
+// RunConsensus submits payload via BroadcastTxAsync and waits for its
+// completion on the shared consensusPipeline subscription, rather than
+// blocking a dedicated goroutine inside BroadcastTxCommit: under load or
+// slow consensus, the old per-call goroutine could run long after its
+// caller gave up, leaking exactly like a reader loop that stops draining.
+// ctx canceling here releases this wait immediately regardless of what the
+// backend is doing - the tx may still commit later, counted as orphaned
+// rather than leaked.
 func (r *Repository) RunConsensus(ctx context.Context, payload ConsensusPayload) (*ConsensusResult, *RepositoryError) {
-	// Serialize the payload
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return nil, &RepositoryError{
@@ -827,157 +1102,167 @@ func (r *Repository) RunConsensus(ctx context.Context, payload ConsensusPayload)
 		}
 	}
 
-	// Create consensus transaction
-	consensusTx := cmttypes.Tx(payloadBytes)
-
-	// Use a channel to detect both context deadline and RPC completion
-	done := make(chan struct {
-		result *cmtrpctypes.ResultBroadcastTxCommit
-		err    error
-	}, 1)
+	txHash, err := r.backend.BroadcastTxAsync(ctx, payloadBytes)
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "CONSENSUS_ERROR",
+			Message: "Failed to broadcast transaction",
+			Detail:  err.Error(),
+		}
+	}
 
-	go func() {
-		result, err := r.rpcClient.BroadcastTxCommit(ctx, consensusTx)
-		done <- struct {
-			result *cmtrpctypes.ResultBroadcastTxCommit
-			err    error
-		}{result, err}
-	}()
+	resultCh, err := r.consensusPipeline.submit(txHash)
+	if err != nil {
+		return nil, &RepositoryError{
+			Code:    "CONSENSUS_BACKPRESSURE",
+			Message: "Too many transactions awaiting consensus",
+			Detail:  err.Error(),
+		}
+	}
 
-	// Wait for either the operation to complete or context to be canceled
 	select {
 	case <-ctx.Done():
+		r.consensusPipeline.release(txHash)
 		return nil, &RepositoryError{
 			Code:    "CONSENSUS_TIMEOUT",
 			Message: "Consensus operation timed out",
 			Detail:  ctx.Err().Error(),
 		}
-	case result := <-done:
-		if result.err != nil {
+	case res := <-resultCh:
+		if res.err != nil {
 			return nil, &RepositoryError{
 				Code:    "CONSENSUS_ERROR",
 				Message: "Failed to commit to blockchain",
-				Detail:  result.err.Error(),
-			}
-		}
-
-		// Check for errors in the response
-		if result.result.CheckTx.Code != 0 {
-			return nil, &RepositoryError{
-				Code:    "CONSENSUS_ERROR",
-				Message: "Blockchain rejected transaction",
-				Detail:  fmt.Sprintf("CheckTx code: %d", result.result.CheckTx.Code),
+				Detail:  res.err.Error(),
 			}
 		}
-
-		// Return success result
-		return &ConsensusResult{
-			TxHash:      hex.EncodeToString(result.result.Hash),
-			BlockHeight: result.result.Height,
-			Code:        result.result.CheckTx.Code,
-		}, nil
+		return res.result, nil
 	}
 }
 
-func (r *Repository) CommitToL1(sessionID, operatorID, packageID, supplierSignature, destination, priority, courierID string, qcPassed bool, issues []string) (*ConsensusResult, *RepositoryError) {
-	if len(r.l1Addresses) == 0 {
+// CommitToL1 fans the commit out across every configured L1 endpoint via
+// r.l1Client (see package l1client): the current primary is contacted
+// immediately, the rest after a short hedge delay, and a breaker per
+// endpoint demotes nodes that keep failing out of primary rotation. It
+// only succeeds once a quorum of endpoints agree on the resulting
+// BlockHash/BlockHeight; disagreeing or insufficient responses surface as
+// an "L1_DIVERGENCE" RepositoryError with per-node detail.
+func (r *Repository) CommitToL1(parentCtx context.Context, sessionID, operatorID, merkleRoot string) (*ConsensusResult, *RepositoryError) {
+	if r.l1Client == nil {
 		return nil, &RepositoryError{
 			Code:    "CONFIG_ERROR",
 			Message: "No L1 node addresses configured",
-			Detail:  "l1Addresses slice is empty",
+			Detail:  "l1Client was never set up via SetupRpcClient",
 		}
 	}
 
-	payload := map[string]interface{}{
-		"session_id":         sessionID,
-		"operator_id":        operatorID,
-		"package_id":         packageID,
-		"supplier_signature": supplierSignature,
-		"destination":        destination,
-		"priority":           priority,
-		"courier_id":         courierID,
-		"qc_passed":          qcPassed,
-		"issues":             issues,
-		"timestamp":          time.Now(),
-		// "origin_node_id": r.node.ID,
-	}
+	// Stamping the payload with a beacon round gives L1 verifiers an
+	// unpredictable-but-checkable ordering nonce, so a proposer can no
+	// longer grind or replay a commit's timestamp undetected. A session
+	// only ever draws rounds from the network active at its StartHeight,
+	// and a round older than the last one this session committed is
+	// rejected outright.
+	var round uint64
+	var randomness, prevSig string
+	if r.beaconNetworks != nil {
+		var session models.Session
+		if err := r.db.WithContext(parentCtx).Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+			return nil, &RepositoryError{
+				Code:    "DATABASE_ERROR",
+				Message: "Failed to load session for beacon round check",
+				Detail:  err.Error(),
+			}
+		}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, &RepositoryError{
-			Code:    "SERIALIZATION_ERROR",
-			Message: "Failed to serialize L1 commit payload",
-			Detail:  err.Error(),
+		activeBeacon, err := r.beaconNetworks.For(session.StartHeight)
+		if err != nil {
+			return nil, &RepositoryError{
+				Code:    "CONFIG_ERROR",
+				Message: "No beacon network configured for this session",
+				Detail:  err.Error(),
+			}
 		}
-	}
 
-	fmt.Println(r.l1Addresses[0])
-	url := fmt.Sprintf("http://%s/session/%s/commit-l1", r.l1Addresses[0], sessionID)
-	fmt.Println(url)
+		entry, err := beacon.FetchLatest(activeBeacon)
+		if err != nil {
+			return nil, &RepositoryError{
+				Code:    "BEACON_ERROR",
+				Message: "Failed to fetch beacon entry",
+				Detail:  err.Error(),
+			}
+		}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, &RepositoryError{
-			Code:    "REQUEST_ERROR",
-			Message: "Failed to create HTTP request",
-			Detail:  err.Error(),
+		if entry.Round < session.LastBeaconRound {
+			return nil, &RepositoryError{
+				Code:    "BEACON_STALE_ROUND",
+				Message: "Beacon round is older than this session's last committed round",
+				Detail:  fmt.Sprintf("round %d is older than last committed round %d", entry.Round, session.LastBeaconRound),
+			}
 		}
-	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := r.httpClient.Do(req)
-	if err != nil {
-		return nil, &RepositoryError{
-			Code:    "NETWORK_ERROR",
-			Message: "Failed to connect to L1 node",
-			Detail:  err.Error(),
+		if err := activeBeacon.Verify(entry, nil); err != nil {
+			return nil, &RepositoryError{
+				Code:    "BEACON_VERIFICATION_FAILED",
+				Message: "Beacon entry failed signature verification",
+				Detail:  err.Error(),
+			}
 		}
+
+		round = entry.Round
+		randomness = hex.EncodeToString(entry.Randomness)
+		prevSig = hex.EncodeToString(entry.Previous)
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	ctx, cancel := context.WithTimeout(parentCtx, r.l1ClientCfg.RequestTimeout)
+	defer cancel()
+
+	result, err := r.l1Client.SubmitCommit(ctx, l1client.CommitRequest{
+		SessionID:     sessionID,
+		OperatorID:    operatorID,
+		MerkleRoot:    merkleRoot,
+		Timestamp:     time.Now(),
+		BeaconRound:   round,
+		Randomness:    randomness,
+		PrevSignature: prevSig,
+	})
 	if err != nil {
-		return nil, &RepositoryError{
-			Code:    "READ_ERROR",
-			Message: "Failed to read response from L1 node",
-			Detail:  err.Error(),
+		var divergence *l1client.DivergenceError
+		if errors.As(err, &divergence) {
+			return nil, &RepositoryError{
+				Code:    "L1_DIVERGENCE",
+				Message: "L1 nodes did not reach quorum on the committed block",
+				Detail:  l1DivergenceDetail(divergence),
+			}
 		}
-	}
-
-	// Or unmarshal it to a struct if it's JSON
-	type TransactionStatus struct {
-		TxID        string    `json:"tx_id"`
-		RequestID   string    `json:"request_id"`
-		Status      string    `json:"status"`
-		BlockHeight int64     `json:"block_height"`
-		BlockHash   string    `json:"block_hash,omitempty"`
-		ConfirmTime time.Time `json:"confirm_time"`
-	}
-	type ClientResponse struct {
-		StatusCode int               `json:"-"` // Not included in JSON
-		Headers    map[string]string `json:"-"` // Not included in JSON
-		// Body          string            `json:"body,omitempty"`
-		Body          interface{}       `json:"body"`
-		Meta          TransactionStatus `json:"meta"`
-		BlockchainRef string            `json:"blockchain_ref"`
-		NodeID        string            `json:"node_id"`
-	}
-	var result ClientResponse
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
 		return nil, &RepositoryError{
-			Code:    "PARSE_ERROR",
-			Message: "Failed to parse L1 node response",
+			Code:    "NETWORK_ERROR",
+			Message: "Failed to connect to L1 nodes",
 			Detail:  err.Error(),
 		}
 	}
 
 	return &ConsensusResult{
-		BlockHeight: result.Meta.BlockHeight,
-		TxHash:      result.Meta.BlockHash,
+		BlockHeight: result.BlockHeight,
+		TxHash:      result.BlockHash,
 		Code:        0,
+		BeaconRound: round,
 	}, nil
 }
 
+// l1DivergenceDetail renders every node's response so an operator debugging
+// an L1_DIVERGENCE error can see exactly which endpoints disagreed or failed.
+func l1DivergenceDetail(divergence *l1client.DivergenceError) string {
+	detail := fmt.Sprintf("required %d matching responses, got:", divergence.Required)
+	for _, resp := range divergence.Responses {
+		if resp.Err != nil {
+			detail += fmt.Sprintf(" [%s: error=%s]", resp.Endpoint, resp.Err.Error())
+			continue
+		}
+		detail += fmt.Sprintf(" [%s: block_hash=%s block_height=%d]", resp.Endpoint, resp.BlockHash, resp.BlockHeight)
+	}
+	return detail
+}
+
 // CreateTestPackage is used to create packages (for testing only)
 func (r *Repository) CreateTestPackage(
 	requestID string,
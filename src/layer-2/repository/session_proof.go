@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"gorm.io/gorm"
+)
+
+// canonicalSessionLeaves builds the ordered set of Merkle leaves a
+// committed session's on-chain root commits to: package, items, QC
+// record, QC issues, label, operator, and the supplier signature. The
+// order is part of the commitment - GetSessionProof must rebuild leaves
+// in this same order to reproduce a matching tree.
+func canonicalSessionLeaves(pkg *models.Package, qcRecord *models.QCRecord, label *models.Label, operatorID string) []merkleLeaf {
+	leaves := []merkleLeaf{
+		{Key: "package", Data: canonicalPackageLeaf(pkg)},
+	}
+
+	items := make([]models.Item, len(pkg.Items))
+	copy(items, pkg.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	for _, item := range items {
+		leaves = append(leaves, merkleLeaf{
+			Key:  "item:" + item.ID,
+			Data: []byte(fmt.Sprintf("item_id=%s\ndescription=%s\nquantity=%d\n", item.ID, item.Description, item.Quantity)),
+		})
+	}
+
+	leaves = append(leaves, merkleLeaf{Key: "qc_record", Data: canonicalQCRecordLeaf(qcRecord)})
+
+	issues := make([]models.QCIssue, len(qcRecord.Issues))
+	copy(issues, qcRecord.Issues)
+	sort.Slice(issues, func(i, j int) bool { return issues[i].ID < issues[j].ID })
+	for _, issue := range issues {
+		leaves = append(leaves, merkleLeaf{
+			Key:  "qc_issue:" + issue.ID,
+			Data: []byte(fmt.Sprintf("qc_issue_id=%s\ncode=%s\nseverity=%s\ndescription=%s\nphoto_url=%s\n", issue.ID, issue.Code, issue.Severity, issue.Description, issue.PhotoURL)),
+		})
+	}
+
+	leaves = append(leaves, merkleLeaf{
+		Key:  "label",
+		Data: []byte(fmt.Sprintf("label_id=%s\ndestination=%s\npriority=%s\ncourier_id=%s\n", label.ID, label.Destination, label.Priority, label.CourierID)),
+	})
+	leaves = append(leaves, merkleLeaf{Key: "operator", Data: []byte(operatorID)})
+	leaves = append(leaves, merkleLeaf{
+		Key:  "signature",
+		Data: []byte(fmt.Sprintf("algorithm=%s\nkey_id=%s\nsignature=%s\n", pkg.VerifierAlgorithm, pkg.VerifierKeyID, pkg.Signature)),
+	})
+
+	return leaves
+}
+
+func canonicalPackageLeaf(pkg *models.Package) []byte {
+	return []byte(fmt.Sprintf("package_id=%s\ndelivery_note_id=%s\nsupplier_id=%s\nstatus=%s\n", pkg.ID, pkg.DeliveryNoteID, pkg.SupplierID, pkg.Status))
+}
+
+func canonicalQCRecordLeaf(qcRecord *models.QCRecord) []byte {
+	return []byte(fmt.Sprintf("qc_record_id=%s\npassed=%t\ninspector_id=%s\n", qcRecord.ID, qcRecord.Passed, qcRecord.InspectorID))
+}
+
+// commitSessionMerkleRoot builds the Merkle tree over session's canonical
+// leaves, persists the leaves as an off-chain models.SessionBlob keyed by
+// the resulting root, and returns the root as a hex string for the
+// on-chain transaction. dbTx is the caller's open transaction, so the
+// blob write commits atomically with the rest of CommitSession.
+func (r *Repository) commitSessionMerkleRoot(dbTx *gorm.DB, sessionID string, pkg *models.Package, qcRecord *models.QCRecord, label *models.Label, operatorID string) (string, *RepositoryError) {
+	leaves := canonicalSessionLeaves(pkg, qcRecord, label, operatorID)
+	root, _ := buildMerkleTree(leaves)
+	rootHex := hex.EncodeToString(root)
+
+	leavesJSON, err := json.Marshal(leaves)
+	if err != nil {
+		return "", &RepositoryError{
+			Code:    "SERIALIZATION_ERROR",
+			Message: "Failed to serialize session Merkle leaves",
+			Detail:  err.Error(),
+		}
+	}
+
+	blob := models.SessionBlob{
+		RootHash:   rootHex,
+		SessionID:  sessionID,
+		LeavesJSON: string(leavesJSON),
+	}
+	if err := dbTx.Create(&blob).Error; err != nil {
+		return "", &RepositoryError{
+			Code:    "DATABASE_ERROR",
+			Message: "Failed to persist session blob",
+			Detail:  err.Error(),
+		}
+	}
+	return rootHex, nil
+}
+
+// GetSessionProof returns a MerkleProof that leaf (e.g. "package",
+// "item:ITEM-001", "qc_issue:QCI-...") was committed under sessionID's
+// on-chain Merkle root, so an auditor or client can verify that single
+// field without trusting this node. It rebuilds the tree from the
+// session's stored SessionBlob rather than trusting a cached root.
+func (r *Repository) GetSessionProof(sessionID, leaf string) (*MerkleProof, *RepositoryError) {
+	var session models.Session
+	if err := r.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, &RepositoryError{
+			Code:    "ENTITY_NOT_FOUND",
+			Message: "Session does not exist",
+			Detail:  fmt.Sprintf("Session with id %s does not exist", sessionID),
+		}
+	}
+	if session.MerkleRoot == "" {
+		return nil, &RepositoryError{
+			Code:    "INVALID_STATE",
+			Message: "Session has no committed Merkle root yet",
+			Detail:  fmt.Sprintf("Session %s has not been committed", sessionID),
+		}
+	}
+
+	var blob models.SessionBlob
+	if err := r.db.Where("root_hash = ?", session.MerkleRoot).First(&blob).Error; err != nil {
+		return nil, &RepositoryError{
+			Code:    "ENTITY_NOT_FOUND",
+			Message: "Session blob does not exist for this root",
+			Detail:  fmt.Sprintf("No blob stored for root %s", session.MerkleRoot),
+		}
+	}
+
+	var leaves []merkleLeaf
+	if err := json.Unmarshal([]byte(blob.LeavesJSON), &leaves); err != nil {
+		return nil, &RepositoryError{
+			Code:    "DESERIALIZATION_ERROR",
+			Message: "Failed to parse session blob",
+			Detail:  err.Error(),
+		}
+	}
+
+	leafIndex := -1
+	for i, l := range leaves {
+		if l.Key == leaf {
+			leafIndex = i
+			break
+		}
+	}
+	if leafIndex == -1 {
+		return nil, &RepositoryError{
+			Code:    "ENTITY_NOT_FOUND",
+			Message: "No such leaf in this session's committed data",
+			Detail:  fmt.Sprintf("leaf %q not found for session %s", leaf, sessionID),
+		}
+	}
+
+	root, levels := buildMerkleTree(leaves)
+	rootHex := hex.EncodeToString(root)
+	if rootHex != session.MerkleRoot {
+		return nil, &RepositoryError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Recomputed Merkle root does not match the committed root",
+			Detail:  fmt.Sprintf("expected %s, got %s", session.MerkleRoot, rootHex),
+		}
+	}
+
+	return &MerkleProof{
+		Leaf:     leaf,
+		LeafHash: hex.EncodeToString(levels[0][leafIndex]),
+		Steps:    merkleProofFor(levels, leafIndex),
+		Root:     rootHex,
+	}, nil
+}
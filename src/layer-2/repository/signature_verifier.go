@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+)
+
+// SignatureVerifier verifies a supplier-signed payload against one
+// registered models.SupplierKey. Repository dispatches on key.Algorithm, so
+// adding a new algorithm means registering a new SignatureVerifier rather
+// than touching ValidatePackage.
+type SignatureVerifier interface {
+	// Algorithm is the models.SupplierKey.Algorithm value this verifier
+	// handles, e.g. "ed25519".
+	Algorithm() string
+	// Verify reports an error if signature is not a valid signature of
+	// payload under the raw key bytes stored on the SupplierKey.
+	Verify(key, payload, signature []byte) error
+}
+
+// ed25519Verifier implements SignatureVerifier for raw Ed25519 public keys.
+type ed25519Verifier struct{}
+
+func (ed25519Verifier) Algorithm() string { return "ed25519" }
+
+func (ed25519Verifier) Verify(key, payload, signature []byte) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("ed25519: public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), payload, signature) {
+		return errors.New("ed25519: signature does not match payload")
+	}
+	return nil
+}
+
+// ecdsaSecp256k1Verifier implements SignatureVerifier for ECDSA keys
+// registered under the "ecdsa-secp256k1" algorithm, with ASN.1 DER-encoded
+// (PKIX) public keys and ASN.1 DER-encoded signatures over SHA-256.
+// x509.ParsePKIXPublicKey only accepts the NIST curves (P-224, P-256,
+// P-384, P-521), so a key on the secp256k1 curve itself fails to parse and
+// its signatures are not verified.
+type ecdsaSecp256k1Verifier struct{}
+
+func (ecdsaSecp256k1Verifier) Algorithm() string { return "ecdsa-secp256k1" }
+
+func (ecdsaSecp256k1Verifier) Verify(key, payload, signature []byte) error {
+	pub, err := x509.ParsePKIXPublicKey(key)
+	if err != nil {
+		return fmt.Errorf("ecdsa-secp256k1: parsing public key: %w", err)
+	}
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("ecdsa-secp256k1: key is not an ECDSA public key")
+	}
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], signature) {
+		return errors.New("ecdsa-secp256k1: signature does not match payload")
+	}
+	return nil
+}
+
+// x509Verifier implements SignatureVerifier for a supplier's PEM-encoded
+// X.509 certificate, optionally checked against roots (Repository's trust
+// store, set via SetTrustRoots) before its embedded public key is used to
+// check signature.
+type x509Verifier struct {
+	roots *x509.CertPool
+}
+
+func (v *x509Verifier) Algorithm() string { return "x509" }
+
+func (v *x509Verifier) Verify(key, payload, signature []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return errors.New("x509: public key is not PEM-encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("x509: parsing certificate: %w", err)
+	}
+	if v.roots != nil {
+		if _, err := cert.Verify(x509.VerifyOptions{Roots: v.roots}); err != nil {
+			return fmt.Errorf("x509: certificate chain did not verify: %w", err)
+		}
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, payload, signature); err != nil {
+		return fmt.Errorf("x509: %w", err)
+	}
+	return nil
+}
+
+// RegisterVerifier adds (or replaces) the SignatureVerifier used for its
+// Algorithm(). NewRepository registers ed25519, ecdsa-secp256k1, and x509
+// by default.
+func (r *Repository) RegisterVerifier(v SignatureVerifier) {
+	r.verifiers[v.Algorithm()] = v
+}
+
+// SetTrustRoots configures the CA pool ValidatePackage's x509 verifier
+// checks supplier certificate chains against. Leaving it unset accepts any
+// well-formed certificate, which is only appropriate for local testing.
+func (r *Repository) SetTrustRoots(roots *x509.CertPool) {
+	r.RegisterVerifier(&x509Verifier{roots: roots})
+}
+
+// canonicalPackagePayload builds the deterministic byte string
+// ValidatePackage verifies supplierSignature against: the package ID,
+// delivery note ID, and its items sorted by item ID, since items come back
+// from the DB in no guaranteed order and a canonical form can't depend on
+// that.
+func canonicalPackagePayload(pkg *models.Package) []byte {
+	items := make([]models.Item, len(pkg.Items))
+	copy(items, pkg.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package_id=%s\ndelivery_note_id=%s\n", pkg.ID, pkg.DeliveryNoteID)
+	for _, item := range items {
+		fmt.Fprintf(&buf, "item:%s:%d:%s\n", item.ID, item.Quantity, item.Description)
+	}
+	return buf.Bytes()
+}
+
+// verifySignature tries signature against each of keys in turn - there may
+// be more than one live key during a rotation - returning the first whose
+// registered SignatureVerifier accepts it.
+func (r *Repository) verifySignature(keys []models.SupplierKey, payload, signature []byte) (*models.SupplierKey, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("supplier has no registered signing keys")
+	}
+
+	var lastErr error
+	for i := range keys {
+		key := keys[i]
+		verifier, ok := r.verifiers[key.Algorithm]
+		if !ok {
+			lastErr = fmt.Errorf("no verifier registered for algorithm %q", key.Algorithm)
+			continue
+		}
+		if err := verifier.Verify(key.PublicKey, payload, signature); err != nil {
+			lastErr = err
+			continue
+		}
+		return &key, nil
+	}
+	return nil, lastErr
+}
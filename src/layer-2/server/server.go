@@ -14,8 +14,8 @@ import (
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/app"
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
 	service_registry "github.com/ahmadzakiakmal/thesis/src/layer-2/srvreg"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
 
-	cmtlog "github.com/cometbft/cometbft/libs/log"
 	nm "github.com/cometbft/cometbft/node"
 	"github.com/cometbft/cometbft/rpc/client"
 	cmthttp "github.com/cometbft/cometbft/rpc/client/http"
@@ -27,7 +27,7 @@ type WebServer struct {
 	app                *app.Application
 	httpAddr           string
 	server             *http.Server
-	logger             cmtlog.Logger
+	logger             logging.Logger
 	node               *nm.Node
 	startTime          time.Time
 	serviceRegistry    *service_registry.ServiceRegistry
@@ -82,7 +82,7 @@ type ClientResponse struct {
 }
 
 // NewWebServer creates a new web server
-func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, node *nm.Node, serviceRegistry *service_registry.ServiceRegistry, repository *repository.Repository) (*WebServer, error) {
+func NewWebServer(app *app.Application, httpPort string, logger logging.Logger, node *nm.Node, serviceRegistry *service_registry.ServiceRegistry, repository *repository.Repository) (*WebServer, error) {
 	mux := http.NewServeMux()
 
 	rpcAddr := fmt.Sprintf("http://localhost:%s", extractPortFromAddress(node.Config().RPC.ListenAddress))
@@ -127,6 +127,9 @@ func NewWebServer(app *app.Application, httpPort string, logger cmtlog.Logger, n
 	// Session Endpoints
 	mux.HandleFunc("/session/", server.handleSessionAPI)
 	mux.HandleFunc("/commit/", server.handleCommitAPI)
+	mux.HandleFunc("/import/", server.handleBulkImport)
+	mux.HandleFunc("/commit-jobs/", server.handleCommitJobStatus)
+	mux.HandleFunc("/byzantine-counters", server.handleByzantineCounters)
 
 	return server, nil
 }
@@ -142,10 +145,15 @@ func (ws *WebServer) Start() error {
 	return nil
 }
 
-// Shutdown gracefully shuts down the web server
+// Shutdown gracefully shuts down the web server: it stops accepting new
+// connections, then waits for the service registry's in-flight handlers
+// to finish (or ctx's deadline to pass) before returning.
 func (ws *WebServer) Shutdown(ctx context.Context) error {
 	ws.logger.Info("Shutting down web server")
-	return ws.server.Shutdown(ctx)
+	if err := ws.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return ws.serviceRegistry.Shutdown(ctx)
 }
 
 // handleRoot handles the root endpoint which shows node status
@@ -164,6 +172,24 @@ func (ws *WebServer) handleRoot(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(rpcAddrHtml))
 }
 
+// handleByzantineCounters reports how many times each configured
+// ByzantineProfile strategy has been applied, so test harnesses can
+// assert liveness/safety under a known attack pattern.
+func (ws *WebServer) handleByzantineCounters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(ws.serviceRegistry.ByzantineCounters()); err != nil {
+		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 // handleDebug provides debugging information
 func (ws *WebServer) handleDebug(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -227,6 +253,74 @@ func (ws *WebServer) handleDebug(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleBulkImport handles POST /import/{code}, accepting an uploaded
+// XLSX or CSV spreadsheet in the request body and dispatching its rows to
+// repository.Repository.BulkImport, e.g.
+// POST /import/SUPPLY_CHAIN-CATALOG_ITEMS.
+func (ws *WebServer) handleBulkImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 3 || pathParts[2] == "" {
+		JSONError(w, "Missing import code", http.StatusBadRequest)
+		return
+	}
+	code := pathParts[2]
+
+	report, repoErr := ws.repository.BulkImport(r.Context(), code, r.Body)
+	if repoErr != nil {
+		JSONError(w, repoErr.Message+": "+repoErr.Detail, http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleCommitJobStatus handles GET /commit-jobs/{sessionID}, reporting the
+// state of a session's asynchronous L1 commit job (queued, committed,
+// failed, with its attempt count and last error) for an operator dashboard
+// of stuck commits.
+func (ws *WebServer) handleCommitJobStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		JSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 3 || pathParts[2] == "" {
+		JSONError(w, "Missing session ID", http.StatusBadRequest)
+		return
+	}
+	sessionID := pathParts[2]
+
+	job, repoErr := ws.repository.CommitJobStatus(sessionID)
+	if repoErr != nil {
+		JSONError(w, repoErr.Message+": "+repoErr.Detail, http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		JSONError(w, "No commit job found for this session", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(job); err != nil {
+		JSONError(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 // handleTransactionStatus returns the status of a transaction
 func (ws *WebServer) handleTransactionStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -266,6 +360,30 @@ func (ws *WebServer) handleTransactionStatus(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// consensusBatchKey best-effort extracts an operator_id and package_id from
+// request's body, so its consensus submission can be batched and
+// rate-limited by ConsensusScheduler like any other. Requests whose body
+// doesn't carry a package_id (e.g. creating a session) fall back to the
+// session ID segment of the path instead of leaving the submission
+// unbatched.
+func consensusBatchKey(request *service_registry.Request) (operatorID, packageID string) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(request.Body), &fields); err == nil {
+		if v, ok := fields["operator_id"].(string); ok {
+			operatorID = v
+		}
+		if v, ok := fields["package_id"].(string); ok {
+			packageID = v
+		}
+	}
+	if packageID == "" {
+		if parts := strings.Split(request.Path, "/"); len(parts) >= 3 && parts[1] == "session" {
+			packageID = parts[2]
+		}
+	}
+	return operatorID, packageID
+}
+
 // handleSessionAPI handels API requests regarding supply chain session
 func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
 	requestID, err := generateRequestID()
@@ -283,7 +401,7 @@ func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
 	}
 	// request.Body = strings.TrimSpace(request.Body)
 
-	response, err := request.GenerateResponse(ws.serviceRegistry)
+	response, err := request.GenerateResponse(r.Context(), ws.serviceRegistry)
 	if err != nil {
 		JSONError(w, "Failed to generate response: "+err.Error(), http.StatusUnprocessableEntity)
 		ws.logger.Error("Failed to generate response", "err", err)
@@ -296,14 +414,18 @@ func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
 		OriginNodeID: string(ws.node.ConsensusReactor().Switch.NodeInfo().ID()),
 	}
 
-	// Simulate transaction consensus
-	consensusResponse, repoErr := ws.repository.RunConsensus(context.Background(), transaction)
+	// Simulate transaction consensus, routed through the scheduler so a
+	// burst of same-package_id requests is batched and prioritized rather
+	// than each hitting the backend independently.
+	operatorID, packageID := consensusBatchKey(request)
+	consensusResponse, repoErr := ws.repository.SubmitConsensus(context.Background(), transaction, operatorID, packageID)
 	if repoErr != nil {
 		if repoErr.Code == "CONSENSUS_ERROR" {
 			JSONError(w, "Consensus error occurred: "+repoErr.Detail, http.StatusInternalServerError)
 			return
 		}
 		JSONError(w, "An error occured: "+repoErr.Message, http.StatusInternalServerError)
+		return
 	}
 
 	// Add block height info to consensus transaction
@@ -400,7 +522,7 @@ func (ws *WebServer) handleCommitAPI(w http.ResponseWriter, r *http.Request) {
 	}
 	// request.Body = strings.TrimSpace(request.Body)
 
-	response, err := request.GenerateResponse(ws.serviceRegistry)
+	response, err := request.GenerateResponse(r.Context(), ws.serviceRegistry)
 	if err != nil {
 		JSONError(w, "Failed to generate response: "+err.Error(), http.StatusUnprocessableEntity)
 		ws.logger.Error("Failed to generate response", "err", err)
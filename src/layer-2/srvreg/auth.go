@@ -0,0 +1,258 @@
+package srvreg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+)
+
+// DefaultTokenTTL is how long a token issued by LoginHandler stays valid
+// before TokenMiddleware rejects it and the caller must log in again.
+const DefaultTokenTTL = 15 * time.Minute
+
+// bearerPrefix is stripped from the Authorization header before the token
+// is handed to a TokenIssuer.
+const bearerPrefix = "Bearer "
+
+// AccessLevel rank, least to most privileged - RequireAccessLevel enforces
+// "at least" a level rather than an exact match. An AccessLevel outside
+// this map (including "") ranks below every known level.
+var accessLevelRank = map[string]int{
+	"Basic":    1,
+	"Standard": 2,
+	"Admin":    3,
+}
+
+// tokenClaims is the signed payload of a bearer token: who it was issued
+// for, and when it stops being valid.
+type tokenClaims struct {
+	OperatorID string `json:"operator_id"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+// TokenIssuer mints and verifies HMAC-signed bearer tokens binding a
+// request to the Operator that logged in, so handlers and RBAC guards can
+// trust ctx's resolved Operator instead of whatever operator_id a caller
+// puts in the request body.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokenIssuer builds a TokenIssuer. secret must be kept server-side and
+// stable across restarts, or every token issued before a restart is
+// invalidated. ttl <= 0 defaults to DefaultTokenTTL.
+func NewTokenIssuer(secret []byte, ttl time.Duration) *TokenIssuer {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &TokenIssuer{secret: secret, ttl: ttl}
+}
+
+// TTL returns the duration a token issued by Issue stays valid for.
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
+
+// Issue mints a bearer token for operatorID, valid until t's configured
+// ttl elapses.
+func (t *TokenIssuer) Issue(operatorID string) string {
+	claims := tokenClaims{OperatorID: operatorID, ExpiresAt: time.Now().Add(t.ttl).Unix()}
+	payload, _ := json.Marshal(claims)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(encodedPayload))
+	signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return encodedPayload + "." + signature
+}
+
+// Verify checks token's signature and expiry and returns the operator ID
+// it was issued for.
+func (t *TokenIssuer) Verify(token string) (string, error) {
+	encodedPayload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", fmt.Errorf("srvreg: malformed token")
+	}
+
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(encodedPayload))
+	expectedSignature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expectedSignature), []byte(signature)) {
+		return "", fmt.Errorf("srvreg: invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", fmt.Errorf("srvreg: invalid token payload: %w", err)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("srvreg: invalid token claims: %w", err)
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return "", fmt.Errorf("srvreg: token expired")
+	}
+	return claims.OperatorID, nil
+}
+
+// operatorCtxKey is the context key TokenMiddleware attaches the resolved
+// Operator under.
+type operatorCtxKey struct{}
+
+// withOperator returns a copy of ctx carrying operator, retrievable with
+// OperatorFromContext.
+func withOperator(ctx context.Context, operator *models.Operator) context.Context {
+	return context.WithValue(ctx, operatorCtxKey{}, operator)
+}
+
+// OperatorFromContext returns the Operator TokenMiddleware resolved for
+// this request, or nil if the request carried no valid bearer token.
+func OperatorFromContext(ctx context.Context) *models.Operator {
+	operator, _ := ctx.Value(operatorCtxKey{}).(*models.Operator)
+	return operator
+}
+
+// TokenMiddleware resolves an "Authorization: Bearer <token>" header to
+// the Operator it was issued for and attaches it to ctx (see
+// OperatorFromContext). A missing Authorization header, or one without the
+// "Bearer " prefix, leaves ctx's Operator unset rather than rejecting the
+// request outright - routes with no capability requirement stay open;
+// RequireRole/RequireAccessLevel are what actually enforce authentication
+// on the routes that need it. A Bearer token that fails verification, or
+// names no known Operator, is rejected with 401.
+func TokenMiddleware(repo *repository.Repository, issuer *TokenIssuer) Middleware {
+	return func(next ServiceHandler) ServiceHandler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			header := strings.TrimSpace(req.Headers["Authorization"])
+			if !strings.HasPrefix(header, bearerPrefix) {
+				return next(ctx, req)
+			}
+
+			operatorID, err := issuer.Verify(strings.TrimPrefix(header, bearerPrefix))
+			if err != nil {
+				return &Response{
+					StatusCode: http.StatusUnauthorized,
+					Headers:    map[string]string{"Content-Type": "application/json"},
+					Body:       fmt.Sprintf(`{"error":"%s"}`, err.Error()),
+				}, nil
+			}
+
+			operator, repoErr := repo.GetOperatorByID(ctx, operatorID)
+			if repoErr != nil {
+				return &Response{
+					StatusCode: http.StatusUnauthorized,
+					Headers:    map[string]string{"Content-Type": "application/json"},
+					Body:       `{"error":"token does not match a known operator"}`,
+				}, nil
+			}
+
+			return next(withOperator(ctx, operator), req)
+		}
+	}
+}
+
+// forbiddenResponse builds the 403 RequireRole/RequireAccessLevel return
+// when ctx's Operator doesn't satisfy the capability a route requires.
+func forbiddenResponse(message string) *Response {
+	return &Response{
+		StatusCode: http.StatusForbidden,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf(`{"error":"%s"}`, message),
+	}
+}
+
+// RequireRole is the policy DSL for role-gated routes: pass it to
+// RegisterHandler alongside the handler it protects (it must run after
+// TokenMiddleware, so register it as a per-route middleware, not via Use)
+// and the route 403s unless TokenMiddleware resolved an Operator whose
+// Role is one of roles.
+func RequireRole(roles ...string) Middleware {
+	return func(next ServiceHandler) ServiceHandler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			operator := OperatorFromContext(ctx)
+			if operator == nil {
+				return forbiddenResponse("authentication required"), nil
+			}
+			for _, role := range roles {
+				if operator.Role == role {
+					return next(ctx, req)
+				}
+			}
+			return forbiddenResponse(fmt.Sprintf("role %q is not permitted to call this endpoint", operator.Role)), nil
+		}
+	}
+}
+
+// RequireAccessLevel is the policy DSL for access-level-gated routes: pass
+// it to RegisterHandler alongside the handler it protects, and the route
+// 403s unless TokenMiddleware resolved an Operator whose AccessLevel
+// ranks at or above minLevel in accessLevelRank (e.g. "Admin" for a
+// supervisor-only endpoint - the highest rank currently seeded).
+func RequireAccessLevel(minLevel string) Middleware {
+	minRank := accessLevelRank[minLevel]
+	return func(next ServiceHandler) ServiceHandler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			operator := OperatorFromContext(ctx)
+			if operator == nil {
+				return forbiddenResponse("authentication required"), nil
+			}
+			if accessLevelRank[operator.AccessLevel] < minRank {
+				return forbiddenResponse(fmt.Sprintf("access level %q does not meet the required %q", operator.AccessLevel, minLevel)), nil
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+type loginRequestBody struct {
+	OperatorID string `json:"operator_id"`
+}
+
+// LoginHandler issues a short-lived bearer token for an existing Operator:
+// POST /auth/login with {"operator_id": "..."}. The token must be sent
+// back as "Authorization: Bearer <token>" on every subsequent request that
+// a RequireRole/RequireAccessLevel guard protects. Operator carries no
+// password or secret of its own in this schema, so this is the last place
+// a body-supplied identity is trusted outright - everything past login
+// goes through TokenMiddleware instead.
+func (sr *ServiceRegistry) LoginHandler(ctx context.Context, req *Request) (*Response, error) {
+	var body loginRequestBody
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil || body.OperatorID == "" {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       `{"error":"operator_id is required"}`,
+		}, nil
+	}
+
+	operator, repoErr := sr.repository.GetOperatorByID(ctx, body.OperatorID)
+	if repoErr != nil {
+		return &Response{
+			StatusCode: http.StatusUnauthorized,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       `{"error":"unknown operator"}`,
+		}, nil
+	}
+
+	token := sr.tokenIssuer.Issue(operator.ID)
+	respBody, _ := json.Marshal(map[string]interface{}{
+		"token":      token,
+		"expires_in": int(sr.tokenIssuer.TTL().Seconds()),
+	})
+	return &Response{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(respBody),
+	}, nil
+}
@@ -0,0 +1,289 @@
+package srvreg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ByzantineProfile corrupts an otherwise-honest response before it leaves
+// this node, letting tests and benchmarks exercise a known, named attack
+// pattern instead of the single hard-coded corruption ServiceRegistry used
+// to apply. Apply receives both the original request and the honest
+// response the handler produced, so a profile can key its decision off
+// either (e.g. Censor inspects req.Path, Equivocation hashes peerID).
+// peerID identifies who the response is being produced for; it is the
+// empty string at call sites that don't yet have a per-peer notion (e.g.
+// the HTTP-facing handlers), which peer-independent strategies ignore.
+type ByzantineProfile interface {
+	// Name identifies the strategy for logging and for the per-strategy
+	// counters exposed through the webserver.
+	Name() string
+	Apply(req *Request, honest *Response, peerID string) (*Response, error)
+}
+
+// errByzantineSilent is returned by SilentProfile to signal "this node
+// never responded" rather than "this node returned an error response" -
+// callers should treat it like a timeout, not a handled failure.
+var errByzantineSilent = errors.New("srvreg: byzantine silent profile dropped the response")
+
+// SilentProfile simulates a node that never responds at all.
+type SilentProfile struct{}
+
+func (SilentProfile) Name() string { return "silent" }
+
+func (SilentProfile) Apply(_ *Request, _ *Response, _ string) (*Response, error) {
+	return nil, errByzantineSilent
+}
+
+// EquivocationProfile sends a different deterministic response to
+// different peers, derived by hashing peerID together with Seed so the
+// same peer always observes the same variant but distinct peers diverge.
+type EquivocationProfile struct {
+	Seed int64
+}
+
+func (EquivocationProfile) Name() string { return "equivocation" }
+
+func (p EquivocationProfile) Apply(_ *Request, honest *Response, peerID string) (*Response, error) {
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, p.Seed)
+	h.Write([]byte(peerID))
+	variant := h.Sum(nil)[0] % 3
+
+	corrupted := *honest
+	switch variant {
+	case 0:
+		// Variant 0 is the honest response, so at least one peer always
+		// sees the truth and the divergence is attributable.
+	case 1:
+		corrupted.Body = fmt.Sprintf(`{"message":"equivocated response","variant":1,"for_peer":%q}`, peerID)
+		corrupted.StatusCode = http200OrConflict(honest.StatusCode)
+	default:
+		corrupted.Body = fmt.Sprintf(`{"message":"equivocated response","variant":2,"for_peer":%q}`, peerID)
+		corrupted.StatusCode = 500
+	}
+	return &corrupted, nil
+}
+
+func http200OrConflict(status int) int {
+	if status >= 200 && status < 300 {
+		return 409
+	}
+	return status
+}
+
+// DelayProfile sleeps for a stochastic duration drawn from
+// [Min, Min+Max) before returning the honest response unchanged,
+// modeling a slow-but-not-silent byzantine node.
+type DelayProfile struct {
+	Min, Max time.Duration
+	rng      *rand.Rand
+	mu       sync.Mutex
+}
+
+func NewDelayProfile(min, max time.Duration, seed int64) *DelayProfile {
+	return &DelayProfile{Min: min, Max: max, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (*DelayProfile) Name() string { return "delay" }
+
+func (p *DelayProfile) Apply(_ *Request, honest *Response, _ string) (*Response, error) {
+	p.mu.Lock()
+	jitter := time.Duration(0)
+	if p.Max > 0 {
+		jitter = time.Duration(p.rng.Int63n(int64(p.Max)))
+	}
+	p.mu.Unlock()
+	time.Sleep(p.Min + jitter)
+	return honest, nil
+}
+
+// CorruptProfile flips a bit in the honest response's JSON body, producing
+// a response that looks well-formed at a glance but fails re-parsing or
+// signature verification downstream.
+type CorruptProfile struct{}
+
+func (CorruptProfile) Name() string { return "corrupt" }
+
+func (CorruptProfile) Apply(_ *Request, honest *Response, _ string) (*Response, error) {
+	body := []byte(honest.Body)
+	if len(body) == 0 {
+		return honest, nil
+	}
+	body[len(body)/2] ^= 0x01
+
+	corrupted := *honest
+	corrupted.Body = string(body)
+	return &corrupted, nil
+}
+
+// ReplayProfile echoes a previously seen response for the same request
+// path instead of the current honest one, simulating a node that is
+// stuck replaying stale state. The first request for any given path is
+// always answered honestly, since there is nothing yet to replay.
+type ReplayProfile struct {
+	mu   sync.Mutex
+	last map[string]*Response
+}
+
+func NewReplayProfile() *ReplayProfile {
+	return &ReplayProfile{last: make(map[string]*Response)}
+}
+
+func (*ReplayProfile) Name() string { return "replay" }
+
+func (p *ReplayProfile) Apply(req *Request, honest *Response, _ string) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stale, seen := p.last[req.Path]
+	p.last[req.Path] = honest
+	if !seen {
+		return honest, nil
+	}
+	return stale, nil
+}
+
+// CensorProfile drops a configurable percentage of requests to a
+// configured set of endpoints (path prefixes), returning
+// errByzantineSilent for the dropped share instead of the honest
+// response.
+type CensorProfile struct {
+	Percent   float64 // in [0, 1]
+	Endpoints []string
+	rng       *rand.Rand
+	mu        sync.Mutex
+}
+
+func NewCensorProfile(percent float64, endpoints []string, seed int64) *CensorProfile {
+	return &CensorProfile{Percent: percent, Endpoints: endpoints, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (*CensorProfile) Name() string { return "censor" }
+
+func (p *CensorProfile) Apply(req *Request, honest *Response, _ string) (*Response, error) {
+	censored := false
+	for _, endpoint := range p.Endpoints {
+		if strings.HasPrefix(req.Path, endpoint) {
+			censored = true
+			break
+		}
+	}
+	if !censored {
+		return honest, nil
+	}
+
+	p.mu.Lock()
+	roll := p.rng.Float64()
+	p.mu.Unlock()
+	if roll < p.Percent {
+		return nil, errByzantineSilent
+	}
+	return honest, nil
+}
+
+// ParseByzantineProfile parses a "-byzantine" flag value of the form
+// "name" or "name:key=val,key=val,..." into a ByzantineProfile. An empty
+// spec is not valid here; callers should treat an empty flag as "no
+// profile" before calling this.
+func ParseByzantineProfile(spec string) (ByzantineProfile, error) {
+	name, rawArgs, _ := strings.Cut(spec, ":")
+	args, err := parseProfileArgs(rawArgs)
+	if err != nil {
+		return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+	}
+
+	switch strings.ToLower(name) {
+	case "silent":
+		return SilentProfile{}, nil
+	case "equivocation":
+		seed, err := args.int64("seed", 0)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		return EquivocationProfile{Seed: seed}, nil
+	case "delay":
+		min, err := args.duration("min", 50*time.Millisecond)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		max, err := args.duration("max", 200*time.Millisecond)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		seed, err := args.int64("seed", 0)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		return NewDelayProfile(min, max, seed), nil
+	case "corrupt":
+		return CorruptProfile{}, nil
+	case "replay":
+		return NewReplayProfile(), nil
+	case "censor":
+		percent, err := args.float64("percent", 0.5)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		seed, err := args.int64("seed", 0)
+		if err != nil {
+			return nil, fmt.Errorf("byzantine profile %q: %w", spec, err)
+		}
+		var endpoints []string
+		if raw, ok := args["endpoints"]; ok {
+			endpoints = strings.Split(raw, "|")
+		}
+		return NewCensorProfile(percent, endpoints, seed), nil
+	default:
+		return nil, fmt.Errorf("byzantine profile %q: unknown strategy %q", spec, name)
+	}
+}
+
+// profileArgs holds the "key=val" pairs following a profile name.
+type profileArgs map[string]string
+
+func parseProfileArgs(raw string) (profileArgs, error) {
+	args := profileArgs{}
+	if raw == "" {
+		return args, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed argument %q, expected key=val", pair)
+		}
+		args[key] = val
+	}
+	return args, nil
+}
+
+func (a profileArgs) int64(key string, def int64) (int64, error) {
+	raw, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+func (a profileArgs) float64(key string, def float64) (float64, error) {
+	raw, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+func (a profileArgs) duration(key string, def time.Duration) (time.Duration, error) {
+	raw, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	return time.ParseDuration(raw)
+}
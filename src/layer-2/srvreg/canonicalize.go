@@ -0,0 +1,82 @@
+package srvreg
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ResponseCanonicalizer normalizes a Response before two replicas' copies
+// of it are diffed for byzantine detection, so non-deterministic headers,
+// JSON key ordering, and fields expected to vary between independent
+// executions (timestamps, generated IDs) don't produce a false-positive
+// mismatch. A route gets one via ServiceRegistry.WithCanonicalizer.
+type ResponseCanonicalizer struct {
+	// HeaderDenylist lists header names (case-insensitive) stripped
+	// before comparison, e.g. Date, Server, X-Request-ID, Set-Cookie.
+	HeaderDenylist []string
+	// SortJSONKeys re-serializes a JSON object/array body so it compares
+	// equal regardless of source field order. encoding/json already
+	// marshals map keys in sorted order, so this only needs to round-trip
+	// the body through Unmarshal/Marshal to take effect.
+	SortJSONKeys bool
+	// BlankPaths is a list of dot-separated JSON field paths (e.g.
+	// "data.issued_at") whose values are zeroed before comparison.
+	BlankPaths []string
+}
+
+// Canonicalize returns a copy of resp with HeaderDenylist's headers
+// stripped and, if SortJSONKeys or BlankPaths apply, its body
+// re-serialized with sorted keys and those fields blanked. resp itself is
+// left untouched. A nil receiver or resp is returned as-is.
+func (c *ResponseCanonicalizer) Canonicalize(resp *Response) *Response {
+	if c == nil || resp == nil {
+		return resp
+	}
+
+	out := *resp
+	out.Headers = make(map[string]string, len(resp.Headers))
+	denylist := make(map[string]bool, len(c.HeaderDenylist))
+	for _, h := range c.HeaderDenylist {
+		denylist[strings.ToLower(h)] = true
+	}
+	for k, v := range resp.Headers {
+		if denylist[strings.ToLower(k)] {
+			continue
+		}
+		out.Headers[k] = v
+	}
+
+	if out.Body != "" && (c.SortJSONKeys || len(c.BlankPaths) > 0) {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(out.Body), &parsed); err == nil {
+			for _, path := range c.BlankPaths {
+				blankJSONPath(parsed, strings.Split(path, "."))
+			}
+			if canonical, err := json.Marshal(parsed); err == nil {
+				out.Body = string(canonical)
+			}
+		}
+	}
+
+	return &out
+}
+
+// blankJSONPath zeroes the field at the dotted path within v (the result
+// of unmarshaling a JSON object into interface{}), silently doing
+// nothing if any segment along the path is missing or not an object.
+func blankJSONPath(v interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		if _, exists := m[path[0]]; exists {
+			m[path[0]] = nil
+		}
+		return
+	}
+	blankJSONPath(m[path[0]], path[1:])
+}
@@ -0,0 +1,84 @@
+package srvreg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
+)
+
+// Notifier delivers a single NotificationEvent to one channel - a
+// courier's webhook, an operator's email/SMS gateway, an audit log, ...
+// A delivery failure should return a descriptive error so the
+// notification worker can record it against the event and retry with
+// backoff via Repository.RetryNotificationEvent.
+type Notifier interface {
+	// Name identifies this Notifier in delivery logs and errors.
+	Name() string
+	Notify(ctx context.Context, event models.NotificationEvent) error
+}
+
+// WebhookNotifier POSTs event's payload as-is to endpoint, signing the
+// request with an X-Signature header carrying the same HMAC-SHA256
+// Repository.enqueueNotificationEvent already computed into
+// event.Signature, so a courier's webhook handler can verify the request
+// came from this node.
+type WebhookNotifier struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewWebhookNotifier builds a WebhookNotifier posting to endpoint with a
+// 5-second delivery timeout.
+func NewWebhookNotifier(endpoint string) *WebhookNotifier {
+	return &WebhookNotifier{endpoint: endpoint, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook:" + n.endpoint }
+
+// Notify implements Notifier.
+func (n *WebhookNotifier) Notify(ctx context.Context, event models.NotificationEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint, bytes.NewReader([]byte(event.Payload)))
+	if err != nil {
+		return fmt.Errorf("webhook: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.EventType)
+	req.Header.Set("X-Signature", "sha256="+event.Signature)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: delivering event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: endpoint responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AuditLogNotifier records every event through logger instead of
+// delivering it anywhere external - registering it alongside courier/
+// operator Notifiers means every event is audited even if every other
+// channel is unreachable.
+type AuditLogNotifier struct {
+	logger logging.Logger
+}
+
+// NewAuditLogNotifier builds an AuditLogNotifier writing through logger.
+func NewAuditLogNotifier(logger logging.Logger) *AuditLogNotifier {
+	return &AuditLogNotifier{logger: logger}
+}
+
+func (n *AuditLogNotifier) Name() string { return "audit-log" }
+
+// Notify implements Notifier. It never fails - a broken log sink isn't
+// something the outbox should retry an event over.
+func (n *AuditLogNotifier) Notify(ctx context.Context, event models.NotificationEvent) error {
+	n.logger.Info("notification event", "session_id", event.SessionID, "event_type", event.EventType, "payload", event.Payload)
+	return nil
+}
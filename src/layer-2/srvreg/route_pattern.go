@@ -0,0 +1,262 @@
+package srvreg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// segmentKind classifies one segment of a compiled route pattern.
+type segmentKind int
+
+const (
+	segLiteral segmentKind = iota
+	segParam
+	segWildcard
+)
+
+// pathSegment is one "/"-delimited piece of a route pattern, already
+// parsed out of its ":name", ":name(regexp)" or "*name" syntax so matching
+// a request path never has to re-parse the pattern.
+type pathSegment struct {
+	kind    segmentKind
+	literal string         // set for segLiteral
+	name    string         // set for segParam/segWildcard
+	pattern *regexp.Regexp // optional constraint, set only for segParam
+}
+
+// compileSegments splits pattern into its literal/param/wildcard segments.
+// A wildcard ("*rest") is only valid as the final segment, since it
+// consumes every remaining path segment.
+func compileSegments(pattern string) ([]pathSegment, error) {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	segments := make([]pathSegment, 0, len(parts))
+
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "*"):
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("wildcard segment %q must be the last segment in pattern %q", part, pattern)
+			}
+			segments = append(segments, pathSegment{kind: segWildcard, name: part[1:]})
+
+		case strings.HasPrefix(part, ":"):
+			name := part[1:]
+			var constraint *regexp.Regexp
+			if open := strings.Index(name, "("); open >= 0 && strings.HasSuffix(name, ")") {
+				expr := name[open+1 : len(name)-1]
+				compiled, err := regexp.Compile("^" + expr + "$")
+				if err != nil {
+					return nil, fmt.Errorf("invalid constraint on segment %q in pattern %q: %w", part, pattern, err)
+				}
+				constraint = compiled
+				name = name[:open]
+			}
+			segments = append(segments, pathSegment{kind: segParam, name: name, pattern: constraint})
+
+		default:
+			segments = append(segments, pathSegment{kind: segLiteral, literal: part})
+		}
+	}
+
+	return segments, nil
+}
+
+// compiledRoute is a registered pattern, pre-compiled into segments and
+// linked into its method's routeTrie.
+type compiledRoute struct {
+	method   string
+	pattern  string
+	segments []pathSegment
+	handler  ServiceHandler
+	// deadline, if non-zero, is the per-route timeout WithDeadline applied
+	// to this pattern; GenerateResponse wraps the handler's context with
+	// context.WithTimeout(ctx, deadline) when it is set.
+	deadline time.Duration
+	// canonicalizer, if set via WithCanonicalizer, normalizes this
+	// route's responses before ProcessProposal's byzantine check diffs
+	// them.
+	canonicalizer *ResponseCanonicalizer
+}
+
+// paramEdge is one :param transition out of a routeNode.
+type paramEdge struct {
+	name string
+	// pattern, if non-nil, constrains which path segments may take this
+	// edge (e.g. :id([0-9a-f]{16})).
+	pattern *regexp.Regexp
+	node    *routeNode
+}
+
+// wildcardEdge is the single *rest transition out of a routeNode, terminal
+// by construction since a wildcard always ends a pattern.
+type wildcardEdge struct {
+	name  string
+	route *compiledRoute
+}
+
+// routeNode is one depth of a method's route trie. Lookup always tries
+// literal children first, then paramEdges (constrained ones before
+// unconstrained, see addParamEdge), then the wildcard edge - so a more
+// specific route (more literal segments, a tighter param constraint, no
+// wildcard) is always preferred over a less specific one that would also
+// match.
+type routeNode struct {
+	children   map[string]*routeNode
+	paramEdges []*paramEdge
+	wildcard   *wildcardEdge
+	route      *compiledRoute // set if a pattern terminates exactly here
+}
+
+func newRouteNode() *routeNode {
+	return &routeNode{children: make(map[string]*routeNode)}
+}
+
+// routeTrie indexes every registered pattern for one HTTP method by its
+// literal prefix, so GetHandlerForPath walks at most len(path segments)
+// nodes instead of scanning every registered route.
+type routeTrie struct {
+	root *routeNode
+}
+
+func newRouteTrie() *routeTrie {
+	return &routeTrie{root: newRouteNode()}
+}
+
+// insert adds route to the trie, creating intermediate nodes as needed.
+func (t *routeTrie) insert(route *compiledRoute) error {
+	node := t.root
+	for _, seg := range route.segments {
+		switch seg.kind {
+		case segLiteral:
+			child, ok := node.children[seg.literal]
+			if !ok {
+				child = newRouteNode()
+				node.children[seg.literal] = child
+			}
+			node = child
+
+		case segParam:
+			var edge *paramEdge
+			for _, e := range node.paramEdges {
+				if e.name == seg.name && sameConstraint(e.pattern, seg.pattern) {
+					edge = e
+					break
+				}
+			}
+			if edge == nil {
+				edge = &paramEdge{name: seg.name, pattern: seg.pattern, node: newRouteNode()}
+				node.addParamEdge(edge)
+			}
+			node = edge.node
+
+		case segWildcard:
+			if node.wildcard != nil {
+				return fmt.Errorf("pattern %q conflicts with an already-registered wildcard route", route.pattern)
+			}
+			node.wildcard = &wildcardEdge{name: seg.name, route: route}
+			return nil
+		}
+	}
+
+	if node.route != nil {
+		return fmt.Errorf("pattern %q conflicts with an already-registered route", route.pattern)
+	}
+	node.route = route
+	return nil
+}
+
+// find walks segments the same way insert does, matching on segment kind,
+// literal/param name, and constraint rather than on concrete path values,
+// so a caller holding a registered pattern (not a request path) can locate
+// the compiledRoute it produced - e.g. to attach a deadline after the fact.
+func (t *routeTrie) find(segments []pathSegment) *compiledRoute {
+	node := t.root
+	for _, seg := range segments {
+		switch seg.kind {
+		case segLiteral:
+			child, ok := node.children[seg.literal]
+			if !ok {
+				return nil
+			}
+			node = child
+
+		case segParam:
+			var next *routeNode
+			for _, e := range node.paramEdges {
+				if e.name == seg.name && sameConstraint(e.pattern, seg.pattern) {
+					next = e.node
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			node = next
+
+		case segWildcard:
+			if node.wildcard == nil {
+				return nil
+			}
+			return node.wildcard.route
+		}
+	}
+	return node.route
+}
+
+// addParamEdge inserts edge, keeping constrained edges ahead of
+// unconstrained ones so a tighter regexp wins when both would match.
+func (n *routeNode) addParamEdge(edge *paramEdge) {
+	if edge.pattern == nil {
+		n.paramEdges = append(n.paramEdges, edge)
+		return
+	}
+	n.paramEdges = append([]*paramEdge{edge}, n.paramEdges...)
+}
+
+func sameConstraint(a, b *regexp.Regexp) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+// lookup walks segments against the trie, returning the first matching
+// route's handler along with the path params extracted along the way.
+func (t *routeTrie) lookup(segments []string) (*compiledRoute, map[string]string) {
+	return t.root.lookup(segments)
+}
+
+func (n *routeNode) lookup(segments []string) (*compiledRoute, map[string]string) {
+	if len(segments) == 0 {
+		if n.route != nil {
+			return n.route, map[string]string{}
+		}
+		return nil, nil
+	}
+
+	seg, rest := segments[0], segments[1:]
+
+	if child, ok := n.children[seg]; ok {
+		if route, params := child.lookup(rest); route != nil {
+			return route, params
+		}
+	}
+
+	for _, edge := range n.paramEdges {
+		if edge.pattern != nil && !edge.pattern.MatchString(seg) {
+			continue
+		}
+		if route, params := edge.node.lookup(rest); route != nil {
+			params[edge.name] = seg
+			return route, params
+		}
+	}
+
+	if n.wildcard != nil {
+		return n.wildcard.route, map[string]string{n.wildcard.name: strings.Join(segments, "/")}
+	}
+
+	return nil, nil
+}
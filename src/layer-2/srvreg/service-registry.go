@@ -2,6 +2,7 @@ package srvreg
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -15,7 +16,7 @@ import (
 	"time"
 
 	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
-	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/ahmadzakiakmal/thesis/src/logging"
 )
 
 // Request represents the client's original HTTP request
@@ -27,6 +28,11 @@ type Request struct {
 	RemoteAddr string            `json:"remote_addr"`
 	RequestID  string            `json:"request_id"` // Unique ID for the request
 	Timestamp  time.Time         `json:"timestamp"`
+	// PathParams holds the named/wildcard segments GetHandlerForPath
+	// extracted from Path against the matched route's pattern (e.g. "id"
+	// and "packageID" for "/session/:id/scan/:packageID"), so handlers
+	// don't need to re-parse req.Path with strings.Split.
+	PathParams map[string]string `json:"path_params,omitempty"`
 }
 
 // GenerateRequestID generates a deterministic ID for the request
@@ -82,23 +88,40 @@ type Transaction struct {
 	BlockHeight  int64    `json:"block_height,omitempty"`
 }
 
-// ServiceHandler is a function type for service handlers
-type ServiceHandler func(*Request) (*Response, error)
-
-// RouteKey is used to uniquely identify a route
-type RouteKey struct {
-	Method string
-	Path   string
-}
-
-// ServiceRegistry manages all service handlers
+// ServiceHandler is a function type for service handlers. ctx is derived
+// from the originating request merged with the registry's shutdown
+// context, and carries the route's deadline (set via WithDeadline) when
+// one applies - handlers should pass it straight through to repository
+// and CometBFT RPC calls so a client disconnect or SIGTERM interrupts the
+// in-flight work instead of leaking it past the response.
+type ServiceHandler func(ctx context.Context, req *Request) (*Response, error)
+
+// ServiceRegistry manages all service handlers. Each registered pattern is
+// compiled once into a routeTrie per method, so dispatch walks at most
+// len(path segments) nodes instead of scanning every registered route.
 type ServiceRegistry struct {
-	handlers    map[RouteKey]ServiceHandler
-	exactRoutes map[RouteKey]bool // Whether a route is exact or pattern-based
-	mu          sync.RWMutex
-	repository  *repository.Repository
-	logger      cmtlog.Logger
-	isByzantine bool
+	mu               sync.RWMutex
+	tries            map[string]*routeTrie // HTTP method -> trie
+	repository       *repository.Repository
+	logger           logging.Logger
+	tokenIssuer      *TokenIssuer
+	byzantineProfile ByzantineProfile // nil means respond honestly
+
+	countersMu       sync.Mutex
+	byzantineCounter map[string]int64 // profile name -> times applied
+
+	// globalMiddlewares wraps every handler registered after it's set, in
+	// the order Use was called; RegisterHandler folds it together with
+	// that call's own middlewares into the ServiceHandler stored in the
+	// trie, so dispatch never re-composes the chain per request.
+	globalMiddlewares []Middleware
+
+	// shutdownCtx is cancelled by Shutdown, fanning out into every
+	// in-flight handler's merged context; inFlight lets Shutdown wait for
+	// those handlers to actually return before the webserver stops.
+	shutdownCtx    context.Context
+	shutdownCancel context.CancelFunc
+	inFlight       sync.WaitGroup
 }
 
 // SerializeToBytes converts the transaction to a byte array for blockchain storage
@@ -139,170 +162,392 @@ func ConvertHttpRequestToConsensusRequest(r *http.Request, requestID string) (*R
 	}, nil
 }
 
-// NewServiceRegistry creates a new service registry
+// NewServiceRegistry creates a new service registry. profile is applied to
+// every handled response when non-nil; pass nil for honest behavior.
+// authSecret signs the bearer tokens LoginHandler issues - it must stay
+// stable across restarts and must not be empty in production, or tokens
+// become forgeable/worthless respectively.
 func NewServiceRegistry(
 	repository *repository.Repository,
-	logger cmtlog.Logger,
-	isByzantine bool,
+	logger logging.Logger,
+	profile ByzantineProfile,
+	authSecret []byte,
 ) *ServiceRegistry {
-	return &ServiceRegistry{
-		handlers:    make(map[RouteKey]ServiceHandler),
-		exactRoutes: make(map[RouteKey]bool),
-		repository:  repository,
-		logger:      logger,
-		isByzantine: isByzantine,
+	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
+	sr := &ServiceRegistry{
+		tries:            make(map[string]*routeTrie),
+		repository:       repository,
+		logger:           logger,
+		tokenIssuer:      NewTokenIssuer(authSecret, DefaultTokenTTL),
+		byzantineProfile: profile,
+		byzantineCounter: make(map[string]int64),
+		shutdownCtx:      shutdownCtx,
+		shutdownCancel:   shutdownCancel,
+	}
+
+	// Every route gets request logging, panic recovery, idempotent replay
+	// of state-changing requests, and operator resolution (TokenMiddleware
+	// attaches the bearer token's Operator to ctx for RequireRole/
+	// RequireAccessLevel further down the chain) for free; a configured
+	// ByzantineProfile is applied last, as the innermost global
+	// middleware, so it only ever sees (and corrupts) the handler's own
+	// honest responses; a response replayed from the idempotency cache
+	// skips it and is returned exactly as it was recorded the first time.
+	sr.Use(
+		LoggingMiddleware(),
+		RecoveryMiddleware(),
+		NewIdempotencyMiddleware(repository, DefaultOperatorHeader, DefaultIdempotencyTTL).Wrap,
+		TokenMiddleware(repository, sr.tokenIssuer),
+	)
+	if profile != nil {
+		sr.Use(byzantineMiddleware(sr))
 	}
+	return sr
 }
 
-// RegisterHandler registers a new service handler
-func (sr *ServiceRegistry) RegisterHandler(method, path string, isExactPath bool, handler ServiceHandler) {
+// Use appends middlewares to the chain wrapped around every handler
+// registered from this point on, in the order given - the first
+// middleware passed runs first (outermost). Call Use before
+// RegisterDefaultServices/RegisterHandler; it has no effect on routes
+// already registered.
+func (sr *ServiceRegistry) Use(middlewares ...Middleware) {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
+	sr.globalMiddlewares = append(sr.globalMiddlewares, middlewares...)
+}
+
+// Shutdown cancels the registry's shutdown context, which every in-flight
+// handler's merged context observes, then waits for those handlers to
+// return or for ctx's own deadline to pass, whichever comes first.
+func (sr *ServiceRegistry) Shutdown(ctx context.Context) error {
+	sr.shutdownCancel()
 
-	key := RouteKey{Method: strings.ToUpper(method), Path: path}
-	sr.handlers[key] = handler
-	sr.exactRoutes[key] = isExactPath
+	done := make(chan struct{})
+	go func() {
+		sr.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// GetHandlerForPath finds the appropriate handler for a given path and a boolean of whether or not the handler was found
-func (sr *ServiceRegistry) GetHandlerForPath(method, path string) (ServiceHandler, bool) {
+// WithDeadline enforces a per-route timeout: GenerateResponse wraps the
+// handler's context in context.WithTimeout(ctx, d) for every request
+// matching method and path. method and path must already be registered
+// via RegisterHandler.
+func (sr *ServiceRegistry) WithDeadline(method, path string, d time.Duration) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	method = strings.ToUpper(method)
+	segments, err := compileSegments(path)
+	if err != nil {
+		return err
+	}
+
+	trie, ok := sr.tries[method]
+	if !ok {
+		return fmt.Errorf("srvreg: no routes registered for method %s", method)
+	}
+	route := trie.find(segments)
+	if route == nil {
+		return fmt.Errorf("srvreg: no route registered for %s %s", method, path)
+	}
+	route.deadline = d
+	return nil
+}
+
+// WithCanonicalizer sets the ResponseCanonicalizer ProcessProposal
+// applies to both sides of its byzantine-check comparison for method and
+// path. method and path must already be registered via RegisterHandler.
+func (sr *ServiceRegistry) WithCanonicalizer(method, path string, c *ResponseCanonicalizer) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	method = strings.ToUpper(method)
+	segments, err := compileSegments(path)
+	if err != nil {
+		return err
+	}
+
+	trie, ok := sr.tries[method]
+	if !ok {
+		return fmt.Errorf("srvreg: no routes registered for method %s", method)
+	}
+	route := trie.find(segments)
+	if route == nil {
+		return fmt.Errorf("srvreg: no route registered for %s %s", method, path)
+	}
+	route.canonicalizer = c
+	return nil
+}
+
+// GetCanonicalizerForPath returns the ResponseCanonicalizer configured
+// for the route matching method and path via WithCanonicalizer, or nil
+// if none was set or no route matches.
+func (sr *ServiceRegistry) GetCanonicalizerForPath(method, path string) *ResponseCanonicalizer {
 	sr.mu.RLock()
 	defer sr.mu.RUnlock()
 
-	// Try exact match first
-	key := RouteKey{Method: strings.ToUpper(method), Path: path}
-	if handler, ok := sr.handlers[key]; ok {
-		if sr.exactRoutes[key] {
-			return handler, true
-		}
+	trie, ok := sr.tries[strings.ToUpper(method)]
+	if !ok {
+		return nil
 	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	route, _ := trie.lookup(segments)
+	if route == nil {
+		return nil
+	}
+	return route.canonicalizer
+}
 
-	// Try pattern matching
-	for routeKey, handler := range sr.handlers {
-		if routeKey.Method != strings.ToUpper(method) {
-			continue
+// mergeContext returns a context cancelled when either a or b is done,
+// fanning a single cancel signal out to every read/write/consensus-wait
+// path a handler starts - the same shared deadline-timer shape netstack's
+// gonet adapter uses for its socket deadlines, applied here to an HTTP
+// request merged with the registry's shutdown context.
+func mergeContext(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(a)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-b.Done():
+			cancel()
+		case <-stop:
 		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
 
-		// Skip exact routes in pattern matching
-		if sr.exactRoutes[routeKey] {
-			continue
-		}
+// ByzantineCounters returns how many times the configured ByzantineProfile
+// has been applied, keyed by profile name, for test harnesses and the
+// webserver's debug endpoint to assert liveness/safety under a known
+// attack pattern.
+func (sr *ServiceRegistry) ByzantineCounters() map[string]int64 {
+	sr.countersMu.Lock()
+	defer sr.countersMu.Unlock()
+
+	counters := make(map[string]int64, len(sr.byzantineCounter))
+	for name, count := range sr.byzantineCounter {
+		counters[name] = count
+	}
+	return counters
+}
 
-		// Simple pattern matching - can be enhanced
-		if matchPath(routeKey.Path, path) {
-			return handler, true
-		}
+// RegisterHandler compiles path into a routeTrie pattern and registers
+// handler for method, wrapped by sr's globalMiddlewares followed by
+// middlewares (each list runs in the given order, outermost first). The
+// chain is folded into a single ServiceHandler here, at registration time,
+// so GetHandlerForPath/GenerateResponse pay no extra cost per request.
+// path may contain literal segments, ":name"/":name(regexp)" parameters,
+// and a single trailing "*name" wildcard (e.g.
+// "/session/:id([0-9a-f]{16})/scan/:packageID").
+func (sr *ServiceRegistry) RegisterHandler(method, path string, handler ServiceHandler, middlewares ...Middleware) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	method = strings.ToUpper(method)
+	segments, err := compileSegments(path)
+	if err != nil {
+		log.Panicf("srvreg: failed to register %s %s: %v", method, path, err)
 	}
 
-	return nil, false
-}
+	wrapped := chain(handler, append(append([]Middleware{}, sr.globalMiddlewares...), middlewares...)...)
 
-// matchPath does simple pattern matching for routes.
-// It supports patterns like "/user/:id" matching "/user/123"
-func matchPath(pattern, path string) bool {
-	patternParts := strings.Split(pattern, "/")
-	pathParts := strings.Split(path, "/")
+	trie, ok := sr.tries[method]
+	if !ok {
+		trie = newRouteTrie()
+		sr.tries[method] = trie
+	}
 
-	if len(patternParts) != len(pathParts) {
-		return false
+	route := &compiledRoute{method: method, pattern: path, segments: segments, handler: wrapped}
+	if err := trie.insert(route); err != nil {
+		log.Panicf("srvreg: failed to register %s %s: %v", method, path, err)
 	}
+}
 
-	for i := range len(patternParts) {
-		if strings.HasPrefix(patternParts[i], ":") {
-			// This is a parameter part, it matches anything
-			continue
-		}
+// GetHandlerForPath finds the handler registered for method whose pattern
+// matches path, along with the params extracted from path's named/wildcard
+// segments, that route's deadline (zero if none was set via WithDeadline),
+// and a boolean reporting whether a match was found.
+func (sr *ServiceRegistry) GetHandlerForPath(method, path string) (ServiceHandler, map[string]string, time.Duration, bool) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
 
-		if patternParts[i] != pathParts[i] {
-			return false
-		}
+	trie, ok := sr.tries[strings.ToUpper(method)]
+	if !ok {
+		return nil, nil, 0, false
 	}
 
-	return true
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	route, params := trie.lookup(segments)
+	if route == nil {
+		return nil, nil, 0, false
+	}
+	return route.handler, params, route.deadline, true
 }
 
 // RegisterDefaultServices sets up
 // the default services for the BFT system
 func (sr *ServiceRegistry) RegisterDefaultServices() {
 	// Endpoints
+	// Operator Login Endpoint
+	sr.RegisterHandler(
+		"POST",
+		"/auth/login",
+		sr.LoginHandler,
+	)
 	// Test Create Package Endpoint
 	sr.RegisterHandler(
 		"POST",
 		"/session/test-package",
-		true,
 		sr.CreateTestPackage,
 	)
 	// Create Session Endpoint
 	sr.RegisterHandler(
 		"POST",
 		"/session/start",
-		true,
 		sr.CreateSessionHandler,
 	)
 	// Scan Package Ednpoint
 	sr.RegisterHandler(
 		"GET",
 		"/session/:id/scan/:packageID",
-		false,
 		sr.ScanPackageHandler,
 	)
 	// Validate Package Endpoint
 	sr.RegisterHandler(
 		"POST",
 		"/session/:id/validate",
-		false,
 		sr.ValidatePackageHandler,
 	)
-	// Quality Check Endpoint
+	// Quality Check Endpoint - inspectors only
 	sr.RegisterHandler(
 		"POST",
 		"/session/:id/qc",
-		false,
 		sr.QualityCheckHandler,
+		RequireRole("Quality Control Specialist"),
 	)
-	// Label Package Endpoint
+	// Label Package Endpoint - shipping only
 	sr.RegisterHandler(
 		"POST",
 		"/session/:id/label",
-		false,
 		sr.LabelPackageHandler,
+		RequireRole("Shipping Specialist"),
 	)
-	// Commit Session Endpoint
+	// Commit Session Endpoint - supervisor-level access only
 	sr.RegisterHandler(
 		"POST",
 		"/commit/:id",
-		false,
 		sr.CommitSessionHandler,
+		RequireAccessLevel("Admin"),
 	)
+	// Session Merkle Proof Endpoint
+	sr.RegisterHandler(
+		"GET",
+		"/session/:id/proof/:leaf",
+		sr.GetSessionProofHandler,
+	)
+	// Notification Delivery Status Endpoint - lets an operator see every
+	// package.labeled/session.committed/qc.failed event the outbox has
+	// queued, delivered, or given up on for a session.
+	sr.RegisterHandler(
+		"GET",
+		"/sessions/:id/notifications",
+		sr.GetSessionNotificationsHandler,
+	)
+
+	// Default per-route deadlines: generous enough for a normal Postgres
+	// round trip plus BFT CheckTx/DeliverTx, tight enough that a stalled
+	// transaction gets cancelled - see GenerateResponse, which turns a
+	// fired deadline into 504 instead of letting the handler hang or the
+	// caller see a bare 500. WithDeadline can only be called after the
+	// route it names has been registered above.
+	sr.mustSetDeadlines(map[[2]string]time.Duration{
+		{"POST", "/auth/login"}:                 2 * time.Second,
+		{"POST", "/session/test-package"}:       2 * time.Second,
+		{"POST", "/session/start"}:              2 * time.Second,
+		{"GET", "/session/:id/scan/:packageID"}: 2 * time.Second,
+		{"POST", "/session/:id/validate"}:       4 * time.Second,
+		{"POST", "/session/:id/qc"}:             4 * time.Second,
+		{"POST", "/session/:id/label"}:          4 * time.Second,
+		{"POST", "/commit/:id"}:                 8 * time.Second,
+		{"GET", "/session/:id/proof/:leaf"}:     2 * time.Second,
+		{"GET", "/sessions/:id/notifications"}:  2 * time.Second,
+	})
+}
+
+// mustSetDeadlines calls WithDeadline for each method/path pair in
+// deadlines, panicking if one names a route that wasn't registered -
+// RegisterDefaultServices only ever calls this with its own routes, so a
+// failure here means the two lists drifted apart, a programmer error
+// worth catching at startup rather than silently leaving a route with no
+// deadline.
+func (sr *ServiceRegistry) mustSetDeadlines(deadlines map[[2]string]time.Duration) {
+	for route, d := range deadlines {
+		if err := sr.WithDeadline(route[0], route[1], d); err != nil {
+			log.Panicf("srvreg: failed to set default deadline for %s %s: %v", route[0], route[1], err)
+		}
+	}
 }
 
-// GenerateResponse executes the request and generates a response
-func (req *Request) GenerateResponse(services *ServiceRegistry) (*Response, error) {
+// GenerateResponse executes the request and generates a response. ctx is
+// usually the originating *http.Request's context, so a client disconnect
+// cancels the handler; it is merged with the registry's shutdown context
+// so a SIGTERM does too, and further wrapped in context.WithTimeout when
+// the matched route has a deadline set via WithDeadline. Logging, panic
+// recovery, and Byzantine-profile application no longer happen here - they
+// were folded into the handler itself as middlewares when it was
+// registered (see ServiceRegistry.Use/RegisterHandler).
+func (req *Request) GenerateResponse(ctx context.Context, services *ServiceRegistry) (*Response, error) {
 	// Find the appropriate service handler for this request
-	handler, found := services.GetHandlerForPath(req.Method, req.Path)
-	log.Println("matching service registry handler...")
+	handler, params, deadline, found := services.GetHandlerForPath(req.Method, req.Path)
 	if !found {
-		log.Println("service registry handler not found")
 		return &Response{
 			StatusCode: http.StatusNotFound,
 			Headers:    map[string]string{"Content-Type": "text/plain"},
 			Body:       fmt.Sprintf("Service not found for %s %s", req.Method, req.Path),
 		}, nil
 	}
-	log.Println("service registry found")
-
-	// Execute the handler
-	response, err := handler(req)
+	req.PathParams = params
+
+	handlerCtx, cancel := mergeContext(ctx, services.shutdownCtx)
+	defer cancel()
+	if deadline > 0 {
+		var deadlineCancel context.CancelFunc
+		handlerCtx, deadlineCancel = context.WithTimeout(handlerCtx, deadline)
+		defer deadlineCancel()
+	}
 
-	if services.isByzantine {
-		if response.StatusCode == http.StatusOK || response.StatusCode == http.StatusCreated {
-			response.Body = `{"message": "Byzantiner node response - data corrupted"}`
-			response.StatusCode = http.StatusInternalServerError
-		}
-		services.logger.Info("Byzantine Node Response", response.Body)
+	// Every log line a handler or repository call emits for this request
+	// - in any package, at any depth - comes from this one child logger
+	// (or one derived from it via further With calls, e.g. once a handler
+	// learns the session ID), so it can be found by grepping request_id.
+	reqLogger := services.logger.With("request_id", req.RequestID, "method", req.Method, "path", req.Path)
+	if services.byzantineProfile != nil {
+		reqLogger = reqLogger.With("byzantine_profile", services.byzantineProfile.Name())
 	}
+	handlerCtx = logging.WithLogger(handlerCtx, reqLogger)
 
-	return response, err
+	services.inFlight.Add(1)
+	defer services.inFlight.Done()
+	resp, err := handler(handlerCtx, req)
+	if err != nil && handlerCtx.Err() == context.DeadlineExceeded {
+		return &Response{
+			StatusCode: http.StatusGatewayTimeout,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       `{"error":"request exceeded its deadline"}`,
+		}, nil
+	}
+	return resp, err
 }
 
 func compactJSON(body string) string {
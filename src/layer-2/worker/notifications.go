@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/srvreg"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+const (
+	defaultNotificationPollInterval = 2 * time.Second
+	defaultNotificationBatchSize    = 10
+)
+
+// NotificationPool runs a fixed number of goroutines, each polling repo
+// for queued NotificationEvents and fanning them out to every registered
+// srvreg.Notifier in order - the same shape Pool uses for commit_jobs,
+// applied here to the notification outbox instead of L1 commits.
+type NotificationPool struct {
+	repo      *repository.Repository
+	logger    cmtlog.Logger
+	notifiers []srvreg.Notifier
+	workers   int
+	interval  time.Duration
+	batch     int
+	cancel    context.CancelFunc
+}
+
+// NewNotificationPool creates a NotificationPool of `workers` goroutines
+// delivering to notifiers, in the order given. interval and batch fall
+// back to defaultNotificationPollInterval/defaultNotificationBatchSize
+// when <= 0.
+func NewNotificationPool(repo *repository.Repository, logger cmtlog.Logger, notifiers []srvreg.Notifier, workers, batch int, interval time.Duration) *NotificationPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if batch <= 0 {
+		batch = defaultNotificationBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultNotificationPollInterval
+	}
+	return &NotificationPool{repo: repo, logger: logger, notifiers: notifiers, workers: workers, interval: interval, batch: batch}
+}
+
+// Start launches the pool's goroutines. Call Stop to shut them down.
+func (p *NotificationPool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for i := 0; i < p.workers; i++ {
+		go p.run(ctx)
+	}
+}
+
+// Stop signals every worker goroutine to exit. It does not wait for a
+// delivery already in flight to finish.
+func (p *NotificationPool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+func (p *NotificationPool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.drainOnce(ctx)
+		}
+	}
+}
+
+// drainOnce claims and delivers one batch of ready events. Concurrent
+// NotificationPool goroutines may claim overlapping batches between two
+// polls; a courier receiving the same event twice just sees a redundant,
+// harmless replay of the same state change, so a race here costs a
+// duplicate delivery rather than a lost one.
+func (p *NotificationPool) drainOnce(ctx context.Context) {
+	events, repoErr := p.repo.ReadyNotificationEvents(p.batch)
+	if repoErr != nil {
+		p.logger.Error("Failed to list ready notification events", "err", repoErr.Detail)
+		return
+	}
+
+	for _, event := range events {
+		p.deliver(ctx, event)
+	}
+}
+
+// deliver runs event through every registered Notifier in order, stopping
+// at (and scheduling a retry for) the first one that fails rather than
+// skipping ahead - a courier webhook and the audit log should see events
+// in the same order, not a partial, reshuffled view of the outbox.
+func (p *NotificationPool) deliver(ctx context.Context, event models.NotificationEvent) {
+	for _, notifier := range p.notifiers {
+		if err := notifier.Notify(ctx, event); err != nil {
+			p.logger.Error("Notification delivery failed", "session_id", event.SessionID, "event_type", event.EventType, "notifier", notifier.Name(), "err", err.Error())
+			if repoErr := p.repo.RetryNotificationEvent(event, err); repoErr != nil {
+				p.logger.Error("Failed to record notification failure", "err", repoErr.Detail)
+			}
+			return
+		}
+	}
+
+	if repoErr := p.repo.MarkNotificationDelivered(event); repoErr != nil {
+		p.logger.Error("Failed to mark notification delivered", "err", repoErr.Detail)
+	}
+}
@@ -0,0 +1,99 @@
+// Package worker runs the background goroutines that drain
+// repository.Repository's commit_jobs queue, so CommitSession only has to
+// enqueue a job (Repository.EnqueueCommit) instead of blocking on an L1
+// round-trip inside its DB transaction.
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository"
+	"github.com/ahmadzakiakmal/thesis/src/layer-2/repository/models"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultBatchSize    = 10
+)
+
+// Pool runs a fixed number of goroutines, each polling repo for ready
+// commit jobs and processing them with Repository.ProcessCommitJob.
+type Pool struct {
+	repo     *repository.Repository
+	logger   cmtlog.Logger
+	workers  int
+	interval time.Duration
+	batch    int
+	cancel   context.CancelFunc
+}
+
+// NewPool creates a Pool of `workers` goroutines. interval and batch fall
+// back to defaultPollInterval/defaultBatchSize when <= 0.
+func NewPool(repo *repository.Repository, logger cmtlog.Logger, workers, batch int, interval time.Duration) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &Pool{repo: repo, logger: logger, workers: workers, interval: interval, batch: batch}
+}
+
+// Start launches the pool's goroutines. Call Stop to shut them down.
+func (p *Pool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for i := 0; i < p.workers; i++ {
+		go p.run(ctx)
+	}
+}
+
+// Stop signals every worker goroutine to exit. It does not wait for a job
+// already in flight to finish.
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+func (p *Pool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.drainOnce()
+		}
+	}
+}
+
+// drainOnce claims and processes one batch of ready jobs. ReadyCommitJobs
+// atomically flips each job to "processing" as it claims it, so two Pool
+// goroutines (or two poll ticks of a slow worker) never claim the same job
+// and double-submit it to L1.
+func (p *Pool) drainOnce() {
+	jobs, repoErr := p.repo.ReadyCommitJobs(p.batch)
+	if repoErr != nil {
+		p.logger.Error("Failed to list ready commit jobs", "err", repoErr.Detail)
+		return
+	}
+
+	for _, job := range jobs {
+		p.process(job)
+	}
+}
+
+func (p *Pool) process(job models.CommitJob) {
+	if repoErr := p.repo.ProcessCommitJob(job); repoErr != nil {
+		p.logger.Error("Commit job failed", "session_id", job.SessionID, "err", repoErr.Detail)
+	}
+}
@@ -0,0 +1,25 @@
+package logging
+
+import cmtlog "github.com/cometbft/cometbft/libs/log"
+
+// cometAdapter wraps a Logger so CometBFT's node/consensus machinery - which
+// only knows about cmtlog.Logger - writes into the same sink and format as
+// the rest of the system.
+type cometAdapter struct {
+	logger Logger
+}
+
+// NewCometLogger adapts logger to cmtlog.Logger so it can be passed
+// anywhere CometBFT wants its own logger type (node construction, the
+// mempool, the consensus reactor, ...).
+func NewCometLogger(logger Logger) cmtlog.Logger {
+	return cometAdapter{logger: logger}
+}
+
+func (a cometAdapter) Debug(msg string, keyvals ...interface{}) { a.logger.Debug(msg, keyvals...) }
+func (a cometAdapter) Info(msg string, keyvals ...interface{})  { a.logger.Info(msg, keyvals...) }
+func (a cometAdapter) Error(msg string, keyvals ...interface{}) { a.logger.Error(msg, keyvals...) }
+
+func (a cometAdapter) With(keyvals ...interface{}) cmtlog.Logger {
+	return cometAdapter{logger: a.logger.With(keyvals...)}
+}
@@ -0,0 +1,22 @@
+package logging
+
+import "context"
+
+type ctxKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, so every downstream
+// call - a handler, a repository method, a retry loop - can pull it back
+// out via FromContext and inherit whatever fields (request ID, session
+// ID, node ID, ...) the caller already attached with Logger.With.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the Logger attached to ctx via WithLogger, or
+// Discard if none was attached - callers never need a nil check.
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return logger
+	}
+	return Discard
+}
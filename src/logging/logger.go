@@ -0,0 +1,204 @@
+// Package logging provides a single structured logger abstraction (hclog
+// style key/value pairs, with text or JSON output) shared by srvreg,
+// repository, and the CometBFT adapter, so every log line - whatever layer
+// or package emitted it - can be grepped by request ID, session ID, or
+// node ID across the whole system.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a log severity, ordered Debug < Info < Warn < Error.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses a -log-level flag value ("debug", "info", "warn", or
+// "error"), defaulting to LevelInfo for an empty string.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "info":
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("logging: unknown level %q (want debug, info, warn, or error)", s)
+	}
+}
+
+// Format selects how a Logger renders each line.
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+// ParseFormat parses a -log-format flag value ("text" or "json"),
+// defaulting to FormatText for an empty string.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("logging: unknown format %q (want text or json)", s)
+	}
+}
+
+// Logger is an hclog-style structured logger: every call takes a message
+// plus an even-length list of alternating keys and values, and With
+// returns a child logger that carries those fields on every subsequent
+// call without the caller repeating them.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	With(keyvals ...interface{}) Logger
+}
+
+// Options configures New.
+type Options struct {
+	// Output defaults to os.Stderr.
+	Output io.Writer
+	// Level suppresses any call below it; defaults to LevelInfo.
+	Level Level
+	// Format defaults to FormatText.
+	Format Format
+}
+
+// structuredLogger is the concrete Logger. Derived loggers (via With)
+// share mu and out with their parent so concurrent writers - every
+// ServiceRegistry worker and every CometBFT consensus goroutine - never
+// interleave two lines' bytes.
+type structuredLogger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	format Format
+	fields []interface{}
+}
+
+// New builds a root Logger from opts.
+func New(opts Options) Logger {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	return &structuredLogger{
+		mu:     &sync.Mutex{},
+		out:    out,
+		level:  opts.Level,
+		format: opts.Format,
+	}
+}
+
+// Discard is a Logger that writes nothing, used as the fallback when a
+// context carries no logger at all (see FromContext).
+var Discard Logger = New(Options{Output: io.Discard})
+
+func (l *structuredLogger) With(keyvals ...interface{}) Logger {
+	return &structuredLogger{
+		mu:     l.mu,
+		out:    l.out,
+		level:  l.level,
+		format: l.format,
+		fields: append(append([]interface{}{}, l.fields...), keyvals...),
+	}
+}
+
+func (l *structuredLogger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *structuredLogger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *structuredLogger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *structuredLogger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+func (l *structuredLogger) log(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	all := append(append([]interface{}{}, l.fields...), keyvals...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.format == FormatJSON {
+		l.writeJSON(level, msg, all)
+	} else {
+		l.writeText(level, msg, all)
+	}
+}
+
+func (l *structuredLogger) writeText(level Level, msg string, keyvals []interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ts=%s level=%s msg=%q", time.Now().Format(time.RFC3339Nano), level, msg)
+	for _, kv := range pairs(keyvals) {
+		fmt.Fprintf(&b, " %s=%v", kv[0], kv[1])
+	}
+	b.WriteByte('\n')
+	io.WriteString(l.out, b.String())
+}
+
+func (l *structuredLogger) writeJSON(level Level, msg string, keyvals []interface{}) {
+	entry := make(map[string]interface{}, len(keyvals)/2+2)
+	entry["ts"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+	for _, kv := range pairs(keyvals) {
+		entry[kv[0].(string)] = kv[1]
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(l.out, "{\"level\":\"error\",\"msg\":%q}\n", "logging: failed to marshal entry: "+err.Error())
+		return
+	}
+	l.out.Write(append(encoded, '\n'))
+}
+
+// pairs walks keyvals two at a time, stringifying each key and tolerating
+// an odd-length list by reporting the dangling value under the key
+// "!BADKEY" rather than panicking - a caller typo shouldn't take down a
+// handler mid-request.
+func pairs(keyvals []interface{}) [][2]interface{} {
+	out := make([][2]interface{}, 0, len(keyvals)/2+1)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			out = append(out, [2]interface{}{fmt.Sprintf("%v", keyvals[i]), keyvals[i+1]})
+		} else {
+			out = append(out, [2]interface{}{"!BADKEY", keyvals[i]})
+		}
+	}
+	return out
+}